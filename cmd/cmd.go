@@ -1,10 +1,37 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/container"
 	"github.com/danielpnjt/speed-engine/internal/server"
 )
 
 func Run() {
+	if len(os.Args) > 1 {
+		runJob(container.New(), os.Args[1])
+		return
+	}
 	server.StartService(container.New())
 }
+
+// runJob runs a one-off maintenance job instead of the server, e.g. `speed-engine ledger-opening-balances`.
+func runJob(container *container.Container, job string) {
+	ctx := context.Background()
+	switch job {
+	case "ledger-opening-balances":
+		// * books the balances players had before the ledger existed, run once when it goes live
+		res, err := container.LedgerService.OpenBalances(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "ledger opening balances failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%+v\n", res.Data)
+	default:
+		slog.ErrorContext(ctx, "unknown job", "job", job)
+		os.Exit(1)
+	}
+}
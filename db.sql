@@ -3,8 +3,15 @@ CREATE TABLE public.users (
 	username VARCHAR(255) NOT NULL,
 	password VARCHAR(255) NOT NULL,
 	email VARCHAR(64) NOT NULL,
+	email_verified_at TIMESTAMPTZ NULL,
 	name VARCHAR(255) NOT NULL,
 	balance INT NOT NULL DEFAULT 0,
+	held_balance INT NOT NULL DEFAULT 0,
+	status VARCHAR(32) NOT NULL DEFAULT 'ACTIVE',
+	totp_secret VARCHAR(64) NOT NULL DEFAULT '',
+	totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+	kyc_level INT NOT NULL DEFAULT 0,
+	version INT NOT NULL DEFAULT 0,
 	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
 	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
 	deleted_at TIMESTAMPTZ NULL
@@ -15,7 +22,11 @@ CREATE TABLE public.banks (
 	user_id INT NOT NULL,
 	account_name VARCHAR(255) NOT NULL,
 	account_number VARCHAR(255) NOT NULL,
+	bank_code VARCHAR(32) NOT NULL,
 	bank_name VARCHAR(255) NOT NULL,
+	is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+	verified_name VARCHAR(255) NOT NULL DEFAULT '',
+	verified_at TIMESTAMPTZ NULL,
 	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
 	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
 	deleted_at TIMESTAMPTZ NULL
@@ -27,10 +38,217 @@ CREATE TABLE public.transactions (
 	bank_id INT NOT NULL,
 	amount INT NOT NULL,
 	type TEXT NOT NULL,
-	reference VARCHAR(255) NOT NULL,
+	reference VARCHAR(255) NOT NULL UNIQUE,
+	provider VARCHAR(32) NOT NULL DEFAULT '',
+	provider_reference VARCHAR(255) NOT NULL DEFAULT '',
+	fee INT NOT NULL DEFAULT 0,
+	fee_rule_id INT NULL,
 	status VARCHAR(255) NOT NULL,
 	expired_at TIMESTAMPTZ NOT NULL,
 	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
 	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
 	deleted_at TIMESTAMPTZ NULL
-)
\ No newline at end of file
+);
+
+CREATE INDEX transactions_status_expired_at_idx ON public.transactions (status, expired_at);
+CREATE INDEX transactions_user_type_created_at_idx ON public.transactions (user_id, type, created_at);
+
+CREATE TABLE public.transaction_status_history (
+	id serial4 NOT NULL,
+	transaction_id INT NOT NULL,
+	from_status VARCHAR(32) NOT NULL,
+	to_status VARCHAR(32) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+CREATE INDEX transaction_status_history_transaction_id_idx ON public.transaction_status_history (transaction_id);
+
+CREATE TABLE public.ledger_accounts (
+	id serial4 NOT NULL,
+	code VARCHAR(255) NOT NULL UNIQUE,
+	name VARCHAR(255) NOT NULL,
+	type VARCHAR(32) NOT NULL,
+	user_id INT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE TABLE public.journal_entries (
+	id serial4 NOT NULL,
+	transaction_id INT NOT NULL,
+	reference VARCHAR(255) NOT NULL,
+	description VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE TABLE public.postings (
+	id serial4 NOT NULL,
+	journal_entry_id INT NOT NULL,
+	ledger_account_id INT NOT NULL,
+	direction VARCHAR(8) NOT NULL,
+	amount INT NOT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX postings_ledger_account_id_idx ON public.postings (ledger_account_id);
+
+CREATE TABLE public.balance_holds (
+	id serial4 NOT NULL,
+	user_id INT NOT NULL,
+	transaction_id INT NOT NULL UNIQUE,
+	amount INT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	reason VARCHAR(255) NOT NULL DEFAULT '',
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE TABLE public.payment_callbacks (
+	id serial4 NOT NULL,
+	callback_id VARCHAR(255) NOT NULL UNIQUE,
+	type VARCHAR(32) NOT NULL,
+	reference VARCHAR(255) NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE TABLE public.balance_adjustments (
+	id serial4 NOT NULL,
+	user_id INT NOT NULL,
+	transaction_id INT NULL,
+	direction VARCHAR(16) NOT NULL,
+	amount INT NOT NULL,
+	reason TEXT NOT NULL,
+	status VARCHAR(32) NOT NULL,
+	proposed_by VARCHAR(255) NOT NULL,
+	reviewed_by VARCHAR(255) NOT NULL DEFAULT '',
+	review_note TEXT NOT NULL DEFAULT '',
+	reviewed_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE TABLE public.fee_rules (
+	id serial4 NOT NULL,
+	operation VARCHAR(32) NOT NULL,
+	provider VARCHAR(32) NOT NULL DEFAULT '',
+	bank_code VARCHAR(32) NOT NULL DEFAULT '',
+	min_amount INT NOT NULL DEFAULT 0,
+	max_amount INT NOT NULL DEFAULT 0,
+	flat_fee INT NOT NULL DEFAULT 0,
+	percent_fee NUMERIC(7, 4) NOT NULL DEFAULT 0,
+	min_fee INT NOT NULL DEFAULT 0,
+	max_fee INT NOT NULL DEFAULT 0,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_by VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deactivated_by VARCHAR(255) NOT NULL DEFAULT '',
+	deactivated_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX fee_rules_operation_idx ON public.fee_rules (operation) WHERE active;
+
+-- the flat top up fee charged before fee rules existed
+INSERT INTO public.fee_rules (operation, flat_fee, created_by) VALUES ('topup', 4500, 'system');
+
+CREATE TABLE public.admins (
+	id serial4 NOT NULL,
+	username VARCHAR(255) NOT NULL UNIQUE,
+	password VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	role VARCHAR(32) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	last_login_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	deleted_at TIMESTAMPTZ NULL
+);
+
+CREATE TABLE public.user_status_logs (
+	id serial4 NOT NULL,
+	user_id INT NOT NULL,
+	from_status VARCHAR(32) NOT NULL,
+	to_status VARCHAR(32) NOT NULL,
+	reason TEXT NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX users_email_key ON public.users (LOWER(email));
+
+CREATE INDEX banks_user_id_idx ON public.banks (user_id) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX banks_user_account_key ON public.banks (user_id, bank_code, account_number) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX banks_user_primary_key ON public.banks (user_id) WHERE is_primary AND deleted_at IS NULL;
+
+CREATE INDEX user_status_logs_user_id_idx ON public.user_status_logs (user_id);
+
+CREATE TABLE public.kyc_submissions (
+	id serial4 NOT NULL,
+	user_id INT NOT NULL,
+	full_name VARCHAR(255) NOT NULL,
+	national_id VARCHAR(32) NOT NULL,
+	date_of_birth DATE NOT NULL,
+	document_path VARCHAR(255) NOT NULL,
+	status VARCHAR(32) NOT NULL,
+	level INT NOT NULL DEFAULT 0,
+	reviewed_by VARCHAR(255) NOT NULL DEFAULT '',
+	review_note TEXT NOT NULL DEFAULT '',
+	reviewed_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NULL DEFAULT NOW()
+);
+
+CREATE INDEX kyc_submissions_user_id_idx ON public.kyc_submissions (user_id);
+CREATE INDEX kyc_submissions_national_id_idx ON public.kyc_submissions (national_id);
+
+CREATE TABLE public.user_recovery_codes (
+	id serial4 NOT NULL,
+	user_id INT NOT NULL,
+	code_hash VARCHAR(64) NOT NULL,
+	used_at TIMESTAMPTZ NULL,
+	created_at TIMESTAMPTZ NULL DEFAULT NOW()
+);
+
+CREATE INDEX user_recovery_codes_user_id_idx ON public.user_recovery_codes (user_id);
+
+CREATE TABLE public.audit_logs (
+	id serial4 NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	action VARCHAR(64) NOT NULL,
+	target_type VARCHAR(64) NOT NULL DEFAULT '',
+	target_id VARCHAR(255) NOT NULL DEFAULT '',
+	ip VARCHAR(64) NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	request_id VARCHAR(64) NOT NULL DEFAULT '',
+	before TEXT NOT NULL DEFAULT '',
+	after TEXT NOT NULL DEFAULT '',
+	prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+	hash VARCHAR(64) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX audit_logs_actor_idx ON public.audit_logs (actor);
+CREATE INDEX audit_logs_target_idx ON public.audit_logs (target_type, target_id);
+
+-- audit logs are append-only, rows can never be changed or removed
+CREATE FUNCTION public.audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+	BEFORE UPDATE OR DELETE ON public.audit_logs
+	FOR EACH ROW EXECUTE FUNCTION public.audit_logs_append_only();
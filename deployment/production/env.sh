@@ -1,6 +1,6 @@
 echo appName=speed_engine >> .env
 echo address=0.0.0.0 >> .env
-echo env=development >> .env
+echo env=production >> .env
 echo port=3021 >> .env
 echo version=v1 >> .env
 echo hashKey=DK!@_ >> .env
@@ -42,5 +42,60 @@ echo worker.taskExpiredInSecond=600 >> .env
 echo worker.speed_engine.retryCount=0 >> .env
 echo worker.speed_engine.retryTimeout=30 >> .env
 echo worker.speed_engine.delayFirstRetry=30s >> .env
-echo basicAuth.admin.username=admin >> .env
-echo basicAuth.admin.password=admin >> .env
\ No newline at end of file
+echo admin.bootstrap.username= >> .env
+echo admin.bootstrap.password= >> .env
+echo worker.speed_engine.withdrawHoldTimeout=30m >> .env
+echo payment.providers=midtrans >> .env
+echo payment.vaBankCode=BNI >> .env
+echo payment.vaExpiry=24h >> .env
+echo xendit.publicKey= >> .env
+echo xendit.secretKey= >> .env
+echo xendit.baseUrl=https://api.xendit.co >> .env
+echo xendit.timeout=30s >> .env
+echo xendit.callbackToken= >> .env
+echo xendit.callbackSignatureKey= >> .env
+echo payment.routes= >> .env
+echo midtrans.serverKey= >> .env
+echo midtrans.irisApiKey= >> .env
+echo midtrans.baseUrl=https://api.midtrans.com >> .env
+echo midtrans.irisBaseUrl=https://app.midtrans.com/iris >> .env
+echo midtrans.timeout=30s >> .env
+echo 'worker.speed_engine.expireTopUpSchedule=*/5 * * * *' >> .env
+echo worker.speed_engine.expireTopUpBatch=100 >> .env
+echo idempotency.ttl=24h >> .env
+echo audit.bufferSize=1024 >> .env
+echo session.accessTokenTtl=15m >> .env
+echo session.refreshTokenTtl=720h >> .env
+echo login.failureWindow=15m >> .env
+echo login.lockoutDuration=15m >> .env
+echo login.maxAttempts=10 >> .env
+echo login.maxAttemptsPerIp=50 >> .env
+echo password.resetTokenTtl=30m >> .env
+echo notifier.provider=log >> .env
+echo notifier.logDir= >> .env
+echo smtp.host= >> .env
+echo smtp.port=587 >> .env
+echo smtp.username= >> .env
+echo smtp.password= >> .env
+echo smtp.from= >> .env
+echo email.verificationTokenTtl=24h >> .env
+echo email.verificationPolicy=withdraw >> .env
+echo kyc.withdrawLimits=0,5000000,50000000 >> .env
+echo kyc.documentDir=storage/kyc >> .env
+echo kyc.maxDocumentSize=5242880 >> .env
+echo bank.maxAccounts=5 >> .env
+echo bank.nameMatchThreshold=0.8 >> .env
+echo limits.in.minAmount=10000 >> .env
+echo limits.in.maxAmount=50000000 >> .env
+echo limits.in.dailyCaps=20000000,100000000,500000000 >> .env
+echo limits.in.monthlyCaps=100000000,1000000000,5000000000 >> .env
+echo limits.out.minAmount=50000 >> .env
+echo limits.out.maxAmount=50000000 >> .env
+echo limits.out.dailyCaps=0,10000000,100000000 >> .env
+echo limits.out.monthlyCaps=0,100000000,1000000000 >> .env
+echo limits.velocity=in:1h:10,out:1h:5 >> .env
+echo worker.speed_engine.withdrawRecheckInterval=15m >> .env
+echo manual.bankCode= >> .env
+echo manual.accountNumber= >> .env
+echo manual.accountName= >> .env
+echo server.trustedProxies= >> .env
\ No newline at end of file
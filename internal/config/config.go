@@ -49,8 +49,32 @@ type RedisConfig struct {
 }
 
 type XenditConfig struct {
-	PublicKey string `json:"publicKey"`
-	SecretKey string `json:"secretKey"`
+	PublicKey string        `json:"publicKey"`
+	SecretKey string        `json:"secretKey"`
+	BaseURL   string        `json:"baseUrl"`
+	Timeout   time.Duration `json:"timeout"`
+}
+
+type MidtransConfig struct {
+	ServerKey   string        `json:"serverKey"`
+	IrisAPIKey  string        `json:"irisApiKey"`
+	BaseURL     string        `json:"baseUrl"`
+	IrisBaseURL string        `json:"irisBaseUrl"`
+	Timeout     time.Duration `json:"timeout"`
+}
+
+type ManualTransferConfig struct {
+	BankCode      string `json:"bankCode"`
+	AccountNumber string `json:"accountNumber"`
+	AccountName   string `json:"accountName"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
 }
 
 func init() {
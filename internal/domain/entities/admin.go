@@ -0,0 +1,76 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	AdminRoleViewer     = "viewer"
+	AdminRoleSupport    = "support"
+	AdminRoleFinance    = "finance"
+	AdminRoleSuperAdmin = "superadmin"
+
+	PermissionUserRead          = "user:read"
+	PermissionUserManage        = "user:manage"
+	PermissionTransactionRead   = "transaction:read"
+	PermissionTransactionSettle = "transaction:settle"
+	PermissionLedgerRead        = "ledger:read"
+	PermissionAdjustmentRead    = "adjustment:read"
+	PermissionAdjustmentPropose = "adjustment:propose"
+	PermissionAdjustmentReview  = "adjustment:review"
+	PermissionAdminManage       = "admin:manage"
+	PermissionAuditRead         = "audit:read"
+	PermissionKYCRead           = "kyc:read"
+	PermissionKYCReview         = "kyc:review"
+	PermissionFeeRead           = "fee:read"
+	PermissionFeeManage         = "fee:manage"
+)
+
+var readPermissions = []string{
+	PermissionUserRead,
+	PermissionTransactionRead,
+	PermissionLedgerRead,
+	PermissionAdjustmentRead,
+	PermissionKYCRead,
+	PermissionFeeRead,
+}
+
+// rolePermissions lists what each role may do. Support can block accounts, review identities and raise adjustments
+// but only finance moves money by reviewing them, confirming manual transfers and setting the fees, superadmin
+// additionally manages admin accounts and reads the audit log.
+var rolePermissions = map[string][]string{
+	AdminRoleViewer:     readPermissions,
+	AdminRoleSupport:    append(append([]string{}, readPermissions...), PermissionUserManage, PermissionKYCReview, PermissionAdjustmentPropose),
+	AdminRoleFinance:    append(append([]string{}, readPermissions...), PermissionUserManage, PermissionKYCReview, PermissionAdjustmentPropose, PermissionAdjustmentReview, PermissionTransactionSettle, PermissionFeeManage),
+	AdminRoleSuperAdmin: append(append([]string{}, readPermissions...), PermissionUserManage, PermissionKYCReview, PermissionAdjustmentPropose, PermissionAdjustmentReview, PermissionTransactionSettle, PermissionFeeManage, PermissionAdminManage, PermissionAuditRead),
+}
+
+type Admin struct {
+	ID          int        `db:"id" json:"id"`
+	Username    string     `db:"username" json:"username"`
+	Password    string     `db:"password" json:"-"`
+	Name        string     `db:"name" json:"name"`
+	Role        string     `db:"role" json:"role"`
+	Active      bool       `db:"active" json:"active"`
+	LastLoginAt *time.Time `db:"last_login_at" json:"lastLoginAt"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+// RoleHasPermission reports whether the role grants the permission, unknown roles grant nothing.
+func RoleHasPermission(role string, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminSessionKey is the redis key of an admin session, kept apart from player sessions
+// which are stored under their session id.
+func AdminSessionKey(username string) string {
+	return fmt.Sprintf("admin:%s", username)
+}
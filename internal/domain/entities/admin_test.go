@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleHasPermission(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{role: AdminRoleViewer, permission: PermissionTransactionRead, want: true},
+		{role: AdminRoleViewer, permission: PermissionAdjustmentPropose, want: false},
+		{role: AdminRoleSupport, permission: PermissionAdjustmentPropose, want: true},
+		{role: AdminRoleSupport, permission: PermissionAdjustmentReview, want: false},
+		{role: AdminRoleSupport, permission: PermissionKYCReview, want: true},
+		{role: AdminRoleViewer, permission: PermissionKYCReview, want: false},
+		{role: AdminRoleFinance, permission: PermissionAdjustmentReview, want: true},
+		{role: AdminRoleFinance, permission: PermissionAdminManage, want: false},
+		{role: AdminRoleFinance, permission: PermissionFeeManage, want: true},
+		{role: AdminRoleSupport, permission: PermissionFeeManage, want: false},
+		{role: AdminRoleFinance, permission: PermissionTransactionSettle, want: true},
+		{role: AdminRoleSupport, permission: PermissionTransactionSettle, want: false},
+		{role: AdminRoleSuperAdmin, permission: PermissionAdminManage, want: true},
+		{role: AdminRoleSuperAdmin, permission: PermissionAdjustmentReview, want: true},
+		{role: "", permission: PermissionUserRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.permission, func(t *testing.T) {
+			require.Equal(t, tt.want, RoleHasPermission(tt.role, tt.permission))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuditActionLogin                   = "LOGIN"
+	AuditActionLogout                  = "LOGOUT"
+	AuditActionRefreshTokenReuse       = "REFRESH_TOKEN_REUSE"
+	AuditActionUnlockLogin             = "UNLOCK_LOGIN"
+	AuditActionChangePassword          = "CHANGE_PASSWORD"
+	AuditActionForgotPassword          = "FORGOT_PASSWORD"
+	AuditActionResetPassword           = "RESET_PASSWORD"
+	AuditActionSubmitKYC               = "SUBMIT_KYC"
+	AuditActionReviewKYC               = "REVIEW_KYC"
+	AuditActionVerifyEmail             = "VERIFY_EMAIL"
+	AuditActionEnableTwoFactor         = "ENABLE_2FA"
+	AuditActionDisableTwoFactor        = "DISABLE_2FA"
+	AuditActionRegenerateRecoveryCodes = "REGENERATE_RECOVERY_CODES"
+	AuditActionSubmitBank              = "SUBMIT_BANK"
+	AuditActionUpdateBank              = "UPDATE_BANK"
+	AuditActionDeleteBank              = "DELETE_BANK"
+	AuditActionSetPrimaryBank          = "SET_PRIMARY_BANK"
+	AuditActionVerifyBank              = "VERIFY_BANK"
+	AuditActionCreateFeeRule           = "CREATE_FEE_RULE"
+	AuditActionDeactivateFeeRule       = "DEACTIVATE_FEE_RULE"
+	AuditActionWithdraw                = "WITHDRAW"
+	AuditActionSettleManualTransfer    = "SETTLE_MANUAL_TRANSFER"
+	AuditActionViewUser                = "VIEW_USER"
+	AuditActionChangeUserStatus        = "CHANGE_USER_STATUS"
+	AuditActionAdminLogin              = "ADMIN_LOGIN"
+	AuditActionAdminLogout             = "ADMIN_LOGOUT"
+	AuditActionCreateAdmin             = "CREATE_ADMIN"
+	AuditActionUpdateAdmin             = "UPDATE_ADMIN"
+	AuditActionReviewAdjustment        = "REVIEW_ADJUSTMENT"
+	AuditActionProposeAdjustment       = "PROPOSE_ADJUSTMENT"
+)
+
+// AuditSnapshot is a JSON document kept as text, so the bytes that were hashed are the bytes read back.
+type AuditSnapshot string
+
+func (s AuditSnapshot) MarshalJSON() ([]byte, error) {
+	if s == "" {
+		return []byte("null"), nil
+	}
+	return []byte(s), nil
+}
+
+// AuditLog is one append-only record of a sensitive action. Every record carries the hash of
+// the one before it, editing or removing a row breaks the chain from that row on.
+type AuditLog struct {
+	ID         int           `db:"id" json:"id"`
+	Actor      string        `db:"actor" json:"actor"`
+	Action     string        `db:"action" json:"action"`
+	TargetType string        `db:"target_type" json:"targetType"`
+	TargetID   string        `db:"target_id" json:"targetId"`
+	IP         string        `db:"ip" json:"ip"`
+	UserAgent  string        `db:"user_agent" json:"userAgent"`
+	RequestID  string        `db:"request_id" json:"requestId"`
+	Before     AuditSnapshot `db:"before" json:"before"`
+	After      AuditSnapshot `db:"after" json:"after"`
+	PrevHash   string        `db:"prev_hash" json:"prevHash"`
+	Hash       string        `db:"hash" json:"hash"`
+	CreatedAt  time.Time     `db:"created_at" json:"createdAt"`
+}
+
+// ComputeHash seals the record together with PrevHash. CreatedAt is hashed in UTC at
+// microsecond precision, which is what postgres stores.
+func (a AuditLog) ComputeHash() string {
+	fields := []string{
+		a.PrevHash,
+		a.Actor,
+		a.Action,
+		a.TargetType,
+		a.TargetID,
+		a.IP,
+		a.UserAgent,
+		a.RequestID,
+		string(a.Before),
+		string(a.After),
+		a.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	}
+	// * each field is length prefixed so moving text between fields changes the hash
+	var builder strings.Builder
+	for _, field := range fields {
+		builder.WriteString(strconv.Itoa(len(field)))
+		builder.WriteByte(':')
+		builder.WriteString(field)
+	}
+	sum := sha256.Sum256([]byte(builder.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"time"
+)
+
+const (
+	BalanceAdjustmentStatusPending  = "PENDING"
+	BalanceAdjustmentStatusApproved = "APPROVED"
+	BalanceAdjustmentStatusRejected = "REJECTED"
+
+	BalanceAdjustmentCredit = "CREDIT"
+	BalanceAdjustmentDebit  = "DEBIT"
+)
+
+// BalanceAdjustment is a manual credit or debit proposed by one admin. It only touches
+// the balance once a second admin approves it.
+type BalanceAdjustment struct {
+	ID            int        `db:"id" json:"id"`
+	UserID        int        `db:"user_id" json:"userId"`
+	TransactionID *int       `db:"transaction_id" json:"transactionId"`
+	Direction     string     `db:"direction" json:"direction"`
+	Amount        float64    `db:"amount" json:"amount"`
+	Reason        string     `db:"reason" json:"reason"`
+	Status        string     `db:"status" json:"status"`
+	ProposedBy    string     `db:"proposed_by" json:"proposedBy"`
+	ReviewedBy    string     `db:"reviewed_by" json:"reviewedBy"`
+	ReviewNote    string     `db:"review_note" json:"reviewNote"`
+	ReviewedAt    *time.Time `db:"reviewed_at" json:"reviewedAt"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt     *time.Time `db:"deleted_at" json:"deletedAt"`
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+)
+
+const (
+	BalanceHoldStatusHeld     = "HELD"
+	BalanceHoldStatusSettled  = "SETTLED"
+	BalanceHoldStatusReleased = "RELEASED"
+)
+
+type BalanceHold struct {
+	ID            int        `db:"id" json:"id"`
+	UserID        int        `db:"user_id" json:"userId"`
+	TransactionID int        `db:"transaction_id" json:"transactionId"`
+	Amount        float64    `db:"amount" json:"amount"`
+	Status        string     `db:"status" json:"status"`
+	Reason        string     `db:"reason" json:"reason"`
+	ExpiresAt     time.Time  `db:"expires_at" json:"expiresAt"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt     *time.Time `db:"deleted_at" json:"deletedAt"`
+}
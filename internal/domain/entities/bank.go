@@ -1,16 +1,77 @@
 package entities
 
 import (
+	"strings"
 	"time"
 )
 
+// BankCode is a bank players can withdraw to. Account numbers are digits only and their length
+// falls between MinLength and MaxLength.
+type BankCode struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	MinLength int    `json:"minLength"`
+	MaxLength int    `json:"maxLength"`
+}
+
+var bankCodes = []BankCode{
+	{Code: "BCA", Name: "Bank Central Asia", MinLength: 10, MaxLength: 10},
+	{Code: "BNI", Name: "Bank Negara Indonesia", MinLength: 10, MaxLength: 10},
+	{Code: "BRI", Name: "Bank Rakyat Indonesia", MinLength: 15, MaxLength: 15},
+	{Code: "MANDIRI", Name: "Bank Mandiri", MinLength: 13, MaxLength: 13},
+	{Code: "PERMATA", Name: "Bank Permata", MinLength: 10, MaxLength: 10},
+	{Code: "CIMB", Name: "CIMB Niaga", MinLength: 12, MaxLength: 14},
+	{Code: "BSI", Name: "Bank Syariah Indonesia", MinLength: 10, MaxLength: 10},
+	{Code: "DANAMON", Name: "Bank Danamon", MinLength: 10, MaxLength: 10},
+	{Code: "BTN", Name: "Bank Tabungan Negara", MinLength: 16, MaxLength: 16},
+}
+
+// BankCodes lists the banks players can add accounts at.
+func BankCodes() []BankCode {
+	return append([]BankCode{}, bankCodes...)
+}
+
+// FindBankCode looks a bank up by code, case insensitive.
+func FindBankCode(code string) (BankCode, bool) {
+	for _, bankCode := range bankCodes {
+		if strings.EqualFold(bankCode.Code, strings.TrimSpace(code)) {
+			return bankCode, true
+		}
+	}
+	return BankCode{}, false
+}
+
+// ValidAccountNumber reports whether the number fits the bank's account number format.
+func (b BankCode) ValidAccountNumber(number string) bool {
+	if len(number) < b.MinLength || len(number) > b.MaxLength {
+		return false
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Bank is a withdrawal account. BankCode is the code from the bank catalogue, which payouts
+// pass on as the provider bank code, BankName is only for display.
 type Bank struct {
 	ID            int        `db:"id" json:"id"`
 	UserID        int        `db:"user_id" json:"userId"`
 	AccountName   string     `db:"account_name" json:"accountName"`
 	AccountNumber string     `db:"account_number" json:"accountNumber"`
+	BankCode      string     `db:"bank_code" json:"bankCode"`
 	BankName      string     `db:"bank_name" json:"bankName"`
+	IsPrimary     bool       `db:"is_primary" json:"isPrimary"`
+	VerifiedName  string     `db:"verified_name" json:"verifiedName"`
+	VerifiedAt    *time.Time `db:"verified_at" json:"verifiedAt"`
 	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt     time.Time  `db:"updated_at" json:"updatedAt"`
 	DeletedAt     *time.Time `db:"deleted_at" json:"deletedAt"`
 }
+
+// Verified is true once a name inquiry matched the holder to the player, payouts wait for it.
+func (b Bank) Verified() bool {
+	return b.VerifiedAt != nil
+}
@@ -0,0 +1,106 @@
+package entities
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FeeOperationTopUp    = "topup"
+	FeeOperationWithdraw = "withdraw"
+)
+
+// FeeRule prices one kind of money movement. Empty Provider and BankCode and a zero MinAmount,
+// MaxAmount, MinFee or MaxFee leave that part open. Rules are never edited, a price change
+// deactivates the rule and creates a new one, so the rule a transaction was charged under
+// stays on record.
+type FeeRule struct {
+	ID            int        `db:"id" json:"id"`
+	Operation     string     `db:"operation" json:"operation"`
+	Provider      string     `db:"provider" json:"provider"`
+	BankCode      string     `db:"bank_code" json:"bankCode"`
+	MinAmount     float64    `db:"min_amount" json:"minAmount"`
+	MaxAmount     float64    `db:"max_amount" json:"maxAmount"`
+	FlatFee       float64    `db:"flat_fee" json:"flatFee"`
+	PercentFee    float64    `db:"percent_fee" json:"percentFee"`
+	MinFee        float64    `db:"min_fee" json:"minFee"`
+	MaxFee        float64    `db:"max_fee" json:"maxFee"`
+	Active        bool       `db:"active" json:"active"`
+	CreatedBy     string     `db:"created_by" json:"createdBy"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	DeactivatedBy string     `db:"deactivated_by" json:"deactivatedBy"`
+	DeactivatedAt *time.Time `db:"deactivated_at" json:"deactivatedAt"`
+}
+
+// Matches reports whether the rule applies to a movement of amount through the provider and bank.
+func (r FeeRule) Matches(operation string, provider string, bankCode string, amount float64) bool {
+	if r.Operation != operation {
+		return false
+	}
+	if r.Provider != "" && !strings.EqualFold(r.Provider, provider) {
+		return false
+	}
+	if r.BankCode != "" && !strings.EqualFold(r.BankCode, bankCode) {
+		return false
+	}
+	if r.MinAmount > 0 && amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// Charge is the fee on amount, the flat part plus the percentage kept within the caps and
+// rounded to whole rupiah.
+func (r FeeRule) Charge(amount float64) float64 {
+	fee := r.FlatFee + amount*r.PercentFee/100
+	if r.MinFee > 0 && fee < r.MinFee {
+		fee = r.MinFee
+	}
+	if r.MaxFee > 0 && fee > r.MaxFee {
+		fee = r.MaxFee
+	}
+	return math.Round(fee)
+}
+
+// specificity ranks matching rules, a provider or bank specific rule beats a generic one and
+// an amount tier beats an open one.
+func (r FeeRule) specificity() int {
+	score := 0
+	if r.Provider != "" {
+		score += 4
+	}
+	if r.BankCode != "" {
+		score += 2
+	}
+	if r.MinAmount > 0 || r.MaxAmount > 0 {
+		score++
+	}
+	return score
+}
+
+// SelectFeeRule picks the most specific rule matching the movement, between equally specific
+// rules the newest wins. ok is false when nothing matches and the movement is free.
+func SelectFeeRule(rules []FeeRule, operation string, provider string, bankCode string, amount float64) (rule FeeRule, ok bool) {
+	matching := make([]FeeRule, 0, len(rules))
+	for _, candidate := range rules {
+		if candidate.Active && candidate.Matches(operation, provider, bankCode, amount) {
+			matching = append(matching, candidate)
+		}
+	}
+	if len(matching) == 0 {
+		return
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		if matching[i].specificity() != matching[j].specificity() {
+			return matching[i].specificity() > matching[j].specificity()
+		}
+		return matching[i].ID > matching[j].ID
+	})
+	return matching[0], true
+}
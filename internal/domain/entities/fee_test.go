@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeRule_Charge(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   FeeRule
+		amount float64
+		want   float64
+	}{
+		{name: "flat", rule: FeeRule{FlatFee: 4500}, amount: 100000, want: 4500},
+		{name: "percentage", rule: FeeRule{PercentFee: 0.7}, amount: 100000, want: 700},
+		{name: "flat plus percentage", rule: FeeRule{FlatFee: 1000, PercentFee: 1}, amount: 50000, want: 1500},
+		{name: "minimum cap", rule: FeeRule{PercentFee: 0.5, MinFee: 2500}, amount: 100000, want: 2500},
+		{name: "maximum cap", rule: FeeRule{PercentFee: 1, MaxFee: 25000}, amount: 10000000, want: 25000},
+		{name: "rounded to whole rupiah", rule: FeeRule{PercentFee: 0.25}, amount: 10002, want: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.rule.Charge(tt.amount))
+		})
+	}
+}
+
+func TestSelectFeeRule(t *testing.T) {
+	rules := []FeeRule{
+		{ID: 1, Operation: FeeOperationTopUp, FlatFee: 4500, Active: true},
+		{ID: 2, Operation: FeeOperationTopUp, BankCode: "BCA", FlatFee: 4000, Active: true},
+		{ID: 3, Operation: FeeOperationTopUp, Provider: "midtrans", FlatFee: 3000, Active: true},
+		{ID: 4, Operation: FeeOperationTopUp, MinAmount: 5000000, FlatFee: 0, Active: true},
+		{ID: 5, Operation: FeeOperationWithdraw, FlatFee: 2500, Active: true},
+		{ID: 6, Operation: FeeOperationWithdraw, FlatFee: 6500, Active: false},
+		{ID: 7, Operation: FeeOperationWithdraw, FlatFee: 2000, Active: true},
+	}
+
+	tests := []struct {
+		name      string
+		operation string
+		provider  string
+		bankCode  string
+		amount    float64
+		wantID    int
+		wantOK    bool
+	}{
+		{name: "generic rule", operation: FeeOperationTopUp, provider: "xendit", bankCode: "BNI", amount: 100000, wantID: 1, wantOK: true},
+		{name: "bank beats generic", operation: FeeOperationTopUp, provider: "xendit", bankCode: "bca", amount: 100000, wantID: 2, wantOK: true},
+		{name: "provider beats bank", operation: FeeOperationTopUp, provider: "midtrans", bankCode: "BCA", amount: 100000, wantID: 3, wantOK: true},
+		{name: "amount tier beats generic", operation: FeeOperationTopUp, provider: "xendit", bankCode: "BNI", amount: 6000000, wantID: 4, wantOK: true},
+		{name: "newest wins a tie and inactive rules are skipped", operation: FeeOperationWithdraw, wantID: 7, wantOK: true},
+		{name: "nothing matches", operation: "transfer", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := SelectFeeRule(rules, tt.operation, tt.provider, tt.bankCode, tt.amount)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.wantID, rule.ID)
+		})
+	}
+}
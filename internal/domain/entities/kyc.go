@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"time"
+)
+
+const (
+	KYCStatusPending  = "PENDING"
+	KYCStatusApproved = "APPROVED"
+	KYCStatusRejected = "REJECTED"
+
+	// * a player starts unverified, each level raises how much one withdrawal may pay out
+	KYCLevelNone  = 0
+	KYCLevelBasic = 1
+	KYCLevelFull  = 2
+)
+
+// KYCSubmission is the identity a player hands in for review. Approving it grants the player
+// the reviewed level.
+type KYCSubmission struct {
+	ID           int        `db:"id" json:"id"`
+	UserID       int        `db:"user_id" json:"userId"`
+	FullName     string     `db:"full_name" json:"fullName"`
+	NationalID   string     `db:"national_id" json:"nationalId"`
+	DateOfBirth  time.Time  `db:"date_of_birth" json:"dateOfBirth"`
+	DocumentPath string     `db:"document_path" json:"-"`
+	Status       string     `db:"status" json:"status"`
+	Level        int        `db:"level" json:"level"`
+	ReviewedBy   string     `db:"reviewed_by" json:"reviewedBy"`
+	ReviewNote   string     `db:"review_note" json:"reviewNote"`
+	ReviewedAt   *time.Time `db:"reviewed_at" json:"reviewedAt"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updatedAt"`
+}
+
+// KYCWithdrawLimit is the largest single withdrawal the level allows, limits holds one entry per
+// level starting at KYCLevelNone and levels past the end get the last entry.
+func KYCWithdrawLimit(limits []float64, level int) float64 {
+	return levelValue(limits, level)
+}
+
+// levelValue picks the entry of a per kyc level list, see KYCWithdrawLimit.
+func levelValue(values []float64, level int) float64 {
+	if len(values) == 0 || level < 0 {
+		return 0
+	}
+	if level >= len(values) {
+		return values[len(values)-1]
+	}
+	return values[level]
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	LedgerAccountTypeAsset     = "asset"
+	LedgerAccountTypeLiability = "liability"
+	LedgerAccountTypeRevenue   = "revenue"
+	LedgerAccountTypeExpense   = "expense"
+	LedgerAccountTypeEquity    = "equity"
+
+	LedgerAccountProviderClearing  = "PROVIDER_CLEARING"
+	LedgerAccountFeeRevenue        = "FEE_REVENUE"
+	LedgerAccountBalanceAdjustment = "BALANCE_ADJUSTMENT"
+	LedgerAccountOpeningBalance    = "OPENING_BALANCE"
+
+	PostingDirectionDebit  = "debit"
+	PostingDirectionCredit = "credit"
+)
+
+type LedgerAccount struct {
+	ID        int        `db:"id" json:"id"`
+	Code      string     `db:"code" json:"code"`
+	Name      string     `db:"name" json:"name"`
+	Type      string     `db:"type" json:"type"`
+	UserID    *int       `db:"user_id" json:"userId"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+type JournalEntry struct {
+	ID            int        `db:"id" json:"id"`
+	TransactionID int        `db:"transaction_id" json:"transactionId"`
+	Reference     string     `db:"reference" json:"reference"`
+	Description   string     `db:"description" json:"description"`
+	Postings      []Posting  `db:"-" json:"postings"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt     *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+type Posting struct {
+	ID              int        `db:"id" json:"id"`
+	JournalEntryID  int        `db:"journal_entry_id" json:"journalEntryId"`
+	LedgerAccountID int        `db:"ledger_account_id" json:"ledgerAccountId"`
+	Direction       string     `db:"direction" json:"direction"`
+	Amount          float64    `db:"amount" json:"amount"`
+	CreatedAt       time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt       *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+// UserWalletAccountCode is the ledger account code holding a player's wallet balance.
+func UserWalletAccountCode(userID int) string {
+	return fmt.Sprintf("USER_WALLET:%d", userID)
+}
+
+// OpeningBalanceReference is the journal entry reference of a wallet's opening balance, a wallet
+// gets at most one.
+func OpeningBalanceReference(userID int) string {
+	return fmt.Sprintf("OPENING_BALANCE:%d", userID)
+}
@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"time"
+)
+
+// LimitPolicy bounds one transaction type. The amount of a single movement must fall between
+// MinAmount and MaxAmount, and the movements of a day or a calendar month add up to at most the
+// cap of the player's kyc level, DailyCaps and MonthlyCaps hold one entry per level like
+// KYCWithdrawLimit does.
+type LimitPolicy struct {
+	MinAmount   float64
+	MaxAmount   float64
+	DailyCaps   []float64
+	MonthlyCaps []float64
+}
+
+// TransactionLimit is a LimitPolicy resolved for one kyc level.
+type TransactionLimit struct {
+	MinAmount  float64 `json:"minAmount"`
+	MaxAmount  float64 `json:"maxAmount"`
+	DailyCap   float64 `json:"dailyCap"`
+	MonthlyCap float64 `json:"monthlyCap"`
+}
+
+// VelocityRule allows at most Max movements of the type per Window.
+type VelocityRule struct {
+	Type   string        `json:"type"`
+	Window time.Duration `json:"window"`
+	Max    int64         `json:"max"`
+}
+
+// ForLevel resolves the policy for a kyc level.
+func (p LimitPolicy) ForLevel(level int) TransactionLimit {
+	return TransactionLimit{
+		MinAmount:  p.MinAmount,
+		MaxAmount:  p.MaxAmount,
+		DailyCap:   levelValue(p.DailyCaps, level),
+		MonthlyCap: levelValue(p.MonthlyCaps, level),
+	}
+}
+
+// LimitDayStart is when the daily cap window containing now started, in the server's time zone.
+func LimitDayStart(now time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+}
+
+// LimitMonthStart is when the monthly cap window containing now started, in the server's time zone.
+func LimitMonthStart(now time.Time) time.Time {
+	year, month, _ := now.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+}
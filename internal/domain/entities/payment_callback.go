@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"time"
+)
+
+type PaymentCallback struct {
+	ID         int        `db:"id" json:"id"`
+	CallbackID string     `db:"callback_id" json:"callbackId"`
+	Type       string     `db:"type" json:"type"`
+	Reference  string     `db:"reference" json:"reference"`
+	Payload    string     `db:"payload" json:"payload"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt  *time.Time `db:"deleted_at" json:"deletedAt"`
+}
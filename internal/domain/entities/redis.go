@@ -1,19 +1,114 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 )
 
 type Login struct {
-	ID        int        `db:"id" json:"id"`
-	Username  string     `db:"username" json:"username"`
-	Password  string     `db:"password" json:"-"`
-	Email     string     `db:"email" json:"email"`
-	Name      string     `db:"name" json:"name"`
-	Balance   float64    `db:"balance" json:"balance"`
-	Token     string     `db:"-" json:"token"`
-	ExpireAt  int64      `db:"-" json:"expiredAt"`
-	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
-	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt"`
+	ID          int        `db:"id" json:"id"`
+	Username    string     `db:"username" json:"username"`
+	Password    string     `db:"password" json:"-"`
+	Email       string     `db:"email" json:"email"`
+	Name        string     `db:"name" json:"name"`
+	Balance     float64    `db:"balance" json:"balance"`
+	Status      string     `db:"status" json:"status"`
+	TOTPEnabled bool       `db:"-" json:"totpEnabled"`
+	SessionID   string     `db:"-" json:"sessionId"`
+	Token       string     `db:"-" json:"token"`
+	ExpireAt    int64      `db:"-" json:"expiredAt"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+type AdminLogin struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Token    string `json:"token"`
+	ExpireAt int64  `json:"expiredAt"`
+}
+
+// Session is one signed in device of a player. The refresh token is kept hashed and rotates
+// on every use, the hashes it rotated away from are remembered to spot a stolen token
+// being replayed.
+type Session struct {
+	ID                     string    `json:"id"`
+	UserID                 int       `json:"userId"`
+	Username               string    `json:"username"`
+	IP                     string    `json:"ip"`
+	UserAgent              string    `json:"userAgent"`
+	RefreshTokenHash       string    `json:"refreshTokenHash"`
+	UsedRefreshTokenHashes []string  `json:"usedRefreshTokenHashes"`
+	CreatedAt              time.Time `json:"createdAt"`
+	LastUsedAt             time.Time `json:"lastUsedAt"`
+	ExpireAt               int64     `json:"expiredAt"`
+}
+
+func SessionKey(sessionID string) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
+
+// UserSessionsKey holds the ids of every session a player has open.
+func UserSessionsKey(userID int) string {
+	return fmt.Sprintf("sessions:%d", userID)
+}
+
+const (
+	LoginScopeUsername = "username"
+	LoginScopeIP       = "ip"
+)
+
+// LoginFailureKey counts failed logins for a username or an ip within the failure window.
+func LoginFailureKey(scope string, value string) string {
+	return fmt.Sprintf("login-failure:%s:%s", scope, value)
+}
+
+// LoginBlockKey exists while logins for a username or an ip are held back, its ttl is the wait left.
+func LoginBlockKey(scope string, value string) string {
+	return fmt.Sprintf("login-block:%s:%s", scope, value)
+}
+
+// PasswordResetKey holds the hash of the player's outstanding password reset token, asking
+// for a new one replaces it.
+func PasswordResetKey(userID int) string {
+	return fmt.Sprintf("password-reset:%d", userID)
+}
+
+// LoginChallenge is a login that passed the password check and waits for the second factor.
+type LoginChallenge struct {
+	UserID   int    `json:"userId"`
+	Username string `json:"username"`
+}
+
+// LoginChallengeKey is keyed by the hash of the challenge token handed to the player.
+func LoginChallengeKey(tokenHash string) string {
+	return fmt.Sprintf("login-challenge:%s", tokenHash)
+}
+
+// TOTPSetupKey holds a secret the player is enrolling but has not confirmed with a code yet.
+func TOTPSetupKey(userID int) string {
+	return fmt.Sprintf("totp-setup:%d", userID)
+}
+
+// TOTPUsedKey marks a time step whose code the player already spent.
+func TOTPUsedKey(userID int, step int64) string {
+	return fmt.Sprintf("totp-used:%d:%d", userID, step)
+}
+
+// EmailVerificationKey holds the hash of the player's outstanding email verification token.
+func EmailVerificationKey(userID int) string {
+	return fmt.Sprintf("email-verification:%d", userID)
+}
+
+// BankInquiryKey counts the player's account name inquiries, each one is a paid provider call.
+func BankInquiryKey(userID int) string {
+	return fmt.Sprintf("bank-inquiry:%d", userID)
+}
+
+// VelocityKey counts how often the player started a movement of the type within the rule's window.
+func VelocityKey(transactionType string, window time.Duration, userID int) string {
+	return fmt.Sprintf("velocity:%s:%s:%d", transactionType, window, userID)
 }
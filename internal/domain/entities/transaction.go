@@ -1,19 +1,94 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 )
 
+const (
+	TransactionStatusPending   = "PENDING"
+	TransactionStatusCompleted = "COMPLETED"
+	TransactionStatusExpired   = "EXPIRED"
+	TransactionStatusFailed    = "FAILED"
+	TransactionStatusReversed  = "REVERSED"
+)
+
+const (
+	TransactionTypeIn            = "in"
+	TransactionTypeOut           = "out"
+	TransactionTypeAdjustmentIn  = "adjustment_in"
+	TransactionTypeAdjustmentOut = "adjustment_out"
+)
+
+const (
+	TransactionActorSystem   = "system"
+	TransactionActorCallback = "callback"
+)
+
+// transactionTransitions lists where each status may move next, a status missing here is final.
+var transactionTransitions = map[string][]string{
+	TransactionStatusPending: {
+		TransactionStatusCompleted,
+		TransactionStatusExpired,
+		TransactionStatusFailed,
+	},
+	TransactionStatusCompleted: {TransactionStatusReversed},
+}
+
 type Transaction struct {
-	ID        int        `db:"id" json:"id"`
-	UserID    int        `db:"user_id" json:"userId"`
-	BankID    int        `db:"bank_id" json:"bankId"`
-	Amount    float64    `db:"amount" json:"amount"`
-	Type      string     `db:"type" json:"type"`
-	Reference string     `db:"reference" json:"reference"`
-	Status    string     `db:"status" json:"status"`
-	ExpiredAt time.Time  `db:"expired_at" json:"expiredAt"`
-	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
-	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt"`
+	ID                int        `db:"id" json:"id"`
+	UserID            int        `db:"user_id" json:"userId"`
+	BankID            int        `db:"bank_id" json:"bankId"`
+	Amount            float64    `db:"amount" json:"amount"`
+	Type              string     `db:"type" json:"type"`
+	Reference         string     `db:"reference" json:"reference"`
+	Provider          string     `db:"provider" json:"provider"`
+	ProviderReference string     `db:"provider_reference" json:"providerReference"`
+	Fee               float64    `db:"fee" json:"fee"`
+	FeeRuleID         *int       `db:"fee_rule_id" json:"feeRuleId"`
+	Status            string     `db:"status" json:"status"`
+	ExpiredAt         time.Time  `db:"expired_at" json:"expiredAt"`
+	CreatedAt         time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt         *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+func (t Transaction) CanTransitionTo(status string) bool {
+	for _, next := range transactionTransitions[t.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return status == TransactionStatusCompleted && t.CanSettleLate()
+}
+
+// CanSettleLate reports whether the transaction is a top up that expired or failed and can still
+// be completed by a late payment. Callers complete it only once the provider's status API
+// confirmed that payment.
+func (t Transaction) CanSettleLate() bool {
+	return t.Type == TransactionTypeIn && (t.Status == TransactionStatusExpired || t.Status == TransactionStatusFailed)
+}
+
+// TransactionStatusHistory is one accepted status transition of a transaction.
+type TransactionStatusHistory struct {
+	ID            int        `db:"id" json:"id"`
+	TransactionID int        `db:"transaction_id" json:"transactionId"`
+	FromStatus    string     `db:"from_status" json:"fromStatus"`
+	ToStatus      string     `db:"to_status" json:"toStatus"`
+	Actor         string     `db:"actor" json:"actor"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt     *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+func (TransactionStatusHistory) TableName() string {
+	return "transaction_status_history"
+}
+
+func UserActor(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func AdminActor(username string) string {
+	return fmt.Sprintf("admin:%s", username)
 }
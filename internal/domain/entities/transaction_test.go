@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		txType string
+		from   string
+		to     string
+		want   bool
+	}{
+		{from: TransactionStatusPending, to: TransactionStatusCompleted, want: true},
+		{from: TransactionStatusPending, to: TransactionStatusExpired, want: true},
+		{from: TransactionStatusPending, to: TransactionStatusFailed, want: true},
+		{from: TransactionStatusPending, to: TransactionStatusReversed, want: false},
+		{from: TransactionStatusPending, to: TransactionStatusPending, want: false},
+		{from: TransactionStatusCompleted, to: TransactionStatusReversed, want: true},
+		{from: TransactionStatusCompleted, to: TransactionStatusFailed, want: false},
+		{from: TransactionStatusExpired, to: TransactionStatusCompleted, want: false},
+		{from: TransactionStatusExpired, to: TransactionStatusPending, want: false},
+		{from: TransactionStatusFailed, to: TransactionStatusCompleted, want: false},
+		{from: TransactionStatusFailed, to: TransactionStatusPending, want: false},
+		{txType: TransactionTypeIn, from: TransactionStatusExpired, to: TransactionStatusCompleted, want: true},
+		{txType: TransactionTypeIn, from: TransactionStatusFailed, to: TransactionStatusCompleted, want: true},
+		{txType: TransactionTypeIn, from: TransactionStatusExpired, to: TransactionStatusFailed, want: false},
+		{txType: TransactionTypeOut, from: TransactionStatusFailed, to: TransactionStatusCompleted, want: false},
+		{from: TransactionStatusReversed, to: TransactionStatusCompleted, want: false},
+		{from: "ACTIVE", to: TransactionStatusCompleted, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.txType+" "+tt.from+" to "+tt.to, func(t *testing.T) {
+			require.Equal(t, tt.want, Transaction{Type: tt.txType, Status: tt.from}.CanTransitionTo(tt.to))
+		})
+	}
+}
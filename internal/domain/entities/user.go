@@ -4,14 +4,77 @@ import (
 	"time"
 )
 
+const (
+	UserStatusActive    = "ACTIVE"
+	UserStatusFrozen    = "FROZEN"
+	UserStatusSuspended = "SUSPENDED"
+	UserStatusClosed    = "CLOSED"
+
+	// * how much money movement waits for a verified email
+	EmailPolicyNone     = "none"
+	EmailPolicyWithdraw = "withdraw"
+	EmailPolicyAll      = "all"
+)
+
 type User struct {
+	ID              int        `db:"id" json:"id"`
+	Username        string     `db:"username" json:"username"`
+	Password        string     `db:"password" json:"-"`
+	Email           string     `db:"email" json:"email"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"emailVerifiedAt"`
+	Name            string     `db:"name" json:"name"`
+	Balance         float64    `db:"balance" json:"balance"`
+	HeldBalance     float64    `db:"held_balance" json:"heldBalance"`
+	Status          string     `db:"status" json:"status"`
+	TOTPSecret      string     `db:"totp_secret" json:"-"`
+	TOTPEnabled     bool       `db:"totp_enabled" json:"totpEnabled"`
+	KYCLevel        int        `db:"kyc_level" json:"kycLevel"`
+	Version         int        `db:"version" json:"-"`
+	CreatedAt       time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updatedAt"`
+	DeletedAt       *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+// AvailableBalance is what the player can still spend once pending withdrawals are held back.
+func (u User) AvailableBalance() float64 {
+	return u.Balance - u.HeldBalance
+}
+
+// CanLogin is false for suspended and closed accounts, a frozen player can still sign in.
+func (u User) CanLogin() bool {
+	return u.Status != UserStatusSuspended && u.Status != UserStatusClosed
+}
+
+// CanMoveMoney is false for any account that is not active, new top ups and withdrawals are refused.
+func (u User) CanMoveMoney() bool {
+	return u.CanLogin() && u.Status != UserStatusFrozen
+}
+
+// EmailVerificationRequired reports whether the policy holds the money movement back until the
+// player verifies their email, top ups only wait under EmailPolicyAll.
+func (u User) EmailVerificationRequired(policy string, withdrawal bool) bool {
+	if u.EmailVerifiedAt != nil {
+		return false
+	}
+	return policy == EmailPolicyAll || (policy == EmailPolicyWithdraw && withdrawal)
+}
+
+// UserStatusLog records who changed a player's account status and why.
+type UserStatusLog struct {
+	ID         int       `db:"id" json:"id"`
+	UserID     int       `db:"user_id" json:"userId"`
+	FromStatus string    `db:"from_status" json:"fromStatus"`
+	ToStatus   string    `db:"to_status" json:"toStatus"`
+	Reason     string    `db:"reason" json:"reason"`
+	Actor      string    `db:"actor" json:"actor"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+}
+
+// UserRecoveryCode is a single use code that stands in for the authenticator app, only its hash is kept.
+type UserRecoveryCode struct {
 	ID        int        `db:"id" json:"id"`
-	Username  string     `db:"username" json:"username"`
-	Password  string     `db:"password" json:"-"`
-	Email     string     `db:"email" json:"email"`
-	Name      string     `db:"name" json:"name"`
-	Balance   float64    `db:"balance" json:"balance"`
+	UserID    int        `db:"user_id" json:"userId"`
+	CodeHash  string     `db:"code_hash" json:"-"`
+	UsedAt    *time.Time `db:"used_at" json:"usedAt"`
 	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
-	UpdatedAt time.Time  `db:"updated_at" json:"updatedAt"`
-	DeletedAt *time.Time `db:"deleted_at" json:"deletedAt"`
 }
@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser_EmailVerificationRequired(t *testing.T) {
+	verifiedAt := time.Now()
+	tests := []struct {
+		name       string
+		user       User
+		policy     string
+		withdrawal bool
+		want       bool
+	}{
+		{name: "verified player moves money freely", user: User{EmailVerifiedAt: &verifiedAt}, policy: EmailPolicyAll, withdrawal: true, want: false},
+		{name: "no policy", user: User{}, policy: EmailPolicyNone, withdrawal: true, want: false},
+		{name: "withdraw policy holds withdrawals", user: User{}, policy: EmailPolicyWithdraw, withdrawal: true, want: true},
+		{name: "withdraw policy lets top ups through", user: User{}, policy: EmailPolicyWithdraw, withdrawal: false, want: false},
+		{name: "all policy holds top ups", user: User{}, policy: EmailPolicyAll, withdrawal: false, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.user.EmailVerificationRequired(tt.policy, tt.withdrawal))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+type Admin interface {
+	Create(ctx context.Context, entity *entities.Admin) (err error)
+	Update(ctx context.Context, entity *entities.Admin) (err error)
+	FindByUsername(ctx context.Context, username string) (admin entities.Admin, err error)
+	FindByID(ctx context.Context, id int) (admin entities.Admin, err error)
+	FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.Admin, count int64, err error)
+}
+
+type admin struct {
+	db *gorm.DB
+}
+
+func NewAdmin(db *gorm.DB) Admin {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &admin{db: db}
+}
+
+func (r *admin) Create(ctx context.Context, entity *entities.Admin) (err error) {
+	err = conn(ctx, r.db).Create(entity).Error
+	return
+}
+
+func (r *admin) Update(ctx context.Context, entity *entities.Admin) (err error) {
+	err = conn(ctx, r.db).Save(entity).Error
+	return
+}
+
+func (r *admin) FindByUsername(ctx context.Context, username string) (admin entities.Admin, err error) {
+	err = conn(ctx, r.db).Where(&entities.Admin{Username: username}).First(&admin).Error
+	return
+}
+
+func (r *admin) FindByID(ctx context.Context, id int) (admin entities.Admin, err error) {
+	err = conn(ctx, r.db).Where(&entities.Admin{ID: id}).First(&admin).Error
+	return
+}
+
+func (r *admin) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.Admin, count int64, err error) {
+	limit := pagination.Limit
+	offset := (pagination.Page - 1) * pagination.Limit
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (egErr error) {
+		queryPayload := conn(egCtx, r.db).Limit(int(limit)).Offset(int(offset)).Order("id asc")
+		return utils.CompileConds(queryPayload, conds...).Find(&result).Error
+	})
+	eg.Go(func() (egErr error) {
+		countPayload := conn(egCtx, r.db).Model(&entities.Admin{})
+		return utils.CompileConds(countPayload, conds...).Count(&count).Error
+	})
+	err = eg.Wait()
+	return
+}
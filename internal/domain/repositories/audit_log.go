@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey is the postgres advisory lock serialising appends to the hash chain
+// across every instance of the service.
+const auditChainLockKey = 7366532
+
+type AuditLog interface {
+	Append(ctx context.Context, entity *entities.AuditLog) (err error)
+	FindAfterID(ctx context.Context, id int, limit int) (result []entities.AuditLog, err error)
+	FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.AuditLog, count int64, err error)
+}
+
+type auditLog struct {
+	db *gorm.DB
+}
+
+func NewAuditLog(db *gorm.DB) AuditLog {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &auditLog{db: db}
+}
+
+// Append links the record to the latest one and inserts it, PrevHash and Hash are set here.
+func (r *auditLog) Append(ctx context.Context, entity *entities.AuditLog) (err error) {
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) (err error) {
+		err = tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error
+		if err != nil {
+			return
+		}
+
+		var last []entities.AuditLog
+		err = tx.Order("id desc").Limit(1).Find(&last).Error
+		if err != nil {
+			return
+		}
+		entity.PrevHash = ""
+		if len(last) > 0 {
+			entity.PrevHash = last[0].Hash
+		}
+		entity.Hash = entity.ComputeHash()
+		return tx.Create(entity).Error
+	})
+	return
+}
+
+func (r *auditLog) FindAfterID(ctx context.Context, id int, limit int) (result []entities.AuditLog, err error) {
+	err = conn(ctx, r.db).Where("id > ?", id).Order("id asc").Limit(limit).Find(&result).Error
+	return
+}
+
+func (r *auditLog) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.AuditLog, count int64, err error) {
+	limit := pagination.Limit
+	offset := (pagination.Page - 1) * pagination.Limit
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (egErr error) {
+		queryPayload := conn(egCtx, r.db).Limit(int(limit)).Offset(int(offset)).Order("id desc")
+		return utils.CompileConds(queryPayload, conds...).Find(&result).Error
+	})
+	eg.Go(func() (egErr error) {
+		countPayload := conn(egCtx, r.db).Model(&entities.AuditLog{})
+		return utils.CompileConds(countPayload, conds...).Count(&count).Error
+	})
+	err = eg.Wait()
+	return
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BalanceAdjustment interface {
+	Create(ctx context.Context, entity *entities.BalanceAdjustment) (err error)
+	Update(ctx context.Context, entity *entities.BalanceAdjustment) (err error)
+	FindByIDForUpdate(ctx context.Context, id int) (adjustment entities.BalanceAdjustment, err error)
+	FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.BalanceAdjustment, count int64, err error)
+}
+
+type balanceAdjustment struct {
+	db *gorm.DB
+}
+
+func NewBalanceAdjustment(db *gorm.DB) BalanceAdjustment {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &balanceAdjustment{db: db}
+}
+
+func (r *balanceAdjustment) Create(ctx context.Context, entity *entities.BalanceAdjustment) (err error) {
+	err = conn(ctx, r.db).Create(entity).Error
+	return
+}
+
+func (r *balanceAdjustment) Update(ctx context.Context, entity *entities.BalanceAdjustment) (err error) {
+	err = conn(ctx, r.db).Save(entity).Error
+	return
+}
+
+func (r *balanceAdjustment) FindByIDForUpdate(ctx context.Context, id int) (adjustment entities.BalanceAdjustment, err error) {
+	err = conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where(&entities.BalanceAdjustment{ID: id}).First(&adjustment).Error
+	return
+}
+
+func (r *balanceAdjustment) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.BalanceAdjustment, count int64, err error) {
+	limit := pagination.Limit
+	offset := (pagination.Page - 1) * pagination.Limit
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (egErr error) {
+		queryPayload := conn(egCtx, r.db).Limit(int(limit)).Offset(int(offset)).Order("created_at desc, id desc")
+		return utils.CompileConds(queryPayload, conds...).Find(&result).Error
+	})
+	eg.Go(func() (egErr error) {
+		countPayload := conn(egCtx, r.db).Model(&entities.BalanceAdjustment{})
+		return utils.CompileConds(countPayload, conds...).Count(&count).Error
+	})
+	err = eg.Wait()
+	return
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BalanceHold interface {
+	Create(ctx context.Context, entity *entities.BalanceHold) (err error)
+	Update(ctx context.Context, entity *entities.BalanceHold) (err error)
+	FindByTransactionIDForUpdate(ctx context.Context, transactionID int) (hold entities.BalanceHold, err error)
+}
+
+type balanceHold struct {
+	db *gorm.DB
+}
+
+func NewBalanceHold(db *gorm.DB) BalanceHold {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &balanceHold{db: db}
+}
+
+func (r *balanceHold) Create(ctx context.Context, entity *entities.BalanceHold) (err error) {
+	err = conn(ctx, r.db).Create(entity).Error
+	return
+}
+
+func (r *balanceHold) Update(ctx context.Context, entity *entities.BalanceHold) (err error) {
+	err = conn(ctx, r.db).Save(entity).Error
+	return
+}
+
+func (r *balanceHold) FindByTransactionIDForUpdate(ctx context.Context, transactionID int) (hold entities.BalanceHold, err error) {
+	err = conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where(&entities.BalanceHold{TransactionID: transactionID}).First(&hold).Error
+	return
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"gorm.io/gorm"
@@ -9,8 +10,15 @@ import (
 
 type Bank interface {
 	Create(ctx context.Context, entity *entities.Bank) (err error)
+	Update(ctx context.Context, entity *entities.Bank) (err error)
+	UpdateVerification(ctx context.Context, entity *entities.Bank) (err error)
+	Delete(ctx context.Context, entity *entities.Bank) (err error)
+	SetPrimary(ctx context.Context, entity *entities.Bank) (err error)
 	FindByUserID(ctx context.Context, userID int) (bank []entities.Bank, err error)
 	FindByID(ctx context.Context, id int) (bank entities.Bank, err error)
+	FindByIDAndUserID(ctx context.Context, id int, userID int) (bank entities.Bank, err error)
+	CountByUserID(ctx context.Context, userID int) (count int64, err error)
+	ExistsAccount(ctx context.Context, userID int, bankCode string, accountNumber string, excludeID int) (exists bool, err error)
 }
 
 type bank struct {
@@ -26,16 +34,105 @@ func NewBank(db *gorm.DB) Bank {
 }
 
 func (r *bank) Create(ctx context.Context, entity *entities.Bank) (err error) {
-	err = r.db.WithContext(ctx).Create(&entity).Error
+	err = conn(ctx, r.db).Create(&entity).Error
+	return
+}
+
+func (r *bank) Update(ctx context.Context, entity *entities.Bank) (err error) {
+	err = conn(ctx, r.db).Model(&entities.Bank{}).
+		Where("id = ? AND deleted_at IS NULL", entity.ID).
+		Updates(map[string]interface{}{
+			"account_name":   entity.AccountName,
+			"account_number": entity.AccountNumber,
+			"bank_code":      entity.BankCode,
+			"bank_name":      entity.BankName,
+			"verified_name":  entity.VerifiedName,
+			"verified_at":    entity.VerifiedAt,
+			"updated_at":     time.Now(),
+		}).Error
+	return
+}
+
+// UpdateVerification stores the outcome of a name inquiry, the holder name found replaces the
+// one the player typed.
+func (r *bank) UpdateVerification(ctx context.Context, entity *entities.Bank) (err error) {
+	err = conn(ctx, r.db).Model(&entities.Bank{}).
+		Where("id = ? AND deleted_at IS NULL", entity.ID).
+		Updates(map[string]interface{}{
+			"account_name":  entity.AccountName,
+			"verified_name": entity.VerifiedName,
+			"verified_at":   entity.VerifiedAt,
+			"updated_at":    time.Now(),
+		}).Error
+	return
+}
+
+// Delete soft deletes the account, withdrawals made to it keep pointing at the row.
+func (r *bank) Delete(ctx context.Context, entity *entities.Bank) (err error) {
+	now := time.Now()
+	err = conn(ctx, r.db).Model(&entities.Bank{}).
+		Where("id = ? AND deleted_at IS NULL", entity.ID).
+		Updates(map[string]interface{}{
+			"is_primary": false,
+			"deleted_at": now,
+			"updated_at": now,
+		}).Error
+	entity.IsPrimary = false
+	entity.DeletedAt = &now
+	return
+}
+
+// SetPrimary makes the account the player's only primary one.
+func (r *bank) SetPrimary(ctx context.Context, entity *entities.Bank) (err error) {
+	err = conn(ctx, r.db).Model(&entities.Bank{}).
+		Where("user_id = ? AND is_primary AND id <> ?", entity.UserID, entity.ID).
+		Updates(map[string]interface{}{
+			"is_primary": false,
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		return
+	}
+	err = conn(ctx, r.db).Model(&entities.Bank{}).
+		Where("id = ? AND deleted_at IS NULL", entity.ID).
+		Updates(map[string]interface{}{
+			"is_primary": true,
+			"updated_at": time.Now(),
+		}).Error
+	entity.IsPrimary = true
 	return
 }
 
 func (r *bank) FindByUserID(ctx context.Context, userID int) (bank []entities.Bank, err error) {
-	err = r.db.WithContext(ctx).Where(&entities.Bank{UserID: userID}).Find(&bank).Error
+	err = conn(ctx, r.db).Where(&entities.Bank{UserID: userID}).Where("deleted_at IS NULL").
+		Order("is_primary desc, id asc").Find(&bank).Error
 	return
 }
 
+// FindByID also finds deleted accounts, it serves lookups from past transactions.
 func (r *bank) FindByID(ctx context.Context, id int) (bank entities.Bank, err error) {
-	err = r.db.WithContext(ctx).Where(&entities.Bank{ID: id}).First(&bank).Error
+	err = conn(ctx, r.db).Where(&entities.Bank{ID: id}).First(&bank).Error
+	return
+}
+
+// FindByIDAndUserID finds an account the player still holds, use it for anything the player acts on.
+func (r *bank) FindByIDAndUserID(ctx context.Context, id int, userID int) (bank entities.Bank, err error) {
+	err = conn(ctx, r.db).Where(&entities.Bank{ID: id, UserID: userID}).Where("deleted_at IS NULL").First(&bank).Error
+	return
+}
+
+func (r *bank) CountByUserID(ctx context.Context, userID int) (count int64, err error) {
+	err = conn(ctx, r.db).Model(&entities.Bank{}).Where("user_id = ? AND deleted_at IS NULL", userID).Count(&count).Error
+	return
+}
+
+// ExistsAccount reports whether the player already holds the account at the bank, excludeID
+// skips the account being edited.
+func (r *bank) ExistsAccount(ctx context.Context, userID int, bankCode string, accountNumber string, excludeID int) (exists bool, err error) {
+	var count int64
+	err = conn(ctx, r.db).Model(&entities.Bank{}).
+		Where("user_id = ? AND bank_code = ? AND account_number = ? AND id <> ? AND deleted_at IS NULL", userID, bankCode, accountNumber, excludeID).
+		Count(&count).Error
+	exists = count > 0
 	return
 }
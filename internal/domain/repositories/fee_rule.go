@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FeeRule interface {
+	Create(ctx context.Context, entity *entities.FeeRule) (err error)
+	Update(ctx context.Context, entity *entities.FeeRule) (err error)
+	FindByIDForUpdate(ctx context.Context, id int) (rule entities.FeeRule, err error)
+	FindActiveByOperation(ctx context.Context, operation string) (rules []entities.FeeRule, err error)
+	FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.FeeRule, count int64, err error)
+}
+
+type feeRule struct {
+	db *gorm.DB
+}
+
+func NewFeeRule(db *gorm.DB) FeeRule {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &feeRule{db: db}
+}
+
+func (r *feeRule) Create(ctx context.Context, entity *entities.FeeRule) (err error) {
+	err = conn(ctx, r.db).Create(entity).Error
+	return
+}
+
+func (r *feeRule) Update(ctx context.Context, entity *entities.FeeRule) (err error) {
+	err = conn(ctx, r.db).Save(entity).Error
+	return
+}
+
+func (r *feeRule) FindByIDForUpdate(ctx context.Context, id int) (rule entities.FeeRule, err error) {
+	err = conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where(&entities.FeeRule{ID: id}).First(&rule).Error
+	return
+}
+
+func (r *feeRule) FindActiveByOperation(ctx context.Context, operation string) (rules []entities.FeeRule, err error) {
+	err = conn(ctx, r.db).Where("operation = ? AND active", operation).Find(&rules).Error
+	return
+}
+
+func (r *feeRule) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.FeeRule, count int64, err error) {
+	limit := pagination.Limit
+	offset := (pagination.Page - 1) * pagination.Limit
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (egErr error) {
+		queryPayload := conn(egCtx, r.db).Limit(int(limit)).Offset(int(offset)).Order("id desc")
+		return utils.CompileConds(queryPayload, conds...).Find(&result).Error
+	})
+	eg.Go(func() (egErr error) {
+		countPayload := conn(egCtx, r.db).Model(&entities.FeeRule{})
+		return utils.CompileConds(countPayload, conds...).Count(&count).Error
+	})
+	err = eg.Wait()
+	return
+}
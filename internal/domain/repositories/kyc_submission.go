@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type KYCSubmission interface {
+	Create(ctx context.Context, entity *entities.KYCSubmission) (err error)
+	Update(ctx context.Context, entity *entities.KYCSubmission) (err error)
+	FindByID(ctx context.Context, id int) (submission entities.KYCSubmission, err error)
+	FindByIDForUpdate(ctx context.Context, id int) (submission entities.KYCSubmission, err error)
+	FindLatestByUserID(ctx context.Context, userID int) (submission entities.KYCSubmission, err error)
+	ExistsApprovedNationalID(ctx context.Context, nationalID string, excludeUserID int) (exists bool, err error)
+	FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.KYCSubmission, count int64, err error)
+}
+
+type kycSubmission struct {
+	db *gorm.DB
+}
+
+func NewKYCSubmission(db *gorm.DB) KYCSubmission {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &kycSubmission{db: db}
+}
+
+func (r *kycSubmission) Create(ctx context.Context, entity *entities.KYCSubmission) (err error) {
+	err = conn(ctx, r.db).Create(entity).Error
+	return
+}
+
+func (r *kycSubmission) Update(ctx context.Context, entity *entities.KYCSubmission) (err error) {
+	err = conn(ctx, r.db).Save(entity).Error
+	return
+}
+
+func (r *kycSubmission) FindByID(ctx context.Context, id int) (submission entities.KYCSubmission, err error) {
+	err = conn(ctx, r.db).Where(&entities.KYCSubmission{ID: id}).First(&submission).Error
+	return
+}
+
+func (r *kycSubmission) FindByIDForUpdate(ctx context.Context, id int) (submission entities.KYCSubmission, err error) {
+	err = conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where(&entities.KYCSubmission{ID: id}).First(&submission).Error
+	return
+}
+
+func (r *kycSubmission) FindLatestByUserID(ctx context.Context, userID int) (submission entities.KYCSubmission, err error) {
+	err = conn(ctx, r.db).Where(&entities.KYCSubmission{UserID: userID}).Order("created_at desc, id desc").First(&submission).Error
+	return
+}
+
+// ExistsApprovedNationalID reports whether another player already verified with the national id.
+func (r *kycSubmission) ExistsApprovedNationalID(ctx context.Context, nationalID string, excludeUserID int) (exists bool, err error) {
+	var count int64
+	err = conn(ctx, r.db).Model(&entities.KYCSubmission{}).
+		Where("national_id = ? AND status = ? AND user_id <> ?", nationalID, entities.KYCStatusApproved, excludeUserID).
+		Count(&count).Error
+	exists = count > 0
+	return
+}
+
+func (r *kycSubmission) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.KYCSubmission, count int64, err error) {
+	limit := pagination.Limit
+	offset := (pagination.Page - 1) * pagination.Limit
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (egErr error) {
+		queryPayload := conn(egCtx, r.db).Limit(int(limit)).Offset(int(offset)).Order("created_at asc, id asc")
+		return utils.CompileConds(queryPayload, conds...).Find(&result).Error
+	})
+	eg.Go(func() (egErr error) {
+		countPayload := conn(egCtx, r.db).Model(&entities.KYCSubmission{})
+		return utils.CompileConds(countPayload, conds...).Count(&count).Error
+	})
+	err = eg.Wait()
+	return
+}
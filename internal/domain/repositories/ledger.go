@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+type Ledger interface {
+	FirstOrCreateAccount(ctx context.Context, entity *entities.LedgerAccount) (err error)
+	FindAccountByCode(ctx context.Context, code string) (account entities.LedgerAccount, err error)
+	CreateEntry(ctx context.Context, entity *entities.JournalEntry) (err error)
+	ExistsEntryByReference(ctx context.Context, reference string) (exists bool, err error)
+	FindEntriesByAccountID(ctx context.Context, accountID int) (entries []entities.JournalEntry, err error)
+	SumPostings(ctx context.Context, accountID int) (debit float64, credit float64, err error)
+}
+
+type ledger struct {
+	db *gorm.DB
+}
+
+func NewLedger(db *gorm.DB) Ledger {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &ledger{db: db}
+}
+
+func (r *ledger) FirstOrCreateAccount(ctx context.Context, entity *entities.LedgerAccount) (err error) {
+	err = conn(ctx, r.db).Where(&entities.LedgerAccount{Code: entity.Code}).FirstOrCreate(entity).Error
+	return
+}
+
+func (r *ledger) FindAccountByCode(ctx context.Context, code string) (account entities.LedgerAccount, err error) {
+	err = conn(ctx, r.db).Where(&entities.LedgerAccount{Code: code}).First(&account).Error
+	return
+}
+
+// CreateEntry stores the journal entry, gorm saves its postings as an association in the same transaction.
+func (r *ledger) CreateEntry(ctx context.Context, entity *entities.JournalEntry) (err error) {
+	err = conn(ctx, r.db).Create(entity).Error
+	return
+}
+
+func (r *ledger) ExistsEntryByReference(ctx context.Context, reference string) (exists bool, err error) {
+	var count int64
+	err = conn(ctx, r.db).Model(&entities.JournalEntry{}).Where(&entities.JournalEntry{Reference: reference}).Count(&count).Error
+	exists = count > 0
+	return
+}
+
+func (r *ledger) FindEntriesByAccountID(ctx context.Context, accountID int) (entries []entities.JournalEntry, err error) {
+	err = conn(ctx, r.db).
+		Where("id IN (?)", r.db.Model(&entities.Posting{}).Select("journal_entry_id").Where(&entities.Posting{LedgerAccountID: accountID})).
+		Preload("Postings").
+		Order("id desc").
+		Find(&entries).Error
+	return
+}
+
+func (r *ledger) SumPostings(ctx context.Context, accountID int) (debit float64, credit float64, err error) {
+	var result struct {
+		Debit  float64
+		Credit float64
+	}
+	err = conn(ctx, r.db).Model(&entities.Posting{}).
+		Select("COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE 0 END), 0) AS debit, COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE 0 END), 0) AS credit", entities.PostingDirectionDebit, entities.PostingDirectionCredit).
+		Where(&entities.Posting{LedgerAccountID: accountID}).
+		Scan(&result).Error
+	debit = result.Debit
+	credit = result.Credit
+	return
+}
@@ -0,0 +1,181 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/repositories/bank.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	entities "github.com/danielpnjt/speed-engine/internal/domain/entities"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBank is a mock of Bank interface.
+type MockBank struct {
+	ctrl     *gomock.Controller
+	recorder *MockBankMockRecorder
+}
+
+// MockBankMockRecorder is the mock recorder for MockBank.
+type MockBankMockRecorder struct {
+	mock *MockBank
+}
+
+// NewMockBank creates a new mock instance.
+func NewMockBank(ctrl *gomock.Controller) *MockBank {
+	mock := &MockBank{ctrl: ctrl}
+	mock.recorder = &MockBankMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBank) EXPECT() *MockBankMockRecorder {
+	return m.recorder
+}
+
+// CountByUserID mocks base method.
+func (m *MockBank) CountByUserID(ctx context.Context, userID int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByUserID", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByUserID indicates an expected call of CountByUserID.
+func (mr *MockBankMockRecorder) CountByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByUserID", reflect.TypeOf((*MockBank)(nil).CountByUserID), ctx, userID)
+}
+
+// Create mocks base method.
+func (m *MockBank) Create(ctx context.Context, entity *entities.Bank) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBankMockRecorder) Create(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBank)(nil).Create), ctx, entity)
+}
+
+// Delete mocks base method.
+func (m *MockBank) Delete(ctx context.Context, entity *entities.Bank) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBankMockRecorder) Delete(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBank)(nil).Delete), ctx, entity)
+}
+
+// ExistsAccount mocks base method.
+func (m *MockBank) ExistsAccount(ctx context.Context, userID int, bankCode, accountNumber string, excludeID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistsAccount", ctx, userID, bankCode, accountNumber, excludeID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistsAccount indicates an expected call of ExistsAccount.
+func (mr *MockBankMockRecorder) ExistsAccount(ctx, userID, bankCode, accountNumber, excludeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsAccount", reflect.TypeOf((*MockBank)(nil).ExistsAccount), ctx, userID, bankCode, accountNumber, excludeID)
+}
+
+// FindByID mocks base method.
+func (m *MockBank) FindByID(ctx context.Context, id int) (entities.Bank, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(entities.Bank)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockBankMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockBank)(nil).FindByID), ctx, id)
+}
+
+// FindByIDAndUserID mocks base method.
+func (m *MockBank) FindByIDAndUserID(ctx context.Context, id, userID int) (entities.Bank, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDAndUserID", ctx, id, userID)
+	ret0, _ := ret[0].(entities.Bank)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDAndUserID indicates an expected call of FindByIDAndUserID.
+func (mr *MockBankMockRecorder) FindByIDAndUserID(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDAndUserID", reflect.TypeOf((*MockBank)(nil).FindByIDAndUserID), ctx, id, userID)
+}
+
+// FindByUserID mocks base method.
+func (m *MockBank) FindByUserID(ctx context.Context, userID int) ([]entities.Bank, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserID", ctx, userID)
+	ret0, _ := ret[0].([]entities.Bank)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserID indicates an expected call of FindByUserID.
+func (mr *MockBankMockRecorder) FindByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockBank)(nil).FindByUserID), ctx, userID)
+}
+
+// SetPrimary mocks base method.
+func (m *MockBank) SetPrimary(ctx context.Context, entity *entities.Bank) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPrimary", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPrimary indicates an expected call of SetPrimary.
+func (mr *MockBankMockRecorder) SetPrimary(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrimary", reflect.TypeOf((*MockBank)(nil).SetPrimary), ctx, entity)
+}
+
+// Update mocks base method.
+func (m *MockBank) Update(ctx context.Context, entity *entities.Bank) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockBankMockRecorder) Update(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBank)(nil).Update), ctx, entity)
+}
+
+// UpdateVerification mocks base method.
+func (m *MockBank) UpdateVerification(ctx context.Context, entity *entities.Bank) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVerification", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVerification indicates an expected call of UpdateVerification.
+func (mr *MockBankMockRecorder) UpdateVerification(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVerification", reflect.TypeOf((*MockBank)(nil).UpdateVerification), ctx, entity)
+}
@@ -0,0 +1,222 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/domain/repositories/transaction.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	entities "github.com/danielpnjt/speed-engine/internal/domain/entities"
+	constants "github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	utils "github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTransaction is a mock of Transaction interface.
+type MockTransaction struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionMockRecorder
+}
+
+// MockTransactionMockRecorder is the mock recorder for MockTransaction.
+type MockTransactionMockRecorder struct {
+	mock *MockTransaction
+}
+
+// NewMockTransaction creates a new mock instance.
+func NewMockTransaction(ctrl *gomock.Controller) *MockTransaction {
+	mock := &MockTransaction{ctrl: ctrl}
+	mock.recorder = &MockTransactionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransaction) EXPECT() *MockTransactionMockRecorder {
+	return m.recorder
+}
+
+// CountPendingByUserID mocks base method.
+func (m *MockTransaction) CountPendingByUserID(ctx context.Context, userID int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPendingByUserID", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPendingByUserID indicates an expected call of CountPendingByUserID.
+func (mr *MockTransactionMockRecorder) CountPendingByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPendingByUserID", reflect.TypeOf((*MockTransaction)(nil).CountPendingByUserID), ctx, userID)
+}
+
+// Create mocks base method.
+func (m *MockTransaction) Create(ctx context.Context, entity *entities.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTransactionMockRecorder) Create(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTransaction)(nil).Create), ctx, entity)
+}
+
+// FindAllAndCount mocks base method.
+func (m *MockTransaction) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) ([]entities.Transaction, int64, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, pagination}
+	for _, a := range conds {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAllAndCount", varargs...)
+	ret0, _ := ret[0].([]entities.Transaction)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllAndCount indicates an expected call of FindAllAndCount.
+func (mr *MockTransactionMockRecorder) FindAllAndCount(ctx, pagination interface{}, conds ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, pagination}, conds...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllAndCount", reflect.TypeOf((*MockTransaction)(nil).FindAllAndCount), varargs...)
+}
+
+// FindByID mocks base method.
+func (m *MockTransaction) FindByID(ctx context.Context, id int) (entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockTransactionMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockTransaction)(nil).FindByID), ctx, id)
+}
+
+// FindByReference mocks base method.
+func (m *MockTransaction) FindByReference(ctx context.Context, reference string) (entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByReference", ctx, reference)
+	ret0, _ := ret[0].(entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByReference indicates an expected call of FindByReference.
+func (mr *MockTransactionMockRecorder) FindByReference(ctx, reference interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByReference", reflect.TypeOf((*MockTransaction)(nil).FindByReference), ctx, reference)
+}
+
+// FindByReferenceForUpdate mocks base method.
+func (m *MockTransaction) FindByReferenceForUpdate(ctx context.Context, reference string) (entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByReferenceForUpdate", ctx, reference)
+	ret0, _ := ret[0].(entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByReferenceForUpdate indicates an expected call of FindByReferenceForUpdate.
+func (mr *MockTransactionMockRecorder) FindByReferenceForUpdate(ctx, reference interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByReferenceForUpdate", reflect.TypeOf((*MockTransaction)(nil).FindByReferenceForUpdate), ctx, reference)
+}
+
+// FindByUserID mocks base method.
+func (m *MockTransaction) FindByUserID(ctx context.Context, userID int) ([]entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserID", ctx, userID)
+	ret0, _ := ret[0].([]entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserID indicates an expected call of FindByUserID.
+func (mr *MockTransactionMockRecorder) FindByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockTransaction)(nil).FindByUserID), ctx, userID)
+}
+
+// FindExpiredPendingTopUps mocks base method.
+func (m *MockTransaction) FindExpiredPendingTopUps(ctx context.Context, before time.Time, limit int) ([]entities.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExpiredPendingTopUps", ctx, before, limit)
+	ret0, _ := ret[0].([]entities.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExpiredPendingTopUps indicates an expected call of FindExpiredPendingTopUps.
+func (mr *MockTransactionMockRecorder) FindExpiredPendingTopUps(ctx, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExpiredPendingTopUps", reflect.TypeOf((*MockTransaction)(nil).FindExpiredPendingTopUps), ctx, before, limit)
+}
+
+// FindStatusHistory mocks base method.
+func (m *MockTransaction) FindStatusHistory(ctx context.Context, transactionID int) ([]entities.TransactionStatusHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStatusHistory", ctx, transactionID)
+	ret0, _ := ret[0].([]entities.TransactionStatusHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStatusHistory indicates an expected call of FindStatusHistory.
+func (mr *MockTransactionMockRecorder) FindStatusHistory(ctx, transactionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStatusHistory", reflect.TypeOf((*MockTransaction)(nil).FindStatusHistory), ctx, transactionID)
+}
+
+// SumAmountByUserSince mocks base method.
+func (m *MockTransaction) SumAmountByUserSince(ctx context.Context, userID int, transactionType string, since time.Time) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SumAmountByUserSince", ctx, userID, transactionType, since)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SumAmountByUserSince indicates an expected call of SumAmountByUserSince.
+func (mr *MockTransactionMockRecorder) SumAmountByUserSince(ctx, userID, transactionType, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SumAmountByUserSince", reflect.TypeOf((*MockTransaction)(nil).SumAmountByUserSince), ctx, userID, transactionType, since)
+}
+
+// Update mocks base method.
+func (m *MockTransaction) Update(ctx context.Context, entity *entities.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockTransactionMockRecorder) Update(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTransaction)(nil).Update), ctx, entity)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockTransaction) UpdateStatus(ctx context.Context, entity *entities.Transaction, status, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, entity, status, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockTransactionMockRecorder) UpdateStatus(ctx, entity, status, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockTransaction)(nil).UpdateStatus), ctx, entity, status, actor)
+}
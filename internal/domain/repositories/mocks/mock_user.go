@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: /Users/daniel/Desktop/danielpnjt/speed-engine/internal/domain/repositories/user.go
+// Source: internal/domain/repositories/user.go
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -72,6 +72,36 @@ func (mr *MockUserMockRecorder) FindAllAndCount(ctx, pagination interface{}, con
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllAndCount", reflect.TypeOf((*MockUser)(nil).FindAllAndCount), varargs...)
 }
 
+// FindAllIDs mocks base method.
+func (m *MockUser) FindAllIDs(ctx context.Context) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllIDs", ctx)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllIDs indicates an expected call of FindAllIDs.
+func (mr *MockUserMockRecorder) FindAllIDs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllIDs", reflect.TypeOf((*MockUser)(nil).FindAllIDs), ctx)
+}
+
+// FindByEmail mocks base method.
+func (m *MockUser) FindByEmail(ctx context.Context, email string) (entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByEmail", ctx, email)
+	ret0, _ := ret[0].(entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByEmail indicates an expected call of FindByEmail.
+func (mr *MockUserMockRecorder) FindByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUser)(nil).FindByEmail), ctx, email)
+}
+
 // FindByID mocks base method.
 func (m *MockUser) FindByID(ctx context.Context, id int) (entities.User, error) {
 	m.ctrl.T.Helper()
@@ -87,6 +117,21 @@ func (mr *MockUserMockRecorder) FindByID(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockUser)(nil).FindByID), ctx, id)
 }
 
+// FindByIDForUpdate mocks base method.
+func (m *MockUser) FindByIDForUpdate(ctx context.Context, id int) (entities.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(entities.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDForUpdate indicates an expected call of FindByIDForUpdate.
+func (mr *MockUserMockRecorder) FindByIDForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockUser)(nil).FindByIDForUpdate), ctx, id)
+}
+
 // FindByUsername mocks base method.
 func (m *MockUser) FindByUsername(ctx context.Context, username string) (entities.User, error) {
 	m.ctrl.T.Helper()
@@ -101,3 +146,87 @@ func (mr *MockUserMockRecorder) FindByUsername(ctx, username interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUsername", reflect.TypeOf((*MockUser)(nil).FindByUsername), ctx, username)
 }
+
+// MarkEmailVerified mocks base method.
+func (m *MockUser) MarkEmailVerified(ctx context.Context, entity *entities.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserMockRecorder) MarkEmailVerified(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUser)(nil).MarkEmailVerified), ctx, entity)
+}
+
+// UpdateBalance mocks base method.
+func (m *MockUser) UpdateBalance(ctx context.Context, entity *entities.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBalance", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBalance indicates an expected call of UpdateBalance.
+func (mr *MockUserMockRecorder) UpdateBalance(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBalance", reflect.TypeOf((*MockUser)(nil).UpdateBalance), ctx, entity)
+}
+
+// UpdateKYCLevel mocks base method.
+func (m *MockUser) UpdateKYCLevel(ctx context.Context, entity *entities.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateKYCLevel", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateKYCLevel indicates an expected call of UpdateKYCLevel.
+func (mr *MockUserMockRecorder) UpdateKYCLevel(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateKYCLevel", reflect.TypeOf((*MockUser)(nil).UpdateKYCLevel), ctx, entity)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUser) UpdatePassword(ctx context.Context, entity *entities.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserMockRecorder) UpdatePassword(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUser)(nil).UpdatePassword), ctx, entity)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockUser) UpdateStatus(ctx context.Context, entity *entities.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockUserMockRecorder) UpdateStatus(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockUser)(nil).UpdateStatus), ctx, entity)
+}
+
+// UpdateTOTP mocks base method.
+func (m *MockUser) UpdateTOTP(ctx context.Context, entity *entities.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTOTP", ctx, entity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTOTP indicates an expected call of UpdateTOTP.
+func (mr *MockUserMockRecorder) UpdateTOTP(ctx, entity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTOTP", reflect.TypeOf((*MockUser)(nil).UpdateTOTP), ctx, entity)
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentCallback interface {
+	CreateIfNotExists(ctx context.Context, entity *entities.PaymentCallback) (isNew bool, err error)
+}
+
+type paymentCallback struct {
+	db *gorm.DB
+}
+
+func NewPaymentCallback(db *gorm.DB) PaymentCallback {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &paymentCallback{db: db}
+}
+
+// CreateIfNotExists relies on the unique callback_id, a redelivered callback inserts nothing.
+func (r *paymentCallback) CreateIfNotExists(ctx context.Context, entity *entities.PaymentCallback) (isNew bool, err error) {
+	result := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "callback_id"}},
+		DoNothing: true,
+	}).Create(entity)
+	err = result.Error
+	isNew = result.RowsAffected > 0
+	return
+}
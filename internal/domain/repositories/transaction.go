@@ -2,16 +2,30 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Transaction interface {
 	Create(ctx context.Context, entity *entities.Transaction) (err error)
-	FindByUserID(ctx context.Context, userID int) (transaction entities.Transaction, err error)
+	Update(ctx context.Context, entity *entities.Transaction) (err error)
+	UpdateStatus(ctx context.Context, entity *entities.Transaction, status string, actor string) (err error)
+	FindStatusHistory(ctx context.Context, transactionID int) (history []entities.TransactionStatusHistory, err error)
+	FindByUserID(ctx context.Context, userID int) (transactions []entities.Transaction, err error)
+	FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.Transaction, count int64, err error)
 	FindByID(ctx context.Context, id int) (transaction entities.Transaction, err error)
 	FindByReference(ctx context.Context, reference string) (transaction entities.Transaction, err error)
+	FindByReferenceForUpdate(ctx context.Context, reference string) (transaction entities.Transaction, err error)
+	FindExpiredPendingTopUps(ctx context.Context, before time.Time, limit int) (transactions []entities.Transaction, err error)
+	SumAmountByUserSince(ctx context.Context, userID int, transactionType string, since time.Time) (total float64, err error)
+	CountPendingByUserID(ctx context.Context, userID int) (count int64, err error)
 }
 
 type transaction struct {
@@ -27,21 +41,126 @@ func NewTransaction(db *gorm.DB) Transaction {
 }
 
 func (r *transaction) Create(ctx context.Context, entity *entities.Transaction) (err error) {
-	err = r.db.WithContext(ctx).Create(&entity).Error
+	err = conn(ctx, r.db).Create(&entity).Error
 	return
 }
 
-func (r *transaction) FindByUserID(ctx context.Context, userID int) (transaction entities.Transaction, err error) {
-	err = r.db.WithContext(ctx).Where(&entities.Transaction{UserID: userID}).First(&transaction).Error
+// Update saves everything but the status, which only changes through UpdateStatus.
+func (r *transaction) Update(ctx context.Context, entity *entities.Transaction) (err error) {
+	err = conn(ctx, r.db).Omit("status").Save(entity).Error
+	return
+}
+
+// UpdateStatus moves the transaction to status and records the transition. The row is only
+// updated while it still has the status the caller read, a concurrent transition wins.
+func (r *transaction) UpdateStatus(ctx context.Context, entity *entities.Transaction, status string, actor string) (err error) {
+	if !entity.CanTransitionTo(status) {
+		err = fmt.Errorf("%w: %s to %s", constants.ErrInvalidTransition, entity.Status, status)
+		return
+	}
+
+	now := time.Now()
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.Transaction{}).
+			Where("id = ? AND status = ?", entity.ID, entity.Status).
+			Updates(map[string]interface{}{"status": status, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return constants.ErrConcurrentUpdate
+		}
+
+		return tx.Create(&entities.TransactionStatusHistory{
+			TransactionID: entity.ID,
+			FromStatus:    entity.Status,
+			ToStatus:      status,
+			Actor:         actor,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}).Error
+	})
+	if err != nil {
+		return
+	}
+
+	entity.Status = status
+	entity.UpdatedAt = now
+	return
+}
+
+func (r *transaction) FindStatusHistory(ctx context.Context, transactionID int) (history []entities.TransactionStatusHistory, err error) {
+	err = conn(ctx, r.db).Where(&entities.TransactionStatusHistory{TransactionID: transactionID}).Order("created_at, id").Find(&history).Error
+	return
+}
+
+func (r *transaction) FindByUserID(ctx context.Context, userID int) (transactions []entities.Transaction, err error) {
+	err = conn(ctx, r.db).Where(&entities.Transaction{UserID: userID}).Order("created_at desc, id desc").Find(&transactions).Error
+	return
+}
+
+// FindAllAndCount pages through transactions by creation time, newest first unless pagination asks for asc.
+func (r *transaction) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.Transaction, count int64, err error) {
+	limit := pagination.Limit
+	offset := (pagination.Page - 1) * pagination.Limit
+	desc := pagination.Order == nil || *pagination.Order != "asc"
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (egErr error) {
+		queryPayload := conn(egCtx, r.db).Limit(int(limit)).Offset(int(offset)).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: desc}).
+			Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+		return utils.CompileConds(queryPayload, conds...).Find(&result).Error
+	})
+	eg.Go(func() (egErr error) {
+		countPayload := conn(egCtx, r.db).Model(&entities.Transaction{})
+		return utils.CompileConds(countPayload, conds...).Count(&count).Error
+	})
+	err = eg.Wait()
 	return
 }
 
 func (r *transaction) FindByID(ctx context.Context, id int) (transaction entities.Transaction, err error) {
-	err = r.db.WithContext(ctx).Where(&entities.Transaction{ID: id}).First(&transaction).Error
+	err = conn(ctx, r.db).Where(&entities.Transaction{ID: id}).First(&transaction).Error
 	return
 }
 
 func (r *transaction) FindByReference(ctx context.Context, reference string) (transaction entities.Transaction, err error) {
-	err = r.db.WithContext(ctx).Where(&entities.Transaction{Reference: reference}).First(&transaction).Error
+	err = conn(ctx, r.db).Where(&entities.Transaction{Reference: reference}).First(&transaction).Error
+	return
+}
+
+// FindByReferenceForUpdate locks the transaction row until the surrounding unit of work finishes.
+func (r *transaction) FindByReferenceForUpdate(ctx context.Context, reference string) (transaction entities.Transaction, err error) {
+	err = conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where(&entities.Transaction{Reference: reference}).First(&transaction).Error
+	return
+}
+
+// FindExpiredPendingTopUps returns unpaid top ups whose virtual account expired before the given time, oldest first.
+func (r *transaction) FindExpiredPendingTopUps(ctx context.Context, before time.Time, limit int) (transactions []entities.Transaction, err error) {
+	err = conn(ctx, r.db).
+		Where("type = ? AND status = ? AND expired_at < ?", "in", entities.TransactionStatusPending, before).
+		Order("expired_at").
+		Limit(limit).
+		Find(&transactions).Error
+	return
+}
+
+// SumAmountByUserSince adds up the player's movements of the type created since the given time that
+// still count against their limits, pending ones included so they can't be stacked past a cap.
+func (r *transaction) SumAmountByUserSince(ctx context.Context, userID int, transactionType string, since time.Time) (total float64, err error) {
+	err = conn(ctx, r.db).
+		Model(&entities.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ? AND type = ? AND created_at >= ?", userID, transactionType, since).
+		Where("status NOT IN ?", []string{entities.TransactionStatusExpired, entities.TransactionStatusFailed, entities.TransactionStatusReversed}).
+		Scan(&total).Error
+	return
+}
+
+func (r *transaction) CountPendingByUserID(ctx context.Context, userID int) (count int64, err error) {
+	err = conn(ctx, r.db).
+		Model(&entities.Transaction{}).
+		Where(&entities.Transaction{UserID: userID, Status: entities.TransactionStatusPending}).
+		Count(&count).Error
 	return
 }
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"gorm.io/gorm"
+)
+
+const txContextKey = types.String("tx")
+
+// UnitOfWork runs a set of repository calls inside one database transaction.
+// Repositories pick the transaction up from the context passed to fn, so
+// usecases keep calling them exactly as they do outside a unit of work.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) (err error)
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	// * nested calls join the outer transaction instead of opening a new one
+	if _, ok := ctx.Value(txContextKey).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	err = u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey, tx))
+	})
+	return
+}
+
+// conn returns the transaction bound to ctx by UnitOfWork.Do, or db when there is none.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
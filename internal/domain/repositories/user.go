@@ -2,19 +2,30 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type User interface {
 	Create(ctx context.Context, entity *entities.User) (err error)
 	FindByUsername(ctx context.Context, username string) (user entities.User, err error)
+	FindByEmail(ctx context.Context, email string) (user entities.User, err error)
 	FindByID(ctx context.Context, id int) (user entities.User, err error)
+	FindByIDForUpdate(ctx context.Context, id int) (user entities.User, err error)
+	UpdateBalance(ctx context.Context, entity *entities.User) (err error)
+	UpdateStatus(ctx context.Context, entity *entities.User) (err error)
+	UpdatePassword(ctx context.Context, entity *entities.User) (err error)
+	UpdateTOTP(ctx context.Context, entity *entities.User) (err error)
+	MarkEmailVerified(ctx context.Context, entity *entities.User) (err error)
+	UpdateKYCLevel(ctx context.Context, entity *entities.User) (err error)
 	FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (result []entities.User, count int64, err error)
+	FindAllIDs(ctx context.Context) (ids []int, err error)
 }
 
 type user struct {
@@ -30,17 +41,104 @@ func NewUser(db *gorm.DB) User {
 }
 
 func (r *user) Create(ctx context.Context, entity *entities.User) (err error) {
-	err = r.db.WithContext(ctx).Save(&entity).Error
+	err = conn(ctx, r.db).Save(&entity).Error
 	return
 }
 
 func (r *user) FindByUsername(ctx context.Context, username string) (user entities.User, err error) {
-	err = r.db.WithContext(ctx).Where(&entities.User{Username: username}).First(&user).Error
+	err = conn(ctx, r.db).Where(&entities.User{Username: username}).First(&user).Error
+	return
+}
+
+// FindByEmail matches case insensitively, emails are stored lower cased but older rows may not be.
+func (r *user) FindByEmail(ctx context.Context, email string) (user entities.User, err error) {
+	err = conn(ctx, r.db).Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	return
 }
 
 func (r *user) FindByID(ctx context.Context, id int) (user entities.User, err error) {
-	err = r.db.WithContext(ctx).Where(&entities.User{ID: id}).First(&user).Error
+	err = conn(ctx, r.db).Where(&entities.User{ID: id}).First(&user).Error
+	return
+}
+
+// FindByIDForUpdate locks the user row until the surrounding unit of work finishes.
+func (r *user) FindByIDForUpdate(ctx context.Context, id int) (user entities.User, err error) {
+	err = conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Where(&entities.User{ID: id}).First(&user).Error
+	return
+}
+
+// UpdateBalance writes the balance and held balance only if nobody changed the row since it was read,
+// returning constants.ErrConcurrentUpdate otherwise.
+func (r *user) UpdateBalance(ctx context.Context, entity *entities.User) (err error) {
+	result := conn(ctx, r.db).Model(&entities.User{}).
+		Where("id = ? AND version = ?", entity.ID, entity.Version).
+		Updates(map[string]interface{}{
+			"balance":      entity.Balance,
+			"held_balance": entity.HeldBalance,
+			"version":      entity.Version + 1,
+			"updated_at":   time.Now(),
+		})
+	if err = result.Error; err != nil {
+		return
+	}
+	if result.RowsAffected == 0 {
+		err = constants.ErrConcurrentUpdate
+		return
+	}
+	entity.Version++
+	return
+}
+
+// UpdateStatus writes the account status and deleted_at, callers hold the row lock.
+func (r *user) UpdateStatus(ctx context.Context, entity *entities.User) (err error) {
+	err = conn(ctx, r.db).Model(&entities.User{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]interface{}{
+			"status":     entity.Status,
+			"deleted_at": entity.DeletedAt,
+			"updated_at": time.Now(),
+		}).Error
+	return
+}
+
+func (r *user) UpdatePassword(ctx context.Context, entity *entities.User) (err error) {
+	err = conn(ctx, r.db).Model(&entities.User{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]interface{}{
+			"password":   entity.Password,
+			"updated_at": time.Now(),
+		}).Error
+	return
+}
+
+func (r *user) UpdateTOTP(ctx context.Context, entity *entities.User) (err error) {
+	err = conn(ctx, r.db).Model(&entities.User{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]interface{}{
+			"totp_secret":  entity.TOTPSecret,
+			"totp_enabled": entity.TOTPEnabled,
+			"updated_at":   time.Now(),
+		}).Error
+	return
+}
+
+func (r *user) MarkEmailVerified(ctx context.Context, entity *entities.User) (err error) {
+	err = conn(ctx, r.db).Model(&entities.User{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]interface{}{
+			"email_verified_at": entity.EmailVerifiedAt,
+			"updated_at":        time.Now(),
+		}).Error
+	return
+}
+
+func (r *user) UpdateKYCLevel(ctx context.Context, entity *entities.User) (err error) {
+	err = conn(ctx, r.db).Model(&entities.User{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]interface{}{
+			"kyc_level":  entity.KYCLevel,
+			"updated_at": time.Now(),
+		}).Error
 	return
 }
 
@@ -49,13 +147,18 @@ func (r *user) FindAllAndCount(ctx context.Context, pagination constants.Paginat
 	offset := (pagination.Page - 1) * pagination.Limit
 	eg, egCtx := errgroup.WithContext(ctx)
 	eg.Go(func() (egErr error) {
-		queryPayload := r.db.WithContext(egCtx).Limit(int(limit)).Offset(int(offset))
+		queryPayload := conn(egCtx, r.db).Limit(int(limit)).Offset(int(offset))
 		return utils.CompileConds(queryPayload, conds...).Find(&result).Error
 	})
 	eg.Go(func() (egErr error) {
-		countPayload := r.db.WithContext(egCtx).Model(&entities.User{})
+		countPayload := conn(egCtx, r.db).Model(&entities.User{})
 		return utils.CompileConds(countPayload, conds...).Count(&count).Error
 	})
 	err = eg.Wait()
 	return
 }
+
+func (r *user) FindAllIDs(ctx context.Context) (ids []int, err error) {
+	err = conn(ctx, r.db).Model(&entities.User{}).Order("id").Pluck("id", &ids).Error
+	return
+}
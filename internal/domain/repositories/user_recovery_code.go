@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+type UserRecoveryCode interface {
+	Create(ctx context.Context, codes []entities.UserRecoveryCode) (err error)
+	DeleteByUserID(ctx context.Context, userID int) (err error)
+	Use(ctx context.Context, userID int, codeHash string) (ok bool, err error)
+}
+
+type userRecoveryCode struct {
+	db *gorm.DB
+}
+
+func NewUserRecoveryCode(db *gorm.DB) UserRecoveryCode {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &userRecoveryCode{db: db}
+}
+
+func (r *userRecoveryCode) Create(ctx context.Context, codes []entities.UserRecoveryCode) (err error) {
+	err = conn(ctx, r.db).Create(&codes).Error
+	return
+}
+
+func (r *userRecoveryCode) DeleteByUserID(ctx context.Context, userID int) (err error) {
+	err = conn(ctx, r.db).Where("user_id = ?", userID).Delete(&entities.UserRecoveryCode{}).Error
+	return
+}
+
+// Use spends an unused code and reports whether there was one, two requests racing
+// on the same code cannot both get it.
+func (r *userRecoveryCode) Use(ctx context.Context, userID int, codeHash string) (ok bool, err error) {
+	result := conn(ctx, r.db).Model(&entities.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if err = result.Error; err != nil {
+		return
+	}
+	ok = result.RowsAffected == 1
+	return
+}
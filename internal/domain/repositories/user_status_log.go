@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+type UserStatusLog interface {
+	Create(ctx context.Context, entity *entities.UserStatusLog) (err error)
+	FindByUserID(ctx context.Context, userID int) (logs []entities.UserStatusLog, err error)
+}
+
+type userStatusLog struct {
+	db *gorm.DB
+}
+
+func NewUserStatusLog(db *gorm.DB) UserStatusLog {
+	if db == nil {
+		panic("db is nil")
+	}
+
+	return &userStatusLog{db: db}
+}
+
+func (r *userStatusLog) Create(ctx context.Context, entity *entities.UserStatusLog) (err error) {
+	err = conn(ctx, r.db).Create(entity).Error
+	return
+}
+
+func (r *userStatusLog) FindByUserID(ctx context.Context, userID int) (logs []entities.UserStatusLog, err error) {
+	err = conn(ctx, r.db).Where(&entities.UserStatusLog{UserID: userID}).Order("created_at asc, id asc").Find(&logs).Error
+	return
+}
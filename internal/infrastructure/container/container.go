@@ -1,19 +1,33 @@
 package container
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/RichardKnop/machinery/v1"
 	machineryConfig "github.com/RichardKnop/machinery/v1/config"
 	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	notifierWrap "github.com/danielpnjt/speed-engine/internal/infrastructure/notifier"
 	paymentWrap "github.com/danielpnjt/speed-engine/internal/infrastructure/payment"
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/postgres"
 	redisWrap "github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/storage"
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/worker/queue"
+	"github.com/danielpnjt/speed-engine/internal/usecase/admin"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
 	"github.com/danielpnjt/speed-engine/internal/usecase/bank"
+	"github.com/danielpnjt/speed-engine/internal/usecase/fee"
 	"github.com/danielpnjt/speed-engine/internal/usecase/healthcheck"
+	"github.com/danielpnjt/speed-engine/internal/usecase/kyc"
+	"github.com/danielpnjt/speed-engine/internal/usecase/ledger"
+	"github.com/danielpnjt/speed-engine/internal/usecase/limit"
 	"github.com/danielpnjt/speed-engine/internal/usecase/transaction"
 	"github.com/danielpnjt/speed-engine/internal/usecase/user"
 	"github.com/redis/go-redis/v9"
@@ -27,6 +41,12 @@ type Container struct {
 	UserService        user.Service
 	BankService        bank.Service
 	TransactionService transaction.Service
+	LedgerService      ledger.Service
+	AdminService       admin.Service
+	AuditService       audit.Service
+	KYCService         kyc.Service
+	FeeService         fee.Service
+	LimitService       limit.Service
 	RedisClient        *redis.Client
 	QueueWorker        queue.Worker
 }
@@ -61,6 +81,7 @@ func New() *Container {
 		ResultsExpireIn: config.GetInt("worker.taskExpiredInSecond"),
 		Broker:          redisURL,
 		ResultBackend:   redisURL,
+		Lock:            redisURL,
 		Redis: &machineryConfig.RedisConfig{
 			MaxIdle:   config.GetInt("worker.maxIdle"),
 			MaxActive: config.GetInt("worker.maxActive"),
@@ -96,35 +117,181 @@ func New() *Container {
 		Password: redisConfig.Password,
 	})
 	redisWrapper := redisWrap.NewRedisConnection(redisClient)
-	paymentWrapper := paymentWrap.NewPaymentWrapper()
+	// * the sandbox answers every call with success, outside development naming it in
+	// * payment.providers or a route fails validation as an unregistered provider
+	paymentRouter := paymentWrap.NewRouter()
+	if config.GetString("env") == "development" {
+		paymentRouter.Register("sandbox", paymentWrap.NewSandboxWrapper())
+	}
+	if config.GetString("xendit.secretKey") != "" {
+		paymentRouter.Register("xendit", paymentWrap.NewXenditWrapper(config.XenditConfig{
+			PublicKey: config.GetString("xendit.publicKey"),
+			SecretKey: config.GetString("xendit.secretKey"),
+			BaseURL:   config.GetString("xendit.baseUrl"),
+			Timeout:   config.GetDuration("xendit.timeout"),
+		}))
+	}
+	if config.GetString("midtrans.serverKey") != "" {
+		paymentRouter.Register("midtrans", paymentWrap.NewMidtransWrapper(config.MidtransConfig{
+			ServerKey:   config.GetString("midtrans.serverKey"),
+			IrisAPIKey:  config.GetString("midtrans.irisApiKey"),
+			BaseURL:     config.GetString("midtrans.baseUrl"),
+			IrisBaseURL: config.GetString("midtrans.irisBaseUrl"),
+			Timeout:     config.GetDuration("midtrans.timeout"),
+		}))
+	}
+	if config.GetString("manual.accountNumber") != "" {
+		paymentRouter.Register(paymentWrap.ProviderManual, paymentWrap.NewManualWrapper(config.ManualTransferConfig{
+			BankCode:      config.GetString("manual.bankCode"),
+			AccountNumber: config.GetString("manual.accountNumber"),
+			AccountName:   config.GetString("manual.accountName"),
+		}))
+	}
+	if routes := config.GetString("payment.routes"); routes != "" {
+		var paymentRoutes []paymentWrap.Route
+		if err := json.Unmarshal([]byte(routes), &paymentRoutes); err != nil {
+			panic(fmt.Sprintf("invalid payment routes: %s", err))
+		}
+		for _, route := range paymentRoutes {
+			paymentRouter.AddRoute(route)
+		}
+	}
+	paymentWrapper := paymentRouter.
+		SetDefaultProviders(strings.Split(config.GetString("payment.providers"), ",")...).
+		RequireAccountInquiry().
+		Validate()
 
+	var notifierWrapper notifierWrap.Wrapper = notifierWrap.NewLogWrapper(config.GetString("notifier.logDir"))
+	if config.GetString("notifier.provider") == "smtp" {
+		notifierWrapper = notifierWrap.NewSMTPWrapper(config.SMTPConfig{
+			Host:     config.GetString("smtp.host"),
+			Port:     config.GetString("smtp.port"),
+			Username: config.GetString("smtp.username"),
+			Password: config.GetString("smtp.password"),
+			From:     config.GetString("smtp.from"),
+		})
+	}
+
+	// * one withdrawal limit per kyc level, starting at the unverified level
+	kycWithdrawLimits := parseLevelAmounts("kyc.withdrawLimits")
+	documentStorage := storage.NewLocalWrapper(config.GetString("kyc.documentDir"))
+
+	unitOfWork := repositories.NewUnitOfWork(speedEngineDB)
+	adminRepository := repositories.NewAdmin(speedEngineDB)
 	userRepository := repositories.NewUser(speedEngineDB)
+	userStatusLogRepository := repositories.NewUserStatusLog(speedEngineDB)
+	userRecoveryCodeRepository := repositories.NewUserRecoveryCode(speedEngineDB)
 	bankRepository := repositories.NewBank(speedEngineDB)
 	transactionRepository := repositories.NewTransaction(speedEngineDB)
+	balanceHoldRepository := repositories.NewBalanceHold(speedEngineDB)
+	paymentCallbackRepository := repositories.NewPaymentCallback(speedEngineDB)
+	ledgerRepository := repositories.NewLedger(speedEngineDB)
+	balanceAdjustmentRepository := repositories.NewBalanceAdjustment(speedEngineDB)
+	auditLogRepository := repositories.NewAuditLog(speedEngineDB)
+	kycSubmissionRepository := repositories.NewKYCSubmission(speedEngineDB)
+	feeRuleRepository := repositories.NewFeeRule(speedEngineDB)
 
 	healthCheckService := healthcheck.NewService().Validate()
+	auditService := audit.NewService().
+		SetDB(speedEngineDB).
+		SetAuditLogRepository(auditLogRepository).
+		SetBufferSize(config.GetInt("audit.bufferSize")).
+		Validate()
+
 	userService := user.NewService().
 		SetDB(speedEngineDB).
+		SetUnitOfWork(unitOfWork).
 		SetUserRepository(userRepository).
+		SetUserStatusLogRepository(userStatusLogRepository).
+		SetUserRecoveryCodeRepository(userRecoveryCodeRepository).
+		SetTransactionRepository(transactionRepository).
 		SetRedisWrapper(redisWrapper).
+		SetNotifierWrapper(notifierWrapper).
+		SetAuditService(auditService).
 		Validate()
 
 	bankService := bank.NewService().
 		SetDB(speedEngineDB).
+		SetUnitOfWork(unitOfWork).
 		SetBankRepository(bankRepository).
+		SetUserRepository(userRepository).
+		SetRedisWrapper(redisWrapper).
+		SetAuditService(auditService).
+		SetPaymentWrapper(paymentWrapper).
+		SetMaxAccounts(config.GetInt("bank.maxAccounts")).
+		SetNameMatchThreshold(config.GetFloat64("bank.nameMatchThreshold")).
+		Validate()
+
+	feeService := fee.NewService().
+		SetDB(speedEngineDB).
+		SetUnitOfWork(unitOfWork).
+		SetFeeRuleRepository(feeRuleRepository).
+		SetPaymentResolver(paymentRouter).
+		SetAuditService(auditService).
+		Validate()
+
+	limitService := limit.NewService().
+		SetDB(speedEngineDB).
+		SetTransactionRepository(transactionRepository).
+		SetUserRepository(userRepository).
 		SetRedisWrapper(redisWrapper).
+		SetPolicy(entities.TransactionTypeIn, parseLimitPolicy("limits.in")).
+		SetPolicy(entities.TransactionTypeOut, parseLimitPolicy("limits.out")).
+		SetVelocityRules(parseVelocityRules("limits.velocity")).
+		SetKYCWithdrawLimits(kycWithdrawLimits).
+		Validate()
+
+	ledgerService := ledger.NewService().
+		SetDB(speedEngineDB).
+		SetUnitOfWork(unitOfWork).
+		SetLedgerRepository(ledgerRepository).
+		SetUserRepository(userRepository).
 		Validate()
 
 	transactionService := transaction.NewService().
 		SetDB(speedEngineDB).
+		SetUnitOfWork(unitOfWork).
 		SetTransactionRepository(transactionRepository).
+		SetBalanceHoldRepository(balanceHoldRepository).
+		SetPaymentCallbackRepository(paymentCallbackRepository).
 		SetUserRepository(userRepository).
 		SetBankRepository(bankRepository).
 		SetRedisWrapper(redisWrapper).
 		SetPaymentWrapper(paymentWrapper).
 		SetWorker(workerServer).
+		SetLedgerService(ledgerService).
+		SetAuditService(auditService).
+		SetFeeService(feeService).
+		SetLimitService(limitService).
+		Validate()
+
+	kycService := kyc.NewService().
+		SetDB(speedEngineDB).
+		SetUnitOfWork(unitOfWork).
+		SetKYCSubmissionRepository(kycSubmissionRepository).
+		SetUserRepository(userRepository).
+		SetStorageWrapper(documentStorage).
+		SetAuditService(auditService).
+		SetWithdrawLimits(kycWithdrawLimits).
+		SetMaxDocumentSize(int64(config.GetInt("kyc.maxDocumentSize"))).
 		Validate()
 
+	adminService := admin.NewService().
+		SetDB(speedEngineDB).
+		SetUnitOfWork(unitOfWork).
+		SetAdminRepository(adminRepository).
+		SetTransactionRepository(transactionRepository).
+		SetUserRepository(userRepository).
+		SetBalanceAdjustmentRepository(balanceAdjustmentRepository).
+		SetRedisWrapper(redisWrapper).
+		SetLedgerService(ledgerService).
+		SetAuditService(auditService).
+		Validate()
+	err = adminService.Bootstrap(context.Background(), config.GetString("admin.bootstrap.username"), config.GetString("admin.bootstrap.password"))
+	if err != nil {
+		panic(fmt.Sprintf("failed to bootstrap superadmin: %s", err))
+	}
+
 	queueWorker := queue.New().
 		SetMachineryServer(workerServer).
 		SetTransactionService(transactionService).
@@ -138,8 +305,59 @@ func New() *Container {
 		UserService:        userService,
 		BankService:        bankService,
 		TransactionService: transactionService,
+		LedgerService:      ledgerService,
+		AdminService:       adminService,
+		AuditService:       auditService,
+		KYCService:         kycService,
+		FeeService:         feeService,
+		LimitService:       limitService,
 		RedisClient:        redisClient,
 		QueueWorker:        queueWorker,
 	}
 	return container.Validate()
 }
+
+// parseLevelAmounts reads a comma separated list of amounts, one per kyc level.
+func parseLevelAmounts(key string) (amounts []float64) {
+	for _, amount := range strings.Split(config.GetString(key), ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err != nil {
+			panic(fmt.Sprintf("invalid %s: %s", key, err))
+		}
+		amounts = append(amounts, value)
+	}
+	return
+}
+
+func parseLimitPolicy(prefix string) entities.LimitPolicy {
+	return entities.LimitPolicy{
+		MinAmount:   config.GetFloat64(prefix + ".minAmount"),
+		MaxAmount:   config.GetFloat64(prefix + ".maxAmount"),
+		DailyCaps:   parseLevelAmounts(prefix + ".dailyCaps"),
+		MonthlyCaps: parseLevelAmounts(prefix + ".monthlyCaps"),
+	}
+}
+
+// parseVelocityRules reads comma separated type:window:max rules, e.g. out:1h:5.
+func parseVelocityRules(key string) (rules []entities.VelocityRule) {
+	value := config.GetString(key)
+	if value == "" {
+		return
+	}
+	for _, rule := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(rule), ":")
+		if len(parts) != 3 {
+			panic(fmt.Sprintf("invalid %s: %q is not type:window:max", key, rule))
+		}
+		window, err := time.ParseDuration(parts[1])
+		if err != nil || window <= 0 {
+			panic(fmt.Sprintf("invalid %s: bad window in %q", key, rule))
+		}
+		count, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || count <= 0 {
+			panic(fmt.Sprintf("invalid %s: bad max in %q", key, rule))
+		}
+		rules = append(rules, entities.VelocityRule{Type: parts[0], Window: window, Max: count})
+	}
+	return
+}
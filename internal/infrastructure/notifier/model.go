@@ -0,0 +1,7 @@
+package notifier
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
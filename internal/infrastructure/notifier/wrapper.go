@@ -0,0 +1,7 @@
+package notifier
+
+import "context"
+
+type Wrapper interface {
+	Send(ctx context.Context, msg Message) (err error)
+}
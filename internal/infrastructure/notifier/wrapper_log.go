@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// logWrapper delivers nothing, it writes every message to the log and, when a directory is
+// configured, appends it to notifications.log there. It is meant for local development.
+type logWrapper struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewLogWrapper(dir string) *logWrapper {
+	return &logWrapper{dir: dir}
+}
+
+func (w *logWrapper) Send(ctx context.Context, msg Message) (err error) {
+	slog.InfoContext(ctx, "notification", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	if w.dir == "" {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	file, err := os.OpenFile(filepath.Join(w.dir, "notifications.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		err = fmt.Errorf("failed to open notification log: %w", err)
+		return
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "[%s] to: %s\nsubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to write notification log: %w", err)
+		return
+	}
+	return
+}
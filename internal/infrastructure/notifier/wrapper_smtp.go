@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/danielpnjt/speed-engine/internal/config"
+)
+
+// smtpWrapper sends messages as plain text email.
+type smtpWrapper struct {
+	cfg config.SMTPConfig
+}
+
+func NewSMTPWrapper(cfg config.SMTPConfig) *smtpWrapper {
+	if cfg.Host == "" {
+		panic("smtp host is empty")
+	}
+	if cfg.From == "" {
+		panic("smtp sender is empty")
+	}
+	return &smtpWrapper{cfg: cfg}
+}
+
+func (w *smtpWrapper) Send(ctx context.Context, msg Message) (err error) {
+	var auth smtp.Auth
+	if w.cfg.Username != "" {
+		auth = smtp.PlainAuth("", w.cfg.Username, w.cfg.Password, w.cfg.Host)
+	}
+
+	// * header values come from our own templates and the account email, strip line breaks so
+	// * neither can smuggle in extra headers
+	header := strings.NewReplacer("\r", "", "\n", "")
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		w.cfg.From, header.Replace(msg.To), header.Replace(msg.Subject), msg.Body)
+
+	err = smtp.SendMail(net.JoinHostPort(w.cfg.Host, w.cfg.Port), auth, w.cfg.From, []string{msg.To}, []byte(body))
+	if err != nil {
+		err = fmt.Errorf("failed to send email: %w", err)
+		return
+	}
+	return
+}
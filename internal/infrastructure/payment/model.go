@@ -1,14 +1,38 @@
 package payment
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrAccountInquiryUnsupported is returned by providers that cannot look up account holders.
+var ErrAccountInquiryUnsupported = errors.New("account inquiry is not supported by the provider")
 
 type (
 	CreateVARequest struct {
-		ExternalID     string  `json:"external_id" validate:"required"`
-		ExpectedAmount float64 `json:"expected_amount,omitempty"`
+		ExternalID     string     `json:"external_id" validate:"required"`
+		BankCode       string     `json:"bank_code"`
+		Name           string     `json:"name"`
+		IsClosed       bool       `json:"is_closed"`
+		IsSingleUse    bool       `json:"is_single_use"`
+		ExpectedAmount float64    `json:"expected_amount,omitempty"`
+		ExpirationDate *time.Time `json:"expiration_date,omitempty"`
 	}
 
+	// TopUpRequest asks for the state of a top up. PaymentID is the provider's payment id from a
+	// payment callback, when set the payment itself is looked up instead of the VA.
 	TopUpRequest struct {
+		Provider   string `json:"-"`
+		ID         string `json:"id"`
+		ExternalID string `json:"external_id" validate:"required"`
+		PaymentID  string `json:"payment_id,omitempty"`
+	}
+
+	CloseVARequest struct {
+		Provider   string `json:"-"`
+		ID         string `json:"id"`
 		ExternalID string `json:"external_id" validate:"required"`
 	}
 
@@ -20,13 +44,28 @@ type (
 		Amount            int    `json:"amount,required"`
 		Description       string `json:"description,omitempty"`
 	}
+
+	WithdrawStatusRequest struct {
+		Provider   string `json:"-"`
+		ID         string `json:"id"`
+		ExternalID string `json:"external_id" validate:"required"`
+	}
+
+	// AccountInquiryRequest looks up who holds an account. ExpectedHolderName is the name the
+	// player gave, only the sandbox uses it to answer.
+	AccountInquiryRequest struct {
+		BankCode           string `json:"bank_code" validate:"required"`
+		AccountNumber      string `json:"account_number" validate:"required"`
+		ExpectedHolderName string `json:"-"`
+	}
 )
 
 type (
 	CreateVAResponse struct {
-		Status  string               `json:"status"`
-		Message string               `json:"message"`
-		Data    CreateVAResponseData `json:"data"`
+		Provider string               `json:"provider"`
+		Status   string               `json:"status"`
+		Message  string               `json:"message"`
+		Data     CreateVAResponseData `json:"data"`
 	}
 
 	CreateVAResponseData struct {
@@ -48,9 +87,10 @@ type (
 	}
 
 	TopUpResponse struct {
-		Status  string            `json:"status"`
-		Message string            `json:"message"`
-		Data    TopUpResponseData `json:"data"`
+		Provider string            `json:"provider"`
+		Status   string            `json:"status"`
+		Message  string            `json:"message"`
+		Data     TopUpResponseData `json:"data"`
 	}
 
 	TopUpResponseData struct {
@@ -68,13 +108,22 @@ type (
 		ExpirationDate  *time.Time `json:"expiration_date"`
 		SuggestedAmount float64    `json:"suggested_amount,omitempty"`
 		ExpectedAmount  float64    `json:"expected_amount,omitempty"`
+		PaidAmount      float64    `json:"paid_amount,omitempty"`
 		Description     string     `json:"description,omitempty"`
 	}
 
+	CloseVAResponse struct {
+		Provider string               `json:"provider"`
+		Status   string               `json:"status"`
+		Message  string               `json:"message"`
+		Data     CreateVAResponseData `json:"data"`
+	}
+
 	WithdrawResponse struct {
-		Status  string               `json:"status"`
-		Message string               `json:"message"`
-		Data    WithdrawResponseData `json:"data"`
+		Provider string               `json:"provider"`
+		Status   string               `json:"status"`
+		Message  string               `json:"message"`
+		Data     WithdrawResponseData `json:"data"`
 	}
 
 	WithdrawResponseData struct {
@@ -88,3 +137,43 @@ type (
 		Status                  string `json:"status"`
 	}
 )
+
+type (
+	AccountInquiryResponse struct {
+		Provider string                     `json:"provider"`
+		Status   string                     `json:"status"`
+		Message  string                     `json:"message"`
+		Data     AccountInquiryResponseData `json:"data"`
+	}
+
+	AccountInquiryResponseData struct {
+		BankCode          string `json:"bank_code"`
+		AccountNumber     string `json:"account_number"`
+		AccountHolderName string `json:"account_holder_name"`
+	}
+)
+
+// Error is the decoded error body returned by the provider API.
+type Error struct {
+	HTTPStatus int           `json:"-"`
+	ErrorCode  string        `json:"error_code"`
+	Message    string        `json:"message"`
+	Errors     []interface{} `json:"errors,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("payment provider error %d %s: %s", e.HTTPStatus, e.ErrorCode, e.Message)
+}
+
+// Rejected reports whether the provider refused the call with a client error, the call had no
+// effect. A timeout or a server error leaves the outcome unknown and is not a rejection.
+func Rejected(err error) bool {
+	var providerErr *Error
+	return errors.As(err, &providerErr) && providerErr.HTTPStatus >= http.StatusBadRequest && providerErr.HTTPStatus < http.StatusInternalServerError
+}
+
+// NotFound reports whether the provider has no record of what was looked up.
+func NotFound(err error) bool {
+	var providerErr *Error
+	return errors.As(err, &providerErr) && providerErr.HTTPStatus == http.StatusNotFound
+}
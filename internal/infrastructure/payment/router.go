@@ -0,0 +1,251 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Operation names a provider call that routes can be scoped to.
+type Operation string
+
+const (
+	OperationCreateVA Operation = "createVa"
+	OperationWithdraw Operation = "withdraw"
+	// * account inquiry only reads, so every failure moves on to the next provider
+	OperationAccountInquiry Operation = "accountInquiry"
+)
+
+// Route sends matching calls to Providers in order, providers after the first are failovers.
+// An empty Operation or BankCodes and a zero MinAmount or MaxAmount match anything.
+type Route struct {
+	Operation Operation `json:"operation"`
+	BankCodes []string  `json:"bankCodes"`
+	MinAmount float64   `json:"minAmount"`
+	MaxAmount float64   `json:"maxAmount"`
+	Providers []string  `json:"providers"`
+}
+
+func (r Route) match(operation Operation, bankCode string, amount float64) bool {
+	if r.Operation != "" && r.Operation != operation {
+		return false
+	}
+	if len(r.BankCodes) > 0 {
+		found := false
+		for _, code := range r.BankCodes {
+			if strings.EqualFold(code, bankCode) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.MinAmount > 0 && amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// Resolver names the provider a new call would go to first, so it can be priced before it is made.
+type Resolver interface {
+	Provider(operation Operation, bankCode string, amount float64) string
+}
+
+// router is a Wrapper over the registered providers. New VAs and disbursements go to the
+// first matching route, status lookups go back to the provider that handled the transaction.
+type router struct {
+	providers map[string]Wrapper
+	routes    []Route
+	defaults  []string
+	// requireInquiry makes Validate refuse a setup where account inquiries cannot be answered
+	requireInquiry bool
+}
+
+// accountInquirer is implemented by providers that can tell up front whether they offer
+// account inquiry, providers that do not implement it are taken to offer it.
+type accountInquirer interface {
+	supportsAccountInquiry() bool
+}
+
+func NewRouter() *router {
+	return &router{providers: make(map[string]Wrapper)}
+}
+
+func (r *router) Register(name string, wrapper Wrapper) *router {
+	r.providers[name] = wrapper
+	return r
+}
+
+func (r *router) AddRoute(route Route) *router {
+	r.routes = append(r.routes, route)
+	return r
+}
+
+// SetDefaultProviders sets the providers used when no route matches.
+func (r *router) SetDefaultProviders(names ...string) *router {
+	r.defaults = names
+	return r
+}
+
+// RequireAccountInquiry makes Validate check that every bank's account inquiry reaches at least
+// one provider offering it, payouts wait for an account the inquiry verified.
+func (r *router) RequireAccountInquiry() *router {
+	r.requireInquiry = true
+	return r
+}
+
+func (r *router) Validate() Wrapper {
+	if len(r.providers) == 0 {
+		panic("payment providers is empty")
+	}
+	if len(r.defaults) == 0 {
+		panic("default payment providers is empty")
+	}
+	for _, name := range r.defaults {
+		if _, ok := r.providers[name]; !ok {
+			panic(fmt.Sprintf("payment provider %s is not registered", name))
+		}
+	}
+	for _, route := range r.routes {
+		if len(route.Providers) == 0 {
+			panic("payment route has no providers")
+		}
+		for _, name := range route.Providers {
+			if _, ok := r.providers[name]; !ok {
+				panic(fmt.Sprintf("payment provider %s is not registered", name))
+			}
+		}
+	}
+	if r.requireInquiry {
+		if !r.supportsAccountInquiry(r.defaults) {
+			panic("no default payment provider supports account inquiry")
+		}
+		for _, route := range r.routes {
+			if route.Operation != "" && route.Operation != OperationAccountInquiry {
+				continue
+			}
+			if !r.supportsAccountInquiry(route.Providers) {
+				panic(fmt.Sprintf("no payment provider of route %v supports account inquiry", route.Providers))
+			}
+		}
+	}
+	return r
+}
+
+func (r *router) supportsAccountInquiry(names []string) bool {
+	for _, name := range names {
+		inquirer, ok := r.providers[name].(accountInquirer)
+		if !ok || inquirer.supportsAccountInquiry() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *router) candidates(operation Operation, bankCode string, amount float64) []string {
+	for _, route := range r.routes {
+		if route.match(operation, bankCode, amount) {
+			return route.Providers
+		}
+	}
+	return r.defaults
+}
+
+// Provider is the first provider the matching route tries, a failover may end up elsewhere.
+func (r *router) Provider(operation Operation, bankCode string, amount float64) string {
+	return r.candidates(operation, bankCode, amount)[0]
+}
+
+// provider resolves the provider that handled a transaction. Transactions created
+// before routing existed have no provider and belong to the first default one.
+func (r *router) provider(name string) (string, Wrapper, error) {
+	if name == "" {
+		name = r.defaults[0]
+	}
+	wrapper, ok := r.providers[name]
+	if !ok {
+		return name, nil, fmt.Errorf("payment provider %q is not registered", name)
+	}
+	return name, wrapper, nil
+}
+
+// CreateVA fails over on the same rule as Withdraw. After a timeout or a server error the VA may
+// exist at the provider, opening another one elsewhere would leave it orphaned and payable.
+func (r *router) CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error) {
+	for _, name := range r.candidates(OperationCreateVA, req.BankCode, req.ExpectedAmount) {
+		resp, err = r.providers[name].CreateVA(ctx, req)
+		if err == nil || !Rejected(err) {
+			resp.Provider = name
+			return
+		}
+		slog.WarnContext(ctx, "payment provider rejected va", "provider", name, "reference", req.ExternalID, "error", err)
+	}
+	return
+}
+
+func (r *router) TopUp(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error) {
+	name, wrapper, err := r.provider(req.Provider)
+	if err != nil {
+		return
+	}
+	resp, err = wrapper.TopUp(ctx, req)
+	resp.Provider = name
+	return
+}
+
+func (r *router) CloseVA(ctx context.Context, req CloseVARequest) (resp CloseVAResponse, err error) {
+	name, wrapper, err := r.provider(req.Provider)
+	if err != nil {
+		return
+	}
+	resp, err = wrapper.CloseVA(ctx, req)
+	resp.Provider = name
+	return
+}
+
+// Withdraw only fails over when the provider answered with a rejection. A timeout or a
+// server error leaves the disbursement in an unknown state, and retrying it with another
+// provider could pay the player twice. The response then still names the provider, which
+// is the one to ask for the status later.
+func (r *router) Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error) {
+	for _, name := range r.candidates(OperationWithdraw, req.BankCode, float64(req.Amount)) {
+		resp, err = r.providers[name].Withdraw(ctx, req)
+		if err == nil || !Rejected(err) {
+			resp.Provider = name
+			return
+		}
+		slog.WarnContext(ctx, "payment provider rejected disbursement", "provider", name, "reference", req.ExternalID, "error", err)
+	}
+	return
+}
+
+func (r *router) WithdrawStatus(ctx context.Context, req WithdrawStatusRequest) (resp WithdrawResponse, err error) {
+	name, wrapper, err := r.provider(req.Provider)
+	if err != nil {
+		return
+	}
+	resp, err = wrapper.WithdrawStatus(ctx, req)
+	resp.Provider = name
+	return
+}
+
+func (r *router) AccountInquiry(ctx context.Context, req AccountInquiryRequest) (resp AccountInquiryResponse, err error) {
+	for _, name := range r.candidates(OperationAccountInquiry, req.BankCode, 0) {
+		resp, err = r.providers[name].AccountInquiry(ctx, req)
+		if err == nil {
+			resp.Provider = name
+			return
+		}
+		if !errors.Is(err, ErrAccountInquiryUnsupported) {
+			slog.WarnContext(ctx, "payment provider failed account inquiry", "provider", name, "bankCode", req.BankCode, "error", err)
+		}
+	}
+	return
+}
@@ -0,0 +1,206 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type stubWrapper struct {
+	Wrapper
+	err   error
+	calls int
+}
+
+func (w *stubWrapper) CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error) {
+	w.calls++
+	if w.err != nil {
+		err = w.err
+		return
+	}
+	resp.Data.ExternalID = req.ExternalID
+	return
+}
+
+func (w *stubWrapper) Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error) {
+	w.calls++
+	if w.err != nil {
+		err = w.err
+		return
+	}
+	resp.Data.Status = "PENDING"
+	return
+}
+
+func (w *stubWrapper) WithdrawStatus(ctx context.Context, req WithdrawStatusRequest) (resp WithdrawResponse, err error) {
+	w.calls++
+	resp.Data.Status = "COMPLETED"
+	return
+}
+
+func (w *stubWrapper) AccountInquiry(ctx context.Context, req AccountInquiryRequest) (resp AccountInquiryResponse, err error) {
+	w.calls++
+	if w.err != nil {
+		err = w.err
+		return
+	}
+	resp.Data.AccountHolderName = "DANIEL ALEXANDER"
+	return
+}
+
+func TestRouter_Routes(t *testing.T) {
+	router := NewRouter().
+		Register("xendit", &stubWrapper{}).
+		Register("midtrans", &stubWrapper{}).
+		Register("sandbox", &stubWrapper{}).
+		AddRoute(Route{Operation: OperationWithdraw, MinAmount: 10000000, Providers: []string{"midtrans"}}).
+		AddRoute(Route{BankCodes: []string{"bca"}, Providers: []string{"midtrans", "xendit"}}).
+		SetDefaultProviders("xendit").
+		Validate()
+
+	tests := []struct {
+		name         string
+		bankCode     string
+		amount       int
+		wantVA       string
+		wantWithdraw string
+	}{
+		{name: "no route matches", bankCode: "BNI", amount: 50000, wantVA: "xendit", wantWithdraw: "xendit"},
+		{name: "bank code route is case insensitive", bankCode: "BCA", amount: 50000, wantVA: "midtrans", wantWithdraw: "midtrans"},
+		{name: "amount route only applies to withdrawals", bankCode: "BNI", amount: 20000000, wantVA: "xendit", wantWithdraw: "midtrans"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			va, err := router.CreateVA(context.TODO(), CreateVARequest{ExternalID: "TF-1", BankCode: tt.bankCode, ExpectedAmount: float64(tt.amount)})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantVA, va.Provider)
+
+			withdraw, err := router.Withdraw(context.TODO(), WithdrawRequest{ExternalID: "TF-2", BankCode: tt.bankCode, Amount: tt.amount})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantWithdraw, withdraw.Provider)
+		})
+	}
+}
+
+func TestRouter_Failover(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "rejection fails over",
+			err:  &Error{HTTPStatus: http.StatusBadRequest, ErrorCode: "BANK_CODE_NOT_SUPPORTED_ERROR"},
+			want: "midtrans",
+		},
+		{
+			name: "server error does not fail over",
+			err:  &Error{HTTPStatus: http.StatusServiceUnavailable, ErrorCode: "SERVER_ERROR"},
+		},
+		{
+			name: "timeout does not fail over",
+			err:  errors.New("context deadline exceeded"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xendit, midtrans := &stubWrapper{err: tt.err}, &stubWrapper{}
+			router := NewRouter().
+				Register("xendit", xendit).
+				Register("midtrans", midtrans).
+				SetDefaultProviders("xendit", "midtrans").
+				Validate()
+
+			va, errVA := router.CreateVA(context.TODO(), CreateVARequest{ExternalID: "TF-1"})
+			withdraw, errWithdraw := router.Withdraw(context.TODO(), WithdrawRequest{ExternalID: "TF-2"})
+			if tt.want == "" {
+				// * the outcome is unknown, the response names the provider to ask later
+				require.ErrorIs(t, errVA, tt.err)
+				require.ErrorIs(t, errWithdraw, tt.err)
+				require.Equal(t, "xendit", va.Provider)
+				require.Equal(t, "xendit", withdraw.Provider)
+				require.Equal(t, 0, midtrans.calls)
+				return
+			}
+			require.NoError(t, errVA)
+			require.NoError(t, errWithdraw)
+			require.Equal(t, tt.want, va.Provider)
+			require.Equal(t, tt.want, withdraw.Provider)
+		})
+	}
+}
+
+func TestRouter_StatusGoesToHandlingProvider(t *testing.T) {
+	xendit, midtrans := &stubWrapper{}, &stubWrapper{}
+	router := NewRouter().
+		Register("xendit", xendit).
+		Register("midtrans", midtrans).
+		SetDefaultProviders("xendit").
+		Validate()
+
+	resp, err := router.WithdrawStatus(context.TODO(), WithdrawStatusRequest{Provider: "midtrans", ExternalID: "TF-1"})
+	require.NoError(t, err)
+	require.Equal(t, "midtrans", resp.Provider)
+	require.Equal(t, 1, midtrans.calls)
+	require.Equal(t, 0, xendit.calls)
+
+	resp, err = router.WithdrawStatus(context.TODO(), WithdrawStatusRequest{ExternalID: "TF-2"})
+	require.NoError(t, err)
+	require.Equal(t, "xendit", resp.Provider)
+
+	_, err = router.WithdrawStatus(context.TODO(), WithdrawStatusRequest{Provider: "doku", ExternalID: "TF-3"})
+	require.Error(t, err)
+}
+
+func TestRouter_AccountInquiryFailover(t *testing.T) {
+	xendit := &stubWrapper{err: ErrAccountInquiryUnsupported}
+	midtrans := &stubWrapper{}
+	router := NewRouter().
+		Register("xendit", xendit).
+		Register("midtrans", midtrans).
+		SetDefaultProviders("xendit", "midtrans").
+		Validate()
+
+	resp, err := router.AccountInquiry(context.TODO(), AccountInquiryRequest{BankCode: "BCA", AccountNumber: "1234567890"})
+	require.NoError(t, err)
+	require.Equal(t, "midtrans", resp.Provider)
+	require.Equal(t, "DANIEL ALEXANDER", resp.Data.AccountHolderName)
+	require.Equal(t, 1, xendit.calls)
+}
+
+func TestRouter_RequireAccountInquiry(t *testing.T) {
+	xendit := NewXenditWrapper(config.XenditConfig{SecretKey: secretKey})
+
+	// * payouts wait for verified accounts, a xendit only setup could never verify one
+	require.PanicsWithValue(t, "no default payment provider supports account inquiry", func() {
+		NewRouter().
+			Register("xendit", xendit).
+			SetDefaultProviders("xendit").
+			RequireAccountInquiry().
+			Validate()
+	})
+	require.Panics(t, func() {
+		NewRouter().
+			Register("xendit", xendit).
+			Register("midtrans", &stubWrapper{}).
+			AddRoute(Route{BankCodes: []string{"BCA"}, Providers: []string{"xendit"}}).
+			SetDefaultProviders("midtrans").
+			RequireAccountInquiry().
+			Validate()
+	})
+	require.NotPanics(t, func() {
+		NewRouter().
+			Register("xendit", xendit).
+			Register("midtrans", &stubWrapper{}).
+			AddRoute(Route{Operation: OperationWithdraw, Providers: []string{"xendit"}}).
+			SetDefaultProviders("xendit", "midtrans").
+			RequireAccountInquiry().
+			Validate()
+	})
+}
@@ -5,5 +5,8 @@ import "context"
 type Wrapper interface {
 	CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error)
 	TopUp(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error)
+	CloseVA(ctx context.Context, req CloseVARequest) (resp CloseVAResponse, err error)
 	Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error)
+	WithdrawStatus(ctx context.Context, req WithdrawStatusRequest) (resp WithdrawResponse, err error)
+	AccountInquiry(ctx context.Context, req AccountInquiryRequest) (resp AccountInquiryResponse, err error)
 }
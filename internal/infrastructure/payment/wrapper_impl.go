@@ -1,166 +1,290 @@
 package payment
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/danielpnjt/speed-engine/internal/config"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 )
 
-type paymentWrapper struct {
+const (
+	xenditDefaultBaseURL = "https://api.xendit.co"
+	xenditDefaultTimeout = 30 * time.Second
+)
+
+type xenditWrapper struct {
+	cfg    config.XenditConfig
+	client *http.Client
 }
 
-func NewPaymentWrapper() *paymentWrapper {
-	return &paymentWrapper{}
+func NewXenditWrapper(cfg config.XenditConfig) *xenditWrapper {
+	if cfg.SecretKey == "" {
+		panic("xendit secret key is empty")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = xenditDefaultBaseURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = xenditDefaultTimeout
+	}
+
+	return &xenditWrapper{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
 }
 
-func (w *paymentWrapper) CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error) {
-	// xendit.Opt.SecretKey = config.GetString("xendit.secretKey")
-
-	req.ExpectedAmount = 100000
-	isSingleUse := true
-	isClosed := true
-	expiredDate := time.Now().Add(60 * time.Minute).UTC()
-	adminFee := 4500
-
-	// data := virtualaccount.CreateFixedVAParams{
-	// 	ExternalID:     req.ExternalID,
-	// 	BankCode:       req.BankCode,
-	// 	Name:           req.Name,
-	// 	IsSingleUse:    &isSingleUse,
-	// 	IsClosed:       &isSingleUse,
-	// 	ExpectedAmount: float64(int(req.ExpectedAmount) + adminFee),
-	// 	ExpirationDate: &expiredDate,
-	// }
-
-	// va, err := virtualaccount.CreateFixedVA(&data)
-	// if err != nil {
-	// 	slog.ErrorContext(ctx, "failed to create VA", err)
-	// 	err = fmt.Errorf("failed to create VA")
-	// 	return
-	// }
-
-	mockResponseData := CreateVAResponseData{
-		OwnerID:         "57b4e5181473eeb61c11f9b9",
-		ExternalID:      req.ExternalID,
-		BankCode:        "BNI",
-		MerchantCode:    "8808",
-		Name:            "Michael Chen",
-		AccountNumber:   "8808999939380502",
-		IsClosed:        &isClosed,
-		ID:              "57f6fbf26b9f064272622aa6",
-		IsSingleUse:     &isSingleUse,
-		Status:          "PENDING",
-		Currency:        "IDR",
-		ExpirationDate:  &expiredDate,
-		SuggestedAmount: float64(int(req.ExpectedAmount) + adminFee),
-		ExpectedAmount:  float64(int(req.ExpectedAmount) + adminFee),
-		Description:     "mock payment",
-	}
-
-	response := CreateVAResponse{
+func (w *xenditWrapper) CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error) {
+	var data CreateVAResponseData
+	err = w.do(ctx, http.MethodPost, "/callback_virtual_accounts", nil, req, &data)
+	if err != nil {
+		return
+	}
+
+	resp = CreateVAResponse{
 		Status:  constants.STATUS_SUCCESS,
 		Message: constants.MESSAGE_SUCCESS,
-		Data:    mockResponseData,
+		Data:    data,
+	}
+	return
+}
+
+// TopUp reports a top up as paid only on an actual VA payment. Xendit keeps no paid flag on
+// the VA, so with a payment id that payment is looked up and without one the payments made
+// into the VA are listed.
+func (w *xenditWrapper) TopUp(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error) {
+	if req.PaymentID != "" {
+		return w.topUpPayment(ctx, req)
+	}
+
+	path := "/callback_virtual_accounts/" + url.PathEscape(req.ID)
+	var data TopUpResponseData
+	err = w.do(ctx, http.MethodGet, path, nil, nil, &data)
+	if err != nil {
+		return
+	}
+
+	var payments []xenditVAPayment
+	err = w.do(ctx, http.MethodGet, path+"/payments", nil, nil, &payments)
+	if NotFound(err) {
+		err = nil
+	}
+	if err != nil {
+		return
 	}
+	data.Status, data.PaidAmount = topUpStatus(data, req.ExternalID, payments)
 
-	return response, nil
+	resp = TopUpResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+	}
+	return
+}
+
+// topUpPayment looks up a fixed VA payment by its payment id. A payment made into another
+// VA is treated as not found.
+func (w *xenditWrapper) topUpPayment(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error) {
+	var data xenditVAPayment
+	err = w.do(ctx, http.MethodGet, "/callback_virtual_account_payments/payment_id="+url.PathEscape(req.PaymentID), nil, nil, &data)
+	if err != nil {
+		return
+	}
+	if data.ExternalID != req.ExternalID {
+		err = &Error{
+			HTTPStatus: http.StatusNotFound,
+			ErrorCode:  "CALLBACK_VIRTUAL_ACCOUNT_PAYMENT_NOT_FOUND_ERROR",
+			Message:    fmt.Sprintf("payment %s not found for %s", req.PaymentID, req.ExternalID),
+		}
+		return
+	}
+
+	resp = TopUpResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: TopUpResponseData{
+			ID:            data.CallbackVirtualAccountID,
+			ExternalID:    data.ExternalID,
+			BankCode:      data.BankCode,
+			MerchantCode:  data.MerchantCode,
+			AccountNumber: data.AccountNumber,
+			Status:        "COMPLETED",
+			PaidAmount:    data.Amount,
+		},
+	}
+	return
 }
 
-func (w *paymentWrapper) TopUp(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error) {
-	// xendit.Opt.SecretKey = config.GetString("xendit.secretKey")
-
-	// res, err := http.NewRequest("GET", "https://api.xendit.co/callback_virtual_accounts"+fmt.Sprintf(req.ExternalID), nil)
-	// if err != nil {
-	// 	fmt.Println("Error creating request:", err)
-	// 	return
-	// }
-
-	// res.Header.Set("Content-Type", "application/json")
-	// res.SetBasicAuth(xendit.Opt.SecretKey, "")
-
-	// client := &http.Client{}
-	// data, err := client.Do(res)
-	// if err != nil {
-	// 	fmt.Println("Error sending request:", err)
-	// 	return
-	// }
-	// defer data.Body.Close()
-
-	expectedAmount := 100000
-	isSingleUse := true
-	isClosed := true
-	expiredDate := time.Now().Add(60 * time.Minute).UTC()
-	adminFee := 4500
-
-	mockResponseData := TopUpResponseData{
-		OwnerID:         "57b4e5181473eeb61c11f9b9",
-		ExternalID:      req.ExternalID,
-		BankCode:        "BNI",
-		MerchantCode:    "8808",
-		Name:            "Michael Chen",
-		AccountNumber:   "8808999939380502",
-		IsClosed:        &isClosed,
-		ID:              "57f6fbf26b9f064272622aa6",
-		IsSingleUse:     &isSingleUse,
-		Status:          "COMPLETED",
-		Currency:        "IDR",
-		ExpirationDate:  &expiredDate,
-		SuggestedAmount: float64(int(expectedAmount) + adminFee),
-		ExpectedAmount:  float64(int(expectedAmount) + adminFee),
-		Description:     "mock payment",
-	}
-
-	response := TopUpResponse{
+// CloseVA moves the expiration date of a VA that is still open to now, Xendit has no
+// dedicated close call. A VA that is already inactive is left as it is.
+func (w *xenditWrapper) CloseVA(ctx context.Context, req CloseVARequest) (resp CloseVAResponse, err error) {
+	path := "/callback_virtual_accounts/" + url.PathEscape(req.ID)
+
+	var data CreateVAResponseData
+	err = w.do(ctx, http.MethodGet, path, nil, nil, &data)
+	if err != nil {
+		return
+	}
+	if data.Status != "INACTIVE" {
+		body := struct {
+			ExpirationDate time.Time `json:"expiration_date"`
+		}{ExpirationDate: time.Now().UTC()}
+		err = w.do(ctx, http.MethodPatch, path, nil, body, &data)
+		if err != nil {
+			return
+		}
+	}
+
+	resp = CloseVAResponse{
 		Status:  constants.STATUS_SUCCESS,
 		Message: constants.MESSAGE_SUCCESS,
-		Data:    mockResponseData,
+		Data:    data,
+	}
+	return
+}
+
+func (w *xenditWrapper) Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error) {
+	// * the external id doubles as idempotency key so a retried call never pays out twice
+	header := http.Header{}
+	header.Set("X-IDEMPOTENCY-KEY", req.ExternalID)
+
+	var data WithdrawResponseData
+	err = w.do(ctx, http.MethodPost, "/disbursements", header, req, &data)
+	if err != nil {
+		return
 	}
 
-	return response, nil
+	resp = WithdrawResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+	}
+	return
 }
 
-func (w *paymentWrapper) Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error) {
-	// xendit.Opt.SecretKey = config.GetString("xendit.secretKey")
-	// bytesReq, err := json.Marshal(req)
-	// if err != nil {
-	// 	fmt.Println("Error:", err)
-	// 	return
-	// }
-
-	// res, err := http.NewRequest("POST", "https://api.xendit.co/disbursements", bytes.NewReader(bytesReq))
-	// if err != nil {
-	// 	fmt.Println("Error creating request:", err)
-	// 	return
-	// }
-
-	// res.Header.Set("Content-Type", "application/json")
-	// res.SetBasicAuth(xendit.Opt.SecretKey, "")
-
-	// client := &http.Client{}
-	// data, err := client.Do(res)
-	// if err != nil {
-	// 	fmt.Println("Error sending request:", err)
-	// 	return
-	// }
-	// defer data.Body.Close()
-
-	mockResponseData := WithdrawResponseData{
-		ID:                      "57f1ce05bb1a631a65eee662",
-		ExternalID:              req.ExternalID,
-		UserID:                  "5785e6334d7b410667d355c4",
-		BankCode:                req.BankCode,
-		AccountHolderName:       req.AccountHolderName,
-		Amount:                  req.Amount,
-		DisbursementDescription: req.Description,
-		Status:                  "COMPLETED",
-	}
-
-	response := WithdrawResponse{
+func (w *xenditWrapper) WithdrawStatus(ctx context.Context, req WithdrawStatusRequest) (resp WithdrawResponse, err error) {
+	var data []WithdrawResponseData
+	err = w.do(ctx, http.MethodGet, "/disbursements?external_id="+url.QueryEscape(req.ExternalID), nil, nil, &data)
+	if err != nil {
+		return
+	}
+	if len(data) == 0 {
+		err = &Error{
+			HTTPStatus: http.StatusNotFound,
+			ErrorCode:  "DIRECT_DISBURSEMENT_NOT_FOUND_ERROR",
+			Message:    fmt.Sprintf("disbursement %s not found", req.ExternalID),
+		}
+		return
+	}
+
+	resp = WithdrawResponse{
 		Status:  constants.STATUS_SUCCESS,
 		Message: constants.MESSAGE_SUCCESS,
-		Data:    mockResponseData,
+		Data:    data[len(data)-1],
 	}
+	return
+}
+
+// AccountInquiry is not offered, xendit only validates account names asynchronously.
+func (w *xenditWrapper) AccountInquiry(ctx context.Context, req AccountInquiryRequest) (resp AccountInquiryResponse, err error) {
+	err = ErrAccountInquiryUnsupported
+	return
+}
+
+func (w *xenditWrapper) supportsAccountInquiry() bool {
+	return false
+}
+
+// xenditVAPayment is a payment into a fixed VA.
+type xenditVAPayment struct {
+	ID                       string    `json:"id"`
+	PaymentID                string    `json:"payment_id"`
+	CallbackVirtualAccountID string    `json:"callback_virtual_account_id"`
+	ExternalID               string    `json:"external_id"`
+	MerchantCode             string    `json:"merchant_code"`
+	AccountNumber            string    `json:"account_number"`
+	BankCode                 string    `json:"bank_code"`
+	Amount                   float64   `json:"amount"`
+	TransactionTimestamp     time.Time `json:"transaction_timestamp"`
+}
 
-	return response, nil
+// topUpStatus maps a fixed VA and the payments made into it to our top up status. A single use
+// VA turns INACTIVE once it is paid or closed, so only the payments tell the two apart: with a
+// payment the top up is completed, without one an INACTIVE VA past its expiry is expired.
+func topUpStatus(data TopUpResponseData, externalID string, payments []xenditVAPayment) (status string, paidAmount float64) {
+	for _, payment := range payments {
+		if payment.ExternalID == externalID {
+			paidAmount += payment.Amount
+		}
+	}
+	if paidAmount > 0 {
+		return "COMPLETED", paidAmount
+	}
+	if data.Status == "INACTIVE" && data.ExpirationDate != nil && time.Now().After(*data.ExpirationDate) {
+		return "EXPIRED", 0
+	}
+	return "PENDING", 0
+}
+
+func (w *xenditWrapper) do(ctx context.Context, method, path string, header http.Header, body interface{}, out interface{}) (err error) {
+	var reader io.Reader
+	if body != nil {
+		payload, errMarshal := json.Marshal(body)
+		if errMarshal != nil {
+			err = fmt.Errorf("xendit marshal error: %w", errMarshal)
+			return
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, w.cfg.BaseURL+path, reader)
+	if err != nil {
+		err = fmt.Errorf("xendit request error: %w", err)
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.SetBasicAuth(w.cfg.SecretKey, "")
+	for key := range header {
+		request.Header.Set(key, header.Get(key))
+	}
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		err = fmt.Errorf("xendit %s %s error: %w", method, path, err)
+		return
+	}
+	defer response.Body.Close()
+
+	raw, err := io.ReadAll(response.Body)
+	if err != nil {
+		err = fmt.Errorf("xendit read body error: %w", err)
+		return
+	}
+
+	if response.StatusCode >= http.StatusMultipleChoices {
+		providerErr := &Error{HTTPStatus: response.StatusCode}
+		if json.Unmarshal(raw, providerErr) != nil || providerErr.ErrorCode == "" {
+			providerErr.ErrorCode = "UNKNOWN_ERROR"
+			providerErr.Message = strings.TrimSpace(string(raw))
+		}
+		err = providerErr
+		return
+	}
+
+	if out != nil {
+		err = json.Unmarshal(raw, out)
+		if err != nil {
+			err = fmt.Errorf("xendit unmarshal error: %w", err)
+			return
+		}
+	}
+	return
 }
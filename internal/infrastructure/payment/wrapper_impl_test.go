@@ -0,0 +1,302 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+const secretKey = "xnd_development_secret"
+
+func newTestXendit(t *testing.T, handler http.HandlerFunc) *xenditWrapper {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewXenditWrapper(config.XenditConfig{
+		SecretKey: secretKey,
+		BaseURL:   server.URL,
+		Timeout:   time.Second,
+	})
+}
+
+func requireBasicAuth(t *testing.T, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, secretKey, username)
+	require.Equal(t, "", password)
+}
+
+func TestXenditWrapper_CreateVA(t *testing.T) {
+	wrapper := newTestXendit(t, func(w http.ResponseWriter, r *http.Request) {
+		requireBasicAuth(t, r)
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/callback_virtual_accounts", r.URL.Path)
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "TF-ABCDE12345", body["external_id"])
+		require.Equal(t, "BNI", body["bank_code"])
+		require.Equal(t, float64(100000), body["expected_amount"])
+		require.Equal(t, true, body["is_single_use"])
+
+		w.Write([]byte(`{
+			"id": "57f6fbf26b9f064272622aa6",
+			"external_id": "TF-ABCDE12345",
+			"bank_code": "BNI",
+			"account_number": "8808999939380502",
+			"status": "PENDING",
+			"expected_amount": 100000,
+			"expiration_date": "2030-01-01T00:00:00.000Z"
+		}`))
+	})
+
+	resp, err := wrapper.CreateVA(context.TODO(), CreateVARequest{
+		ExternalID:     "TF-ABCDE12345",
+		BankCode:       "BNI",
+		Name:           "Daniel Alexander",
+		IsClosed:       true,
+		IsSingleUse:    true,
+		ExpectedAmount: 100000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "57f6fbf26b9f064272622aa6", resp.Data.ID)
+	require.Equal(t, "8808999939380502", resp.Data.AccountNumber)
+	require.Equal(t, "PENDING", resp.Data.Status)
+	require.NotNil(t, resp.Data.ExpirationDate)
+}
+
+func TestXenditWrapper_TopUp(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		payments       string
+		wantStatus     string
+		wantPaidAmount float64
+	}{
+		{
+			name:       "active va is still waiting for payment",
+			body:       `{"id": "va-1", "status": "ACTIVE", "expiration_date": "2030-01-01T00:00:00.000Z"}`,
+			payments:   `[]`,
+			wantStatus: "PENDING",
+		},
+		{
+			name:       "inactive va before expiry waits for the payment",
+			body:       `{"id": "va-1", "status": "INACTIVE", "expiration_date": "2030-01-01T00:00:00.000Z"}`,
+			payments:   `[]`,
+			wantStatus: "PENDING",
+		},
+		{
+			name:       "inactive va after expiry without a payment",
+			body:       `{"id": "va-1", "status": "INACTIVE", "expiration_date": "2020-01-01T00:00:00.000Z"}`,
+			payments:   `[]`,
+			wantStatus: "EXPIRED",
+		},
+		{
+			name:           "inactive va after expiry was paid, the callback got lost",
+			body:           `{"id": "va-1", "status": "INACTIVE", "expiration_date": "2020-01-01T00:00:00.000Z"}`,
+			payments:       `[{"payment_id": "pay-1", "callback_virtual_account_id": "va-1", "external_id": "TF-ABCDE12345", "amount": 50000}]`,
+			wantStatus:     "COMPLETED",
+			wantPaidAmount: 50000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := newTestXendit(t, func(w http.ResponseWriter, r *http.Request) {
+				requireBasicAuth(t, r)
+				require.Equal(t, http.MethodGet, r.Method)
+				switch r.URL.Path {
+				case "/callback_virtual_accounts/va-1":
+					w.Write([]byte(tt.body))
+				case "/callback_virtual_accounts/va-1/payments":
+					w.Write([]byte(tt.payments))
+				default:
+					t.Fatalf("unexpected path %s", r.URL.Path)
+				}
+			})
+
+			resp, err := wrapper.TopUp(context.TODO(), TopUpRequest{ID: "va-1", ExternalID: "TF-ABCDE12345"})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, resp.Data.Status)
+			require.Equal(t, tt.wantPaidAmount, resp.Data.PaidAmount)
+		})
+	}
+}
+
+func TestXenditWrapper_TopUpPayment(t *testing.T) {
+	wrapper := newTestXendit(t, func(w http.ResponseWriter, r *http.Request) {
+		requireBasicAuth(t, r)
+		require.Equal(t, http.MethodGet, r.Method)
+		require.Equal(t, "/callback_virtual_account_payments/payment_id=pay-1", r.URL.Path)
+		w.Write([]byte(`{"id": "cb-1", "payment_id": "pay-1", "callback_virtual_account_id": "va-1", "external_id": "TF-ABCDE12345", "bank_code": "BNI", "amount": 50000}`))
+	})
+
+	resp, err := wrapper.TopUp(context.TODO(), TopUpRequest{ID: "va-1", ExternalID: "TF-ABCDE12345", PaymentID: "pay-1"})
+	require.NoError(t, err)
+	require.Equal(t, "COMPLETED", resp.Data.Status)
+	require.Equal(t, float64(50000), resp.Data.PaidAmount)
+
+	// * a payment id from another VA proves nothing about this top up
+	_, err = wrapper.TopUp(context.TODO(), TopUpRequest{ID: "va-2", ExternalID: "TF-OTHER", PaymentID: "pay-1"})
+	require.True(t, NotFound(err))
+}
+
+func TestXenditWrapper_CloseVA(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		wantPatch bool
+	}{
+		{name: "active va is expired now", status: "ACTIVE", wantPatch: true},
+		{name: "inactive va is left alone", status: "INACTIVE", wantPatch: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patched bool
+			wrapper := newTestXendit(t, func(w http.ResponseWriter, r *http.Request) {
+				requireBasicAuth(t, r)
+				require.Equal(t, "/callback_virtual_accounts/va-1", r.URL.Path)
+				if r.Method == http.MethodPatch {
+					patched = true
+					var body map[string]interface{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+					require.NotEmpty(t, body["expiration_date"])
+					w.Write([]byte(`{"id": "va-1", "status": "INACTIVE"}`))
+					return
+				}
+				w.Write([]byte(`{"id": "va-1", "status": "` + tt.status + `"}`))
+			})
+
+			resp, err := wrapper.CloseVA(context.TODO(), CloseVARequest{ID: "va-1", ExternalID: "TF-ABCDE12345"})
+			require.NoError(t, err)
+			require.Equal(t, "INACTIVE", resp.Data.Status)
+			require.Equal(t, tt.wantPatch, patched)
+		})
+	}
+}
+
+func TestXenditWrapper_Withdraw(t *testing.T) {
+	wrapper := newTestXendit(t, func(w http.ResponseWriter, r *http.Request) {
+		requireBasicAuth(t, r)
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/disbursements", r.URL.Path)
+		require.Equal(t, "TF-ABCDE12345", r.Header.Get("X-IDEMPOTENCY-KEY"))
+
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"external_id": "TF-ABCDE12345",
+			"bank_code": "BCA",
+			"account_holder_name": "Daniel Alexander",
+			"account_number": "1234567890",
+			"amount": 50000,
+			"description": "disbursement to user"
+		}`, string(raw))
+
+		w.Write([]byte(`{
+			"id": "57f1ce05bb1a631a65eee662",
+			"external_id": "TF-ABCDE12345",
+			"bank_code": "BCA",
+			"account_holder_name": "Daniel Alexander",
+			"amount": 50000,
+			"status": "PENDING"
+		}`))
+	})
+
+	resp, err := wrapper.Withdraw(context.TODO(), WithdrawRequest{
+		ExternalID:        "TF-ABCDE12345",
+		BankCode:          "BCA",
+		AccountHolderName: "Daniel Alexander",
+		AccountNumber:     "1234567890",
+		Amount:            50000,
+		Description:       "disbursement to user",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "57f1ce05bb1a631a65eee662", resp.Data.ID)
+	require.Equal(t, "PENDING", resp.Data.Status)
+}
+
+func TestXenditWrapper_WithdrawStatus(t *testing.T) {
+	wrapper := newTestXendit(t, func(w http.ResponseWriter, r *http.Request) {
+		requireBasicAuth(t, r)
+		require.Equal(t, "/disbursements", r.URL.Path)
+		require.Equal(t, "TF-ABCDE12345", r.URL.Query().Get("external_id"))
+		w.Write([]byte(`[{"id": "disb-1", "external_id": "TF-ABCDE12345", "status": "COMPLETED"}]`))
+	})
+
+	resp, err := wrapper.WithdrawStatus(context.TODO(), WithdrawStatusRequest{ExternalID: "TF-ABCDE12345"})
+	require.NoError(t, err)
+	require.Equal(t, "disb-1", resp.Data.ID)
+	require.Equal(t, "COMPLETED", resp.Data.Status)
+}
+
+func TestXenditWrapper_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		delay   time.Duration
+		wantErr *Error
+	}{
+		{
+			name:   "xendit error body is decoded",
+			status: http.StatusBadRequest,
+			body:   `{"error_code": "DUPLICATE_TRANSACTION_ERROR", "message": "Idempotency key has been used before"}`,
+			wantErr: &Error{
+				HTTPStatus: http.StatusBadRequest,
+				ErrorCode:  "DUPLICATE_TRANSACTION_ERROR",
+				Message:    "Idempotency key has been used before",
+			},
+		},
+		{
+			name:   "non json error body is kept as message",
+			status: http.StatusBadGateway,
+			body:   "bad gateway",
+			wantErr: &Error{
+				HTTPStatus: http.StatusBadGateway,
+				ErrorCode:  "UNKNOWN_ERROR",
+				Message:    "bad gateway",
+			},
+		},
+		{
+			name:  "slow provider hits the client timeout",
+			delay: 2 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := newTestXendit(t, func(w http.ResponseWriter, r *http.Request) {
+				if tt.delay > 0 {
+					select {
+					case <-time.After(tt.delay):
+					case <-r.Context().Done():
+					}
+					return
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := wrapper.Withdraw(context.TODO(), WithdrawRequest{ExternalID: "TF-ABCDE12345"})
+			require.Error(t, err)
+
+			var providerErr *Error
+			if tt.wantErr == nil {
+				require.False(t, errors.As(err, &providerErr))
+				return
+			}
+			require.True(t, errors.As(err, &providerErr))
+			require.Equal(t, tt.wantErr, providerErr)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+// ProviderManual is the name the manual bank transfer provider is registered under.
+const ProviderManual = "manual"
+
+// manualWrapper takes top ups as plain transfers to the company account and leaves payouts to
+// be sent by hand. It never learns an outcome itself, an operator confirms each transfer
+// through the admin API and until then every status lookup answers PENDING.
+type manualWrapper struct {
+	cfg config.ManualTransferConfig
+}
+
+func NewManualWrapper(cfg config.ManualTransferConfig) *manualWrapper {
+	if cfg.BankCode == "" || cfg.AccountNumber == "" {
+		panic("manual transfer account is empty")
+	}
+
+	return &manualWrapper{cfg: cfg}
+}
+
+// CreateVA hands out the company account, the reference in the description is how an
+// operator matches the incoming transfer to the top up.
+func (w *manualWrapper) CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error) {
+	isClosed, isSingleUse := true, true
+	resp = CreateVAResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: CreateVAResponseData{
+			ID:             req.ExternalID,
+			ExternalID:     req.ExternalID,
+			BankCode:       w.cfg.BankCode,
+			Name:           w.cfg.AccountName,
+			AccountNumber:  w.cfg.AccountNumber,
+			IsClosed:       &isClosed,
+			IsSingleUse:    &isSingleUse,
+			Status:         "PENDING",
+			Currency:       "IDR",
+			ExpirationDate: req.ExpirationDate,
+			ExpectedAmount: req.ExpectedAmount,
+			Description:    fmt.Sprintf("transfer exactly %.0f and write %s in the transfer note", req.ExpectedAmount, req.ExternalID),
+		},
+	}
+	return
+}
+
+func (w *manualWrapper) TopUp(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error) {
+	resp = TopUpResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: TopUpResponseData{
+			ID:         req.ID,
+			ExternalID: req.ExternalID,
+			Status:     "PENDING",
+		},
+	}
+	return
+}
+
+// CloseVA has nothing to close, the company account stays open. A transfer arriving after the
+// top up expired is still confirmed by the operator.
+func (w *manualWrapper) CloseVA(ctx context.Context, req CloseVARequest) (resp CloseVAResponse, err error) {
+	resp = CloseVAResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: CreateVAResponseData{
+			ID:         req.ID,
+			ExternalID: req.ExternalID,
+		},
+	}
+	return
+}
+
+// Withdraw queues the payout for an operator, it stays pending until they confirm it went out.
+func (w *manualWrapper) Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error) {
+	resp = WithdrawResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: WithdrawResponseData{
+			ID:                      req.ExternalID,
+			ExternalID:              req.ExternalID,
+			BankCode:                req.BankCode,
+			AccountHolderName:       req.AccountHolderName,
+			Amount:                  req.Amount,
+			DisbursementDescription: req.Description,
+			Status:                  "PENDING",
+		},
+	}
+	return
+}
+
+func (w *manualWrapper) WithdrawStatus(ctx context.Context, req WithdrawStatusRequest) (resp WithdrawResponse, err error) {
+	resp = WithdrawResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: WithdrawResponseData{
+			ID:         req.ID,
+			ExternalID: req.ExternalID,
+			Status:     "PENDING",
+		},
+	}
+	return
+}
+
+// AccountInquiry is not offered, nobody can look an account holder up by hand in time.
+func (w *manualWrapper) AccountInquiry(ctx context.Context, req AccountInquiryRequest) (resp AccountInquiryResponse, err error) {
+	err = ErrAccountInquiryUnsupported
+	return
+}
+
+func (w *manualWrapper) supportsAccountInquiry() bool {
+	return false
+}
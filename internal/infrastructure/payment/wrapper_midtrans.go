@@ -0,0 +1,450 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+const (
+	midtransDefaultBaseURL     = "https://api.midtrans.com"
+	midtransDefaultIrisBaseURL = "https://app.midtrans.com/iris"
+	midtransDefaultTimeout     = 30 * time.Second
+	midtransTimeLayout         = "2006-01-02 15:04:05"
+)
+
+// midtransLocation is the zone of the timestamps midtrans returns without offset.
+var midtransLocation = time.FixedZone("WIB", 7*60*60)
+
+// midtransWrapper takes top ups through the Core API bank transfer charge and pays out
+// through Iris, the Midtrans disbursement API.
+type midtransWrapper struct {
+	cfg    config.MidtransConfig
+	client *http.Client
+}
+
+type (
+	midtransChargeRequest struct {
+		PaymentType        string                     `json:"payment_type"`
+		TransactionDetails midtransTransactionDetails `json:"transaction_details"`
+		BankTransfer       midtransBankTransfer       `json:"bank_transfer"`
+		CustomerDetails    *midtransCustomerDetails   `json:"customer_details,omitempty"`
+		CustomExpiry       *midtransCustomExpiry      `json:"custom_expiry,omitempty"`
+	}
+
+	midtransTransactionDetails struct {
+		OrderID     string `json:"order_id"`
+		GrossAmount int64  `json:"gross_amount"`
+	}
+
+	midtransBankTransfer struct {
+		Bank string `json:"bank"`
+	}
+
+	midtransCustomerDetails struct {
+		FirstName string `json:"first_name"`
+	}
+
+	midtransCustomExpiry struct {
+		ExpiryDuration int    `json:"expiry_duration"`
+		Unit           string `json:"unit"`
+	}
+
+	midtransTransaction struct {
+		StatusCode        string `json:"status_code"`
+		StatusMessage     string `json:"status_message"`
+		TransactionID     string `json:"transaction_id"`
+		OrderID           string `json:"order_id"`
+		GrossAmount       string `json:"gross_amount"`
+		Currency          string `json:"currency"`
+		TransactionStatus string `json:"transaction_status"`
+		ExpiryTime        string `json:"expiry_time"`
+		PermataVANumber   string `json:"permata_va_number"`
+		VANumbers         []struct {
+			Bank     string `json:"bank"`
+			VANumber string `json:"va_number"`
+		} `json:"va_numbers"`
+	}
+
+	midtransPayoutRequest struct {
+		Payouts []midtransPayout `json:"payouts"`
+	}
+
+	midtransPayout struct {
+		BeneficiaryName    string `json:"beneficiary_name"`
+		BeneficiaryAccount string `json:"beneficiary_account"`
+		BeneficiaryBank    string `json:"beneficiary_bank"`
+		Amount             string `json:"amount"`
+		Notes              string `json:"notes"`
+		Status             string `json:"status,omitempty"`
+		ReferenceNo        string `json:"reference_no,omitempty"`
+	}
+
+	midtransAccountValidation struct {
+		AccountName string `json:"account_name"`
+		AccountNo   string `json:"account_no"`
+		BankName    string `json:"bank_name"`
+	}
+)
+
+func NewMidtransWrapper(cfg config.MidtransConfig) *midtransWrapper {
+	if cfg.ServerKey == "" {
+		panic("midtrans server key is empty")
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = midtransDefaultBaseURL
+	}
+	if cfg.IrisBaseURL == "" {
+		cfg.IrisBaseURL = midtransDefaultIrisBaseURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = midtransDefaultTimeout
+	}
+
+	return &midtransWrapper{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+func (w *midtransWrapper) CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error) {
+	charge := midtransChargeRequest{
+		PaymentType: "bank_transfer",
+		TransactionDetails: midtransTransactionDetails{
+			OrderID:     req.ExternalID,
+			GrossAmount: int64(req.ExpectedAmount),
+		},
+		BankTransfer: midtransBankTransfer{Bank: strings.ToLower(req.BankCode)},
+	}
+	if req.Name != "" {
+		charge.CustomerDetails = &midtransCustomerDetails{FirstName: req.Name}
+	}
+	if req.ExpirationDate != nil {
+		charge.CustomExpiry = &midtransCustomExpiry{
+			ExpiryDuration: int(time.Until(*req.ExpirationDate).Minutes()),
+			Unit:           "minute",
+		}
+	}
+
+	var data midtransTransaction
+	err = w.core(ctx, http.MethodPost, "/v2/charge", charge, &data)
+	if err != nil {
+		return
+	}
+
+	isClosed, isSingleUse := true, true
+	resp = CreateVAResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: CreateVAResponseData{
+			ID:             data.TransactionID,
+			ExternalID:     data.OrderID,
+			BankCode:       strings.ToUpper(req.BankCode),
+			Name:           req.Name,
+			AccountNumber:  data.vaNumber(),
+			IsClosed:       &isClosed,
+			IsSingleUse:    &isSingleUse,
+			Status:         midtransTopUpStatus(data.TransactionStatus),
+			Currency:       "IDR",
+			ExpirationDate: data.expiryTime(),
+			ExpectedAmount: data.grossAmount(),
+		},
+	}
+	return
+}
+
+func (w *midtransWrapper) TopUp(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error) {
+	var data midtransTransaction
+	err = w.core(ctx, http.MethodGet, "/v2/"+url.PathEscape(req.ExternalID)+"/status", nil, &data)
+	if err != nil {
+		return
+	}
+
+	resp = TopUpResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: TopUpResponseData{
+			ID:             data.TransactionID,
+			ExternalID:     data.OrderID,
+			AccountNumber:  data.vaNumber(),
+			Status:         midtransTopUpStatus(data.TransactionStatus),
+			Currency:       "IDR",
+			ExpirationDate: data.expiryTime(),
+			ExpectedAmount: data.grossAmount(),
+		},
+	}
+	return
+}
+
+// CloseVA expires the charge unless midtrans already closed it.
+func (w *midtransWrapper) CloseVA(ctx context.Context, req CloseVARequest) (resp CloseVAResponse, err error) {
+	path := "/v2/" + url.PathEscape(req.ExternalID)
+
+	var data midtransTransaction
+	err = w.core(ctx, http.MethodGet, path+"/status", nil, &data)
+	if err != nil {
+		return
+	}
+	if data.TransactionStatus == "pending" {
+		err = w.core(ctx, http.MethodPost, path+"/expire", nil, &data)
+		if err != nil {
+			return
+		}
+	}
+
+	resp = CloseVAResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: CreateVAResponseData{
+			ID:             data.TransactionID,
+			ExternalID:     data.OrderID,
+			AccountNumber:  data.vaNumber(),
+			Status:         midtransTopUpStatus(data.TransactionStatus),
+			ExpirationDate: data.expiryTime(),
+			ExpectedAmount: data.grossAmount(),
+		},
+	}
+	return
+}
+
+func (w *midtransWrapper) Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error) {
+	payout := midtransPayoutRequest{
+		Payouts: []midtransPayout{{
+			BeneficiaryName:    req.AccountHolderName,
+			BeneficiaryAccount: req.AccountNumber,
+			BeneficiaryBank:    strings.ToLower(req.BankCode),
+			Amount:             strconv.Itoa(req.Amount),
+			Notes:              req.Description,
+		}},
+	}
+
+	var data midtransPayoutRequest
+	err = w.iris(ctx, http.MethodPost, "/api/v1/payouts", req.ExternalID, payout, &data)
+	if err != nil {
+		return
+	}
+	if len(data.Payouts) == 0 {
+		err = fmt.Errorf("midtrans payout response is empty")
+		return
+	}
+
+	resp = WithdrawResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: WithdrawResponseData{
+			ID:                      data.Payouts[0].ReferenceNo,
+			ExternalID:              req.ExternalID,
+			BankCode:                req.BankCode,
+			AccountHolderName:       req.AccountHolderName,
+			Amount:                  req.Amount,
+			DisbursementDescription: req.Description,
+			Status:                  midtransWithdrawStatus(data.Payouts[0].Status),
+		},
+	}
+	return
+}
+
+func (w *midtransWrapper) WithdrawStatus(ctx context.Context, req WithdrawStatusRequest) (resp WithdrawResponse, err error) {
+	// * iris only looks payouts up by its own reference, one that was never returned can't be found here
+	if req.ID == "" {
+		err = fmt.Errorf("midtrans payout %s has no reference no", req.ExternalID)
+		return
+	}
+
+	var data midtransPayout
+	err = w.iris(ctx, http.MethodGet, "/api/v1/payouts/"+url.PathEscape(req.ID), "", nil, &data)
+	if err != nil {
+		return
+	}
+
+	amount, _ := strconv.ParseFloat(data.Amount, 64)
+	resp = WithdrawResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: WithdrawResponseData{
+			ID:                      data.ReferenceNo,
+			ExternalID:              req.ExternalID,
+			BankCode:                strings.ToUpper(data.BeneficiaryBank),
+			AccountHolderName:       data.BeneficiaryName,
+			Amount:                  int(amount),
+			DisbursementDescription: data.Notes,
+			Status:                  midtransWithdrawStatus(data.Status),
+		},
+	}
+	return
+}
+
+// AccountInquiry asks Iris who holds the beneficiary account.
+func (w *midtransWrapper) AccountInquiry(ctx context.Context, req AccountInquiryRequest) (resp AccountInquiryResponse, err error) {
+	query := url.Values{}
+	query.Set("bank", strings.ToLower(req.BankCode))
+	query.Set("account", req.AccountNumber)
+
+	var data midtransAccountValidation
+	err = w.iris(ctx, http.MethodGet, "/api/v1/account_validation?"+query.Encode(), "", nil, &data)
+	if err != nil {
+		return
+	}
+
+	resp = AccountInquiryResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: AccountInquiryResponseData{
+			BankCode:          req.BankCode,
+			AccountNumber:     data.AccountNo,
+			AccountHolderName: data.AccountName,
+		},
+	}
+	return
+}
+
+func (t midtransTransaction) vaNumber() string {
+	if len(t.VANumbers) > 0 {
+		return t.VANumbers[0].VANumber
+	}
+	return t.PermataVANumber
+}
+
+func (t midtransTransaction) expiryTime() *time.Time {
+	expiry, err := time.ParseInLocation(midtransTimeLayout, t.ExpiryTime, midtransLocation)
+	if err != nil {
+		return nil
+	}
+	expiry = expiry.UTC()
+	return &expiry
+}
+
+func (t midtransTransaction) grossAmount() float64 {
+	amount, _ := strconv.ParseFloat(t.GrossAmount, 64)
+	return amount
+}
+
+// midtransTopUpStatus maps a Core API transaction status to our top up status.
+func midtransTopUpStatus(status string) string {
+	switch status {
+	case "settlement", "capture":
+		return "COMPLETED"
+	case "expire":
+		return "EXPIRED"
+	case "cancel", "deny", "failure":
+		return "FAILED"
+	default:
+		return "PENDING"
+	}
+}
+
+// midtransWithdrawStatus maps an Iris payout status to our disbursement status.
+func midtransWithdrawStatus(status string) string {
+	switch status {
+	case "completed":
+		return "COMPLETED"
+	case "failed", "rejected":
+		return "FAILED"
+	default:
+		return "PENDING"
+	}
+}
+
+// core calls the Core API. It answers some failures with HTTP 200 and the real
+// status code in the body, so both are checked.
+func (w *midtransWrapper) core(ctx context.Context, method, path string, body interface{}, out *midtransTransaction) (err error) {
+	raw, status, err := w.do(ctx, method, w.cfg.BaseURL+path, w.cfg.ServerKey, nil, body)
+	if err != nil {
+		return
+	}
+
+	errUnmarshal := json.Unmarshal(raw, out)
+	if code, _ := strconv.Atoi(out.StatusCode); code >= http.StatusMultipleChoices {
+		status = code
+	}
+	if status >= http.StatusMultipleChoices || errUnmarshal != nil {
+		err = &Error{HTTPStatus: status, ErrorCode: "UNKNOWN_ERROR", Message: strings.TrimSpace(string(raw))}
+		if out.StatusMessage != "" {
+			err = &Error{HTTPStatus: status, ErrorCode: out.StatusCode, Message: out.StatusMessage}
+		}
+	}
+	return
+}
+
+// iris calls the disbursement API, idempotencyKey is sent when not empty.
+func (w *midtransWrapper) iris(ctx context.Context, method, path, idempotencyKey string, body interface{}, out interface{}) (err error) {
+	header := http.Header{}
+	if idempotencyKey != "" {
+		header.Set("X-Idempotency-Key", idempotencyKey)
+	}
+
+	raw, status, err := w.do(ctx, method, w.cfg.IrisBaseURL+path, w.cfg.IrisAPIKey, header, body)
+	if err != nil {
+		return
+	}
+
+	if status >= http.StatusMultipleChoices {
+		var irisErr struct {
+			ErrorMessage string        `json:"error_message"`
+			Errors       []interface{} `json:"errors"`
+		}
+		providerErr := &Error{HTTPStatus: status, ErrorCode: "UNKNOWN_ERROR", Message: strings.TrimSpace(string(raw))}
+		if json.Unmarshal(raw, &irisErr) == nil && irisErr.ErrorMessage != "" {
+			providerErr.ErrorCode = "IRIS_ERROR"
+			providerErr.Message = irisErr.ErrorMessage
+			providerErr.Errors = irisErr.Errors
+		}
+		err = providerErr
+		return
+	}
+
+	err = json.Unmarshal(raw, out)
+	if err != nil {
+		err = fmt.Errorf("midtrans unmarshal error: %w", err)
+	}
+	return
+}
+
+func (w *midtransWrapper) do(ctx context.Context, method, endpoint, key string, header http.Header, body interface{}) (raw []byte, status int, err error) {
+	var reader io.Reader
+	if body != nil {
+		payload, errMarshal := json.Marshal(body)
+		if errMarshal != nil {
+			err = fmt.Errorf("midtrans marshal error: %w", errMarshal)
+			return
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		err = fmt.Errorf("midtrans request error: %w", err)
+		return
+	}
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/json")
+	request.SetBasicAuth(key, "")
+	for name := range header {
+		request.Header.Set(name, header.Get(name))
+	}
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		err = fmt.Errorf("midtrans %s %s error: %w", method, endpoint, err)
+		return
+	}
+	defer response.Body.Close()
+
+	raw, err = io.ReadAll(response.Body)
+	if err != nil {
+		err = fmt.Errorf("midtrans read body error: %w", err)
+		return
+	}
+	status = response.StatusCode
+	return
+}
@@ -0,0 +1,122 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMidtrans(t *testing.T, handler http.HandlerFunc) *midtransWrapper {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewMidtransWrapper(config.MidtransConfig{
+		ServerKey:   "SB-Mid-server-key",
+		IrisAPIKey:  "iris-creator-key",
+		BaseURL:     server.URL,
+		IrisBaseURL: server.URL + "/iris",
+		Timeout:     time.Second,
+	})
+}
+
+func TestMidtransWrapper_CreateVA(t *testing.T) {
+	wrapper := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		username, _, _ := r.BasicAuth()
+		require.Equal(t, "SB-Mid-server-key", username)
+		require.Equal(t, "/v2/charge", r.URL.Path)
+
+		var body midtransChargeRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "bank_transfer", body.PaymentType)
+		require.Equal(t, "bni", body.BankTransfer.Bank)
+		require.Equal(t, int64(100000), body.TransactionDetails.GrossAmount)
+
+		w.Write([]byte(`{
+			"status_code": "201",
+			"transaction_id": "9aed5972-5b6a-401e-894b-a32c91ed1a3a",
+			"order_id": "TF-ABCDE12345",
+			"gross_amount": "100000.00",
+			"transaction_status": "pending",
+			"va_numbers": [{"bank": "bni", "va_number": "9888800012345678"}],
+			"expiry_time": "2030-01-02 07:00:00"
+		}`))
+	})
+
+	resp, err := wrapper.CreateVA(context.TODO(), CreateVARequest{ExternalID: "TF-ABCDE12345", BankCode: "BNI", ExpectedAmount: 100000})
+	require.NoError(t, err)
+	require.Equal(t, "9aed5972-5b6a-401e-894b-a32c91ed1a3a", resp.Data.ID)
+	require.Equal(t, "9888800012345678", resp.Data.AccountNumber)
+	require.Equal(t, "PENDING", resp.Data.Status)
+	require.Equal(t, float64(100000), resp.Data.ExpectedAmount)
+	require.Equal(t, time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), *resp.Data.ExpirationDate)
+}
+
+func TestMidtransWrapper_TopUpErrorInBody(t *testing.T) {
+	wrapper := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2/TF-ABCDE12345/status", r.URL.Path)
+		w.Write([]byte(`{"status_code": "404", "status_message": "Transaction doesn't exist."}`))
+	})
+
+	_, err := wrapper.TopUp(context.TODO(), TopUpRequest{ExternalID: "TF-ABCDE12345"})
+	require.Equal(t, &Error{HTTPStatus: http.StatusNotFound, ErrorCode: "404", Message: "Transaction doesn't exist."}, err)
+}
+
+func TestMidtransWrapper_Withdraw(t *testing.T) {
+	wrapper := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		username, _, _ := r.BasicAuth()
+		require.Equal(t, "iris-creator-key", username)
+		require.Equal(t, "/iris/api/v1/payouts", r.URL.Path)
+		require.Equal(t, "TF-ABCDE12345", r.Header.Get("X-Idempotency-Key"))
+
+		var body midtransPayoutRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "bca", body.Payouts[0].BeneficiaryBank)
+		require.Equal(t, "50000", body.Payouts[0].Amount)
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"payouts": [{"status": "queued", "reference_no": "1d4f8d2c4e3b"}]}`))
+	})
+
+	resp, err := wrapper.Withdraw(context.TODO(), WithdrawRequest{
+		ExternalID:        "TF-ABCDE12345",
+		BankCode:          "BCA",
+		AccountHolderName: "Daniel Alexander",
+		AccountNumber:     "1234567890",
+		Amount:            50000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "1d4f8d2c4e3b", resp.Data.ID)
+	require.Equal(t, "PENDING", resp.Data.Status)
+}
+
+func TestMidtransWrapper_WithdrawStatus(t *testing.T) {
+	wrapper := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/iris/api/v1/payouts/1d4f8d2c4e3b", r.URL.Path)
+		w.Write([]byte(`{"amount": "50000.0", "beneficiary_bank": "bca", "status": "completed", "reference_no": "1d4f8d2c4e3b"}`))
+	})
+
+	resp, err := wrapper.WithdrawStatus(context.TODO(), WithdrawStatusRequest{ID: "1d4f8d2c4e3b", ExternalID: "TF-ABCDE12345"})
+	require.NoError(t, err)
+	require.Equal(t, "COMPLETED", resp.Data.Status)
+	require.Equal(t, 50000, resp.Data.Amount)
+}
+
+func TestMidtransWrapper_AccountInquiry(t *testing.T) {
+	wrapper := newTestMidtrans(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/iris/api/v1/account_validation", r.URL.Path)
+		require.Equal(t, "bca", r.URL.Query().Get("bank"))
+		require.Equal(t, "1234567890", r.URL.Query().Get("account"))
+		w.Write([]byte(`{"account_name": "DANIEL ALEXANDER", "account_no": "1234567890", "bank_name": "bca"}`))
+	})
+
+	resp, err := wrapper.AccountInquiry(context.TODO(), AccountInquiryRequest{BankCode: "BCA", AccountNumber: "1234567890"})
+	require.NoError(t, err)
+	require.Equal(t, "DANIEL ALEXANDER", resp.Data.AccountHolderName)
+	require.Equal(t, "BCA", resp.Data.BankCode)
+}
@@ -0,0 +1,155 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+// sandboxWrapper answers every call with canned provider data, top ups and
+// disbursements complete immediately. It is meant for local development.
+type sandboxWrapper struct {
+}
+
+func NewSandboxWrapper() *sandboxWrapper {
+	return &sandboxWrapper{}
+}
+
+func (w *sandboxWrapper) CreateVA(ctx context.Context, req CreateVARequest) (resp CreateVAResponse, err error) {
+	isSingleUse := true
+	isClosed := true
+	expiredDate := time.Now().Add(60 * time.Minute).UTC()
+
+	mockResponseData := CreateVAResponseData{
+		OwnerID:         "57b4e5181473eeb61c11f9b9",
+		ExternalID:      req.ExternalID,
+		BankCode:        "BNI",
+		MerchantCode:    "8808",
+		Name:            "Michael Chen",
+		AccountNumber:   "8808999939380502",
+		IsClosed:        &isClosed,
+		ID:              "57f6fbf26b9f064272622aa6",
+		IsSingleUse:     &isSingleUse,
+		Status:          "PENDING",
+		Currency:        "IDR",
+		ExpirationDate:  &expiredDate,
+		SuggestedAmount: req.ExpectedAmount,
+		ExpectedAmount:  req.ExpectedAmount,
+		Description:     "mock payment",
+	}
+
+	response := CreateVAResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    mockResponseData,
+	}
+
+	return response, nil
+}
+
+func (w *sandboxWrapper) TopUp(ctx context.Context, req TopUpRequest) (resp TopUpResponse, err error) {
+	expectedAmount := 100000
+	isSingleUse := true
+	isClosed := true
+	expiredDate := time.Now().Add(60 * time.Minute).UTC()
+
+	mockResponseData := TopUpResponseData{
+		OwnerID:         "57b4e5181473eeb61c11f9b9",
+		ExternalID:      req.ExternalID,
+		BankCode:        "BNI",
+		MerchantCode:    "8808",
+		Name:            "Michael Chen",
+		AccountNumber:   "8808999939380502",
+		IsClosed:        &isClosed,
+		ID:              "57f6fbf26b9f064272622aa6",
+		IsSingleUse:     &isSingleUse,
+		Status:          "COMPLETED",
+		Currency:        "IDR",
+		ExpirationDate:  &expiredDate,
+		SuggestedAmount: float64(expectedAmount),
+		ExpectedAmount:  float64(expectedAmount),
+		Description:     "mock payment",
+	}
+
+	response := TopUpResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    mockResponseData,
+	}
+
+	return response, nil
+}
+
+func (w *sandboxWrapper) CloseVA(ctx context.Context, req CloseVARequest) (resp CloseVAResponse, err error) {
+	expiredDate := time.Now().UTC()
+
+	mockResponseData := CreateVAResponseData{
+		ExternalID:     req.ExternalID,
+		ID:             req.ID,
+		Status:         "INACTIVE",
+		ExpirationDate: &expiredDate,
+	}
+
+	response := CloseVAResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    mockResponseData,
+	}
+
+	return response, nil
+}
+
+func (w *sandboxWrapper) Withdraw(ctx context.Context, req WithdrawRequest) (resp WithdrawResponse, err error) {
+	mockResponseData := WithdrawResponseData{
+		ID:                      "57f1ce05bb1a631a65eee662",
+		ExternalID:              req.ExternalID,
+		UserID:                  "5785e6334d7b410667d355c4",
+		BankCode:                req.BankCode,
+		AccountHolderName:       req.AccountHolderName,
+		Amount:                  req.Amount,
+		DisbursementDescription: req.Description,
+		Status:                  "COMPLETED",
+	}
+
+	response := WithdrawResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    mockResponseData,
+	}
+
+	return response, nil
+}
+
+func (w *sandboxWrapper) WithdrawStatus(ctx context.Context, req WithdrawStatusRequest) (resp WithdrawResponse, err error) {
+	mockResponseData := WithdrawResponseData{
+		ID:                      "57f1ce05bb1a631a65eee662",
+		ExternalID:              req.ExternalID,
+		UserID:                  "5785e6334d7b410667d355c4",
+		DisbursementDescription: "mock disbursement",
+		Status:                  "COMPLETED",
+	}
+
+	response := WithdrawResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    mockResponseData,
+	}
+
+	return response, nil
+}
+
+// AccountInquiry answers with the name the player gave, so local accounts always verify.
+func (w *sandboxWrapper) AccountInquiry(ctx context.Context, req AccountInquiryRequest) (resp AccountInquiryResponse, err error) {
+	response := AccountInquiryResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: AccountInquiryResponseData{
+			BankCode:          req.BankCode,
+			AccountNumber:     req.AccountNumber,
+			AccountHolderName: req.ExpectedHolderName,
+		},
+	}
+
+	return response, nil
+}
@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: /Users/daniel/Desktop/danielpnjt/speed-engine/internal/infrastructure/redis/wrapper.go
+// Source: internal/infrastructure/redis/wrapper.go
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -49,6 +49,21 @@ func (mr *MockWrapperMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWrapper)(nil).Delete), ctx, key)
 }
 
+// DeleteIfEqual mocks base method.
+func (m *MockWrapper) DeleteIfEqual(ctx context.Context, key string, req interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIfEqual", ctx, key, req)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIfEqual indicates an expected call of DeleteIfEqual.
+func (mr *MockWrapperMockRecorder) DeleteIfEqual(ctx, key, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIfEqual", reflect.TypeOf((*MockWrapper)(nil).DeleteIfEqual), ctx, key, req)
+}
+
 // Get mocks base method.
 func (m *MockWrapper) Get(ctx context.Context, key string) (interface{}, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +94,74 @@ func (mr *MockWrapperMockRecorder) GetTTL(ctx, key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTTL", reflect.TypeOf((*MockWrapper)(nil).GetTTL), ctx, key)
 }
 
+// Incr mocks base method.
+func (m *MockWrapper) Incr(ctx context.Context, key string, expirationTime time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key, expirationTime)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockWrapperMockRecorder) Incr(ctx, key, expirationTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockWrapper)(nil).Incr), ctx, key, expirationTime)
+}
+
+// SAdd mocks base method.
+func (m *MockWrapper) SAdd(ctx context.Context, key string, expirationTime time.Duration, members ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key, expirationTime}
+	for _, a := range members {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SAdd", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SAdd indicates an expected call of SAdd.
+func (mr *MockWrapperMockRecorder) SAdd(ctx, key, expirationTime interface{}, members ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key, expirationTime}, members...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SAdd", reflect.TypeOf((*MockWrapper)(nil).SAdd), varargs...)
+}
+
+// SMembers mocks base method.
+func (m *MockWrapper) SMembers(ctx context.Context, key string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SMembers", ctx, key)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SMembers indicates an expected call of SMembers.
+func (mr *MockWrapperMockRecorder) SMembers(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SMembers", reflect.TypeOf((*MockWrapper)(nil).SMembers), ctx, key)
+}
+
+// SRem mocks base method.
+func (m *MockWrapper) SRem(ctx context.Context, key string, members ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, key}
+	for _, a := range members {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SRem", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SRem indicates an expected call of SRem.
+func (mr *MockWrapperMockRecorder) SRem(ctx, key interface{}, members ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, key}, members...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SRem", reflect.TypeOf((*MockWrapper)(nil).SRem), varargs...)
+}
+
 // Set mocks base method.
 func (m *MockWrapper) Set(ctx context.Context, key string, expirationTime time.Duration, req interface{}) error {
 	m.ctrl.T.Helper()
@@ -92,3 +175,18 @@ func (mr *MockWrapperMockRecorder) Set(ctx, key, expirationTime, req interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockWrapper)(nil).Set), ctx, key, expirationTime, req)
 }
+
+// SetNX mocks base method.
+func (m *MockWrapper) SetNX(ctx context.Context, key string, expirationTime time.Duration, req interface{}) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNX", ctx, key, expirationTime, req)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNX indicates an expected call of SetNX.
+func (mr *MockWrapperMockRecorder) SetNX(ctx, key, expirationTime, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNX", reflect.TypeOf((*MockWrapper)(nil).SetNX), ctx, key, expirationTime, req)
+}
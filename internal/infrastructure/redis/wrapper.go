@@ -7,7 +7,13 @@ import (
 
 type Wrapper interface {
 	Set(ctx context.Context, key string, expirationTime time.Duration, req interface{}) (err error)
+	SetNX(ctx context.Context, key string, expirationTime time.Duration, req interface{}) (ok bool, err error)
 	Get(ctx context.Context, key string) (resp interface{}, err error)
 	GetTTL(ctx context.Context, key string) (resp time.Duration, err error)
 	Delete(ctx context.Context, key string) (err error)
+	DeleteIfEqual(ctx context.Context, key string, req interface{}) (ok bool, err error)
+	Incr(ctx context.Context, key string, expirationTime time.Duration) (count int64, err error)
+	SAdd(ctx context.Context, key string, expirationTime time.Duration, members ...string) (err error)
+	SMembers(ctx context.Context, key string) (members []string, err error)
+	SRem(ctx context.Context, key string, members ...string) (err error)
 }
@@ -32,6 +32,22 @@ func (r *redisWrapper) Set(ctx context.Context, key string, expirationTime time.
 	return
 }
 
+// SetNX stores req only when the key does not exist yet and reports whether it did.
+func (r *redisWrapper) SetNX(ctx context.Context, key string, expirationTime time.Duration, req interface{}) (ok bool, err error) {
+	client := r.redis
+	json, err := json.Marshal(req)
+	if err != nil {
+		err = fmt.Errorf("redis marshal error: %w", err)
+		return
+	}
+	ok, err = client.SetNX(ctx, key, json, expirationTime).Result()
+	if err != nil {
+		err = fmt.Errorf("redis setnx error: %w", err)
+		return
+	}
+	return
+}
+
 func (r *redisWrapper) Get(ctx context.Context, key string) (resp interface{}, err error) {
 	client := r.redis
 	val, err := client.Get(ctx, key).Result()
@@ -54,6 +70,30 @@ func (r *redisWrapper) Delete(ctx context.Context, key string) (err error) {
 	}
 	return
 }
+
+var deleteIfEqual = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// DeleteIfEqual deletes the key only while it still holds req and reports whether it did. The
+// read and the delete run as one script, so of two concurrent callers only one gets ok.
+func (r *redisWrapper) DeleteIfEqual(ctx context.Context, key string, req interface{}) (ok bool, err error) {
+	client := r.redis
+	json, err := json.Marshal(req)
+	if err != nil {
+		err = fmt.Errorf("redis marshal error: %w", err)
+		return
+	}
+	deleted, err := deleteIfEqual.Run(ctx, client, []string{key}, string(json)).Int()
+	if err != nil {
+		err = fmt.Errorf("redis delete error: %w", err)
+		return
+	}
+	return deleted == 1, nil
+}
 func (r *redisWrapper) GetTTL(ctx context.Context, key string) (resp time.Duration, err error) {
 	client := r.redis
 	resp, err = client.TTL(ctx, key).Result()
@@ -62,3 +102,64 @@ func (r *redisWrapper) GetTTL(ctx context.Context, key string) (resp time.Durati
 	}
 	return
 }
+
+// Incr increments the counter at key. The expiry is only set while the counter has none, so it
+// measures a fixed window from the first increment instead of sliding with every call. Both run
+// in one transaction, a counter is never left without an expiry.
+func (r *redisWrapper) Incr(ctx context.Context, key string, expirationTime time.Duration) (count int64, err error) {
+	client := r.redis
+	var incr *redis.IntCmd
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, expirationTime)
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("redis incr error: %w", err)
+		return
+	}
+	count = incr.Val()
+	return
+}
+
+// SAdd adds members to the set at key and pushes the expiry of the whole set out to expirationTime.
+func (r *redisWrapper) SAdd(ctx context.Context, key string, expirationTime time.Duration, members ...string) (err error) {
+	client := r.redis
+	values := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		values = append(values, member)
+	}
+	pipe := client.TxPipeline()
+	pipe.SAdd(ctx, key, values...)
+	pipe.Expire(ctx, key, expirationTime)
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		err = fmt.Errorf("redis sadd error: %w", err)
+		return
+	}
+	return
+}
+
+func (r *redisWrapper) SMembers(ctx context.Context, key string) (members []string, err error) {
+	client := r.redis
+	members, err = client.SMembers(ctx, key).Result()
+	if err != nil {
+		err = fmt.Errorf("redis smembers error: %w", err)
+		return
+	}
+	return
+}
+
+func (r *redisWrapper) SRem(ctx context.Context, key string, members ...string) (err error) {
+	client := r.redis
+	values := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		values = append(values, member)
+	}
+	err = client.SRem(ctx, key, values...).Err()
+	if err != nil {
+		err = fmt.Errorf("redis srem error: %w", err)
+		return
+	}
+	return
+}
@@ -0,0 +1,11 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+type Wrapper interface {
+	Put(ctx context.Context, name string, content io.Reader) (err error)
+	Open(ctx context.Context, name string) (content io.ReadCloser, err error)
+}
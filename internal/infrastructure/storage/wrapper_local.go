@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// localWrapper keeps files in a directory on the local disk.
+type localWrapper struct {
+	dir string
+}
+
+func NewLocalWrapper(dir string) *localWrapper {
+	if dir == "" {
+		panic("storage dir is empty")
+	}
+	return &localWrapper{dir: dir}
+}
+
+func (w *localWrapper) Put(ctx context.Context, name string, content io.Reader) (err error) {
+	path, err := w.path(name)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		err = fmt.Errorf("failed to create storage dir: %w", err)
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		err = fmt.Errorf("failed to create file: %w", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(file, content); err != nil {
+		err = fmt.Errorf("failed to write file: %w", err)
+		return
+	}
+	return
+}
+
+func (w *localWrapper) Open(ctx context.Context, name string) (content io.ReadCloser, err error) {
+	path, err := w.path(name)
+	if err != nil {
+		return
+	}
+	content, err = os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("failed to open file: %w", err)
+		return
+	}
+	return
+}
+
+// path keeps every file directly inside the storage dir, names carrying directories are refused.
+func (w *localWrapper) path(name string) (path string, err error) {
+	if name == "" || filepath.Base(name) != name || name == "." || name == ".." {
+		err = fmt.Errorf("invalid file name %q", name)
+		return
+	}
+	path = filepath.Join(w.dir, name)
+	return
+}
@@ -42,10 +42,58 @@ func (w *worker) EnqueueStatusTopUp(ctx context.Context, external string) (err e
 
 	res, err := w.transactionService.TopUp(ctx, external)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to execute get status ojk reporting pusdafil", err)
+		slog.ErrorContext(ctx, "failed to execute get status ojk reporting pusdafil", "error", err)
 		err = nil
 		return
 	}
-	slog.InfoContext(ctx, "success execute get status payment", res)
+	slog.InfoContext(ctx, "success execute get status payment", "response", res)
+	return
+}
+
+func (w *worker) EnqueueWithdrawTimeout(ctx context.Context, external string) (err error) {
+	ctxLogger := Context{
+		ServiceName:    fmt.Sprintf("%s-worker", config.GetString("app.name")),
+		ServiceVersion: config.GetString("app.version"),
+		ServicePort:    config.GetInt("app.port"),
+		Tag:            config.GetString("app.name"),
+		ReqMethod:      "enqueue-speed_engine-withdraw-timeout",
+		ReqURI:         "enqueue-speed_engine-withdraw-timeout",
+	}
+
+	ctx = context.WithValue(ctx, ctx, ctxLogger)
+
+	slog.InfoContext(ctx, "worker start running job")
+
+	res, err := w.transactionService.ExpireWithdraw(ctx, external)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to expire withdrawal hold", "error", err)
+		err = nil
+		return
+	}
+	slog.InfoContext(ctx, "success execute withdrawal hold timeout", "response", res)
+	return
+}
+
+func (w *worker) EnqueueExpireTopUps(ctx context.Context) (err error) {
+	ctxLogger := Context{
+		ServiceName:    fmt.Sprintf("%s-worker", config.GetString("app.name")),
+		ServiceVersion: config.GetString("app.version"),
+		ServicePort:    config.GetInt("app.port"),
+		Tag:            config.GetString("app.name"),
+		ReqMethod:      "enqueue-speed_engine-expire-topup",
+		ReqURI:         "enqueue-speed_engine-expire-topup",
+	}
+
+	ctx = context.WithValue(ctx, ctx, ctxLogger)
+
+	slog.InfoContext(ctx, "worker start running job")
+
+	res, err := w.transactionService.ExpireTopUps(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to expire top ups", "error", err)
+		err = nil
+		return
+	}
+	slog.InfoContext(ctx, "success execute expire top ups", "response", res)
 	return
 }
@@ -2,6 +2,7 @@ package queue
 
 import (
 	"github.com/RichardKnop/machinery/v1"
+	"github.com/RichardKnop/machinery/v1/tasks"
 	"github.com/danielpnjt/speed-engine/internal/config"
 	"github.com/danielpnjt/speed-engine/internal/usecase/transaction"
 	"gorm.io/gorm"
@@ -46,9 +47,21 @@ func (w *worker) RegisterTasks() *worker {
 	}
 
 	w.machineryServer.RegisterTasks(map[string]interface{}{
-		"enqueue-speed_engine-topup": w.EnqueueStatusTopUp,
+		"enqueue-speed_engine-topup":            w.EnqueueStatusTopUp,
+		"enqueue-speed_engine-withdraw-timeout": w.EnqueueWithdrawTimeout,
+		"enqueue-speed_engine-expire-topup":     w.EnqueueExpireTopUps,
 	})
 
+	// * machinery takes the redis lock from the worker config per tick, so only one instance sends the sweep
+	err := w.machineryServer.RegisterPeriodicTask(
+		config.GetString("worker.speed_engine.expireTopUpSchedule"),
+		"periodic-speed_engine-expire-topup",
+		&tasks.Signature{Name: "enqueue-speed_engine-expire-topup"},
+	)
+	if err != nil {
+		panic(err)
+	}
+
 	return w
 }
 
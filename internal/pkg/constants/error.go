@@ -3,5 +3,56 @@ package constants
 import "errors"
 
 var (
-	ErrDefaultMsg = errors.New("something went wrong")
+	ErrDefaultMsg        = errors.New("something went wrong")
+	ErrConcurrentUpdate  = errors.New("record was modified by another request")
+	ErrInsufficientFunds = errors.New("insufficient balance")
+	ErrInvalidTransition = errors.New("invalid transaction status transition")
+	ErrNotPending        = errors.New("request is no longer pending")
+	ErrNotManualTransfer = errors.New("transaction is not a manual transfer")
+	ErrUnknownReference  = errors.New("no transaction with this reference")
+	ErrCallbackMismatch  = errors.New("callback does not match the transaction")
+	ErrSelfReview        = errors.New("request must be reviewed by another admin")
+	ErrAccountInactive   = errors.New("account is not active")
+	ErrAccountClosed     = errors.New("account is closed")
+	ErrBalanceNotZero    = errors.New("account still holds a balance")
+	ErrPendingMovements  = errors.New("account still has pending transactions")
+
+	ErrLoginBlocked = errors.New("too many failed login attempts")
+
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+	ErrInvalidLoginChallenge = errors.New("login challenge is invalid or expired")
+	ErrInvalidSecondFactor   = errors.New("invalid two factor code")
+	ErrSecondFactorRequired  = errors.New("two factor code is required")
+	ErrTwoFactorEnabled      = errors.New("two factor authentication is already enabled")
+	ErrTwoFactorNotEnabled   = errors.New("two factor authentication is not enabled")
+
+	ErrEmailNotVerified     = errors.New("email is not verified")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrInvalidEmailToken    = errors.New("invalid or expired verification token")
+
+	ErrKYCPending       = errors.New("identity verification is already under review")
+	ErrKYCLimitExceeded = errors.New("amount is above the withdrawal limit of your verification level")
+	ErrNationalIDTaken  = errors.New("national id is already verified on another account")
+
+	ErrBankNotFound         = errors.New("bank account not found")
+	ErrBankLimitReached     = errors.New("maximum number of bank accounts reached")
+	ErrBankDuplicate        = errors.New("bank account is already registered")
+	ErrInvalidBankCode      = errors.New("bank is not supported")
+	ErrInvalidAccountNumber = errors.New("account number does not match the bank's format")
+	ErrBankNotVerified      = errors.New("bank account holder is not verified")
+	ErrBankNameMismatch     = errors.New("bank account holder does not match the registered name")
+	ErrBankInquiryLimit     = errors.New("too many bank account checks, try again later")
+
+	ErrFeeRuleInactive = errors.New("fee rule is already inactive")
+	ErrAmountBelowFee  = errors.New("amount does not cover the fee")
+
+	ErrAmountBelowMinimum    = errors.New("amount is below the minimum per transaction")
+	ErrAmountAboveMaximum    = errors.New("amount is above the maximum per transaction")
+	ErrDailyLimitExceeded    = errors.New("amount is above what is left of your daily limit")
+	ErrMonthlyLimitExceeded  = errors.New("amount is above what is left of your monthly limit")
+	ErrVelocityLimitExceeded = errors.New("too many transactions in a short time, try again later")
+
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, session revoked")
 )
@@ -7,9 +7,15 @@ import (
 	"github.com/golang-jwt/jwt"
 )
 
+const (
+	JwtIssuerUser  = "USER_SERVICE"
+	JwtIssuerAdmin = "ADMIN_SERVICE"
+)
+
 type Claims struct {
-	ID       int
-	Username string
+	ID        int
+	Username  string
+	SessionID string `json:",omitempty"`
 	jwt.StandardClaims
 }
 
@@ -18,15 +24,28 @@ type JWTClaimsData struct {
 	Username string `json:"username"`
 }
 
-func JwtSign(id int, username string) (token string, exp int64, err error) {
+// JwtSign signs a player access token bound to one device session, it is meant to be short
+// lived and renewed through the session's refresh token.
+func JwtSign(id int, username string, sessionID string, ttl time.Duration) (token string, exp int64, err error) {
+	return jwtSign(id, username, sessionID, JwtIssuerUser, ttl)
+}
+
+// JwtSignAdmin signs a back-office token, it lives for a working day and carries its own issuer
+// so a player token can never be presented to the admin routes.
+func JwtSignAdmin(id int, username string) (token string, exp int64, err error) {
+	return jwtSign(id, username, "", JwtIssuerAdmin, 12*time.Hour)
+}
+
+func jwtSign(id int, username string, sessionID string, issuer string, ttl time.Duration) (token string, exp int64, err error) {
 	secret := []byte(config.GetString("jwt"))
-	exp = time.Now().Add(7 * 24 * time.Hour).Unix()
+	exp = time.Now().Add(ttl).Unix()
 	claims := &Claims{
 		id,
 		username,
+		sessionID,
 		jwt.StandardClaims{
-			Issuer:    "USER_SERVICE",
-			ExpiresAt: exp, // * 7 days
+			Issuer:    issuer,
+			ExpiresAt: exp,
 		},
 	}
 	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
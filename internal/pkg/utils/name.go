@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NameSimilarity scores how alike two person names are from 0 to 1. Case, punctuation and word
+// order are ignored, so "Alexander, Daniel" and "DANIEL ALEXANDER" score 1.
+func NameSimilarity(a string, b string) float64 {
+	tokensA, tokensB := nameTokens(a), nameTokens(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+	ordered := similarity(strings.Join(tokensA, " "), strings.Join(tokensB, " "))
+
+	sort.Strings(tokensA)
+	sort.Strings(tokensB)
+	sorted := similarity(strings.Join(tokensA, " "), strings.Join(tokensB, " "))
+
+	if sorted > ordered {
+		return sorted
+	}
+	return ordered
+}
+
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// similarity is one minus the edit distance relative to the longer string.
+func similarity(a string, b string) float64 {
+	runesA, runesB := []rune(a), []rune(b)
+	longest := len(runesA)
+	if len(runesB) > longest {
+		longest = len(runesB)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(runesA, runesB))/float64(longest)
+}
+
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       string
+		b       string
+		atLeast float64
+		below   float64
+	}{
+		{name: "case and punctuation", a: "Daniel Alexander", b: "DANIEL, ALEXANDER.", atLeast: 1, below: 1.01},
+		{name: "word order", a: "Alexander Daniel", b: "Daniel Alexander", atLeast: 1, below: 1.01},
+		{name: "typo", a: "Daniel Alexander", b: "Danial Alexandre", atLeast: 0.8, below: 1},
+		{name: "different person", a: "Daniel Alexander", b: "Michael Chen", atLeast: 0, below: 0.5},
+		{name: "empty", a: "", b: "Daniel", atLeast: 0, below: 0.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := NameSimilarity(tt.a, tt.b)
+			require.GreaterOrEqual(t, score, tt.atLeast)
+			require.Less(t, score, tt.below)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+)
+
+const sideEffectKey = types.String("sideEffect")
+
+// TrackSideEffect returns a context that remembers whether a usecase marked it with MarkSideEffect.
+func TrackSideEffect(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sideEffectKey, &atomic.Bool{})
+}
+
+// MarkSideEffect records that the request changed state, a payment provider call or a committed
+// write, so a failure after this point can't be undone by running the request again.
+func MarkSideEffect(ctx context.Context) {
+	if happened, ok := ctx.Value(sideEffectKey).(*atomic.Bool); ok {
+		happened.Store(true)
+	}
+}
+
+// HadSideEffect reports whether the request was marked with MarkSideEffect.
+func HadSideEffect(ctx context.Context) bool {
+	happened, ok := ctx.Value(sideEffectKey).(*atomic.Bool)
+	return ok && happened.Load()
+}
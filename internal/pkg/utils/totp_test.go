@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// * RFC 6238 appendix B vectors for SHA1, cut down to six digits
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(secret, time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tt.want, code, "time %d", tt.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	previous, err := TOTPCode(secret, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	stale, err := TOTPCode(secret, now.Add(-90*time.Second))
+	require.NoError(t, err)
+
+	step, ok := ValidateTOTP(secret, previous, now)
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/30-1, step)
+
+	_, ok = ValidateTOTP(secret, stale, now)
+	require.False(t, ok)
+}
@@ -3,23 +3,17 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/hex"
-	"fmt"
 	"strings"
-	"time"
 )
 
-func GeneratePaymentRef(AccountNumber string) (string, error) {
-	randomBytes := make([]byte, 5)
+// GeneratePaymentRef returns a reference made of 16 random bytes, the reference identifies the
+// transaction at the provider and in every callback so it must not repeat.
+func GeneratePaymentRef() (string, error) {
+	randomBytes := make([]byte, 16)
 	_, err := rand.Read(randomBytes)
 	if err != nil {
 		return "", err
 	}
 
-	randomString := strings.ToUpper(hex.EncodeToString(randomBytes))
-	phoneLast5 := AccountNumber[len(AccountNumber)-5:]
-	secondsInDay := time.Now().Hour()*3600 + time.Now().Minute()*60 + time.Now().Second()
-	timeComponent := fmt.Sprintf("%05d", secondsInDay)
-	paymentCode := fmt.Sprintf("TF-%s%s%s", randomString[:3], phoneLast5[:2], timeComponent[:5])
-
-	return paymentCode, nil
+	return "TF-" + strings.ToUpper(hex.EncodeToString(randomBytes)), nil
 }
@@ -31,7 +31,7 @@ func Validate(c echo.Context, s interface{}) (err error) {
 
 	if err = c.Bind(s); err != nil {
 		errMsg := err.Error()
-		slog.ErrorContext(ctx, "error bind", err.Error())
+		slog.ErrorContext(ctx, "error bind", "error", err.Error())
 		if strings.Contains(errMsg, "invalid syntax") {
 			re := regexp.MustCompile(`"([^"]+)"`)
 			matches := re.FindAllStringSubmatch(errMsg, -1)
@@ -50,7 +50,7 @@ func Validate(c echo.Context, s interface{}) (err error) {
 	errVal := c.Validate(s)
 	if errVal != nil {
 		err = castedValidate(errVal.(validator.ValidationErrors))
-		slog.ErrorContext(ctx, "error validate [a]", err.Error())
+		slog.ErrorContext(ctx, "error validate [a]", "error", err.Error())
 		c.Set("invalid-format", true)
 		return
 	}
@@ -62,7 +62,7 @@ func ValidateAll(c echo.Context, s interface{}) (err error) {
 	ctx := c.Request().Context()
 
 	if err = c.Bind(s); err != nil {
-		slog.ErrorContext(ctx, "error bind", err.Error())
+		slog.ErrorContext(ctx, "error bind", "error", err.Error())
 		err = fmt.Errorf("%s", "Something Went Wrong")
 		return
 	}
@@ -73,7 +73,7 @@ func ValidateAll(c echo.Context, s interface{}) (err error) {
 		errObj := castedValidateAll(errVal.(validator.ValidationErrors), structType)
 		errStr, _ := json.Marshal(errObj)
 		err = fmt.Errorf("%s", errStr)
-		slog.ErrorContext(ctx, "error validate [b]", err)
+		slog.ErrorContext(ctx, "error validate [b]", "error", err)
 		c.Set("invalid-format", true)
 		return
 	}
@@ -93,7 +93,7 @@ func ValidateAllStruct(ctx context.Context, s interface{}) (err error) {
 		errObj := castedValidateAll(errVal.(validator.ValidationErrors), structType)
 		errStr, _ := json.Marshal(errObj)
 		err = fmt.Errorf("%s", errStr)
-		slog.ErrorContext(ctx, "error validate [c]", err)
+		slog.ErrorContext(ctx, "error validate [c]", "error", err)
 		return
 	}
 	return
@@ -123,7 +123,7 @@ func Sanitize(fl validator.FieldLevel) bool {
 func IsValidDate(ctx context.Context, s string) (err error) {
 	_, err = time.Parse("02-01-2006", s)
 	if err != nil {
-		slog.ErrorContext(ctx, "error validate date format", err)
+		slog.ErrorContext(ctx, "error validate date format", "error", err)
 		err = fmt.Errorf("date is not valid format (dd-mm-yyyy)")
 	}
 	return
@@ -132,7 +132,7 @@ func IsValidDate(ctx context.Context, s string) (err error) {
 // func ValidateUUID(ctx context.Context, u string) (err error) {
 // 	_, err = uuid.Parse(u)
 // 	if err != nil {
-// 		slog.ErrorContext(ctx, "failed to parse uuid", err)
+// 		slog.ErrorContext(ctx, "failed to parse uuid", "error", err)
 // 		err = fmt.Errorf("invalid uuid")
 // 		return
 // 	}
@@ -148,19 +148,19 @@ func ValidateMultipartFormValue(ctx context.Context, values map[string][]string,
 	}
 	rawByte, err := json.Marshal(rawRequest)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to validate multipart form value", err)
+		slog.ErrorContext(ctx, "failed to validate multipart form value", "error", err)
 		err = fmt.Errorf("something went wrong")
 		return
 	}
 	err = json.Unmarshal(rawByte, target)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to validate multipart form value", err)
+		slog.ErrorContext(ctx, "failed to validate multipart form value", "error", err)
 		err = fmt.Errorf("something went wrong")
 		return
 	}
 	err = ValidateStruct(ctx, target)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to validate multipart form value", err)
+		slog.ErrorContext(ctx, "failed to validate multipart form value", "error", err)
 	}
 	return
 }
@@ -2,7 +2,7 @@ package handler
 
 import (
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/container"
-	"github.com/redis/go-redis/v9"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
 	"gorm.io/gorm"
 )
 
@@ -12,8 +12,13 @@ type Handler struct {
 	userHandler        *userHandler
 	bankHandler        *bankHandler
 	transactionHandler *transactionHandler
+	ledgerHandler      *ledgerHandler
 	adminHandler       *adminHandler
-	redisClient        *redis.Client
+	auditHandler       *auditHandler
+	kycHandler         *kycHandler
+	feeHandler         *feeHandler
+	limitHandler       *limitHandler
+	redisWrapper       redis.Wrapper
 }
 
 func SetupHandler(container *container.Container) *Handler {
@@ -23,7 +28,13 @@ func SetupHandler(container *container.Container) *Handler {
 		userHandler:        NewUserHandler().SetUserService(container.UserService).Validate(),
 		bankHandler:        NewBankHandler().SetBankService(container.BankService).Validate(),
 		transactionHandler: NewTransactionHandler().SetTransactionService(container.TransactionService).Validate(),
-		redisClient:        container.RedisClient,
+		ledgerHandler:      NewLedgerHandler().SetLedgerService(container.LedgerService).Validate(),
+		adminHandler:       NewAdminHandler().SetAdminService(container.AdminService).Validate(),
+		auditHandler:       NewAuditHandler().SetAuditService(container.AuditService).Validate(),
+		kycHandler:         NewKYCHandler().SetKYCService(container.KYCService).Validate(),
+		feeHandler:         NewFeeHandler().SetFeeService(container.FeeService).Validate(),
+		limitHandler:       NewLimitHandler().SetLimitService(container.LimitService).Validate(),
+		redisWrapper:       redis.NewRedisConnection(container.RedisClient),
 	}
 }
 
@@ -43,8 +54,26 @@ func (h *Handler) Validate() *Handler {
 	if h.transactionHandler == nil {
 		panic("transactionHandler is nil")
 	}
-	if h.redisClient == nil {
-		panic("redisClient is nil")
+	if h.ledgerHandler == nil {
+		panic("ledgerHandler is nil")
+	}
+	if h.adminHandler == nil {
+		panic("adminHandler is nil")
+	}
+	if h.auditHandler == nil {
+		panic("auditHandler is nil")
+	}
+	if h.kycHandler == nil {
+		panic("kycHandler is nil")
+	}
+	if h.feeHandler == nil {
+		panic("feeHandler is nil")
+	}
+	if h.limitHandler == nil {
+		panic("limitHandler is nil")
+	}
+	if h.redisWrapper == nil {
+		panic("redisWrapper is nil")
 	}
 	return h
 }
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"net/http"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
 	"github.com/danielpnjt/speed-engine/internal/usecase/admin"
 	"github.com/labstack/echo/v4"
 )
@@ -26,9 +29,188 @@ func (h *adminHandler) Validate() *adminHandler {
 }
 
 func (h *adminHandler) GetAll(c echo.Context) (err error) {
-	return
+	ctx := c.Request().Context()
+
+	var req admin.FindAllTransactionRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.GetAllTransaction(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) GetByReference(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.FindTransactionByReferenceRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.GetTransactionByReference(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
 }
 
 func (h *adminHandler) GetByUserID(c echo.Context) (err error) {
-	return
+	ctx := c.Request().Context()
+
+	var req admin.FindTransactionByUserIDRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.GetTransactionByUserID(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) GetAllAdjustment(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.FindAllAdjustmentRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.GetAllAdjustment(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) ProposeAdjustment(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.ProposeAdjustmentRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.ProposeAdjustment(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) ApproveAdjustment(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.ReviewAdjustmentRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.ApproveAdjustment(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) RejectAdjustment(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.ReviewAdjustmentRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.RejectAdjustment(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) Login(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.LoginRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.Login(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) Logout(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.adminService.Logout(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) GetProfile(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.adminService.GetProfile(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) GetAllAdmin(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.FindAllAdminRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.GetAllAdmin(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) CreateAdmin(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.CreateAdminRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.CreateAdmin(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *adminHandler) UpdateAdmin(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req admin.UpdateAdminRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.adminService.UpdateAdmin(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
 }
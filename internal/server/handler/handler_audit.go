@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
+	"github.com/labstack/echo/v4"
+)
+
+type auditHandler struct {
+	auditService audit.Service
+}
+
+func NewAuditHandler() *auditHandler {
+	return &auditHandler{}
+}
+
+func (h *auditHandler) SetAuditService(service audit.Service) *auditHandler {
+	h.auditService = service
+	return h
+}
+
+func (h *auditHandler) Validate() *auditHandler {
+	if h.auditService == nil {
+		panic("auditService is nil")
+	}
+	return h
+}
+
+func (h *auditHandler) Search(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req audit.SearchRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.auditService.Search(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *auditHandler) Verify(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.auditService.Verify(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -43,6 +43,77 @@ func (h *bankHandler) SubmitBank(c echo.Context) (err error) {
 	return c.JSON(http.StatusOK, res)
 }
 
+func (h *bankHandler) UpdateBank(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req bank.UpdateBank
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.bankService.UpdateBank(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *bankHandler) DeleteBank(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req bank.FindByIDRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.bankService.DeleteBank(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *bankHandler) SetPrimary(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req bank.FindByIDRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.bankService.SetPrimary(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *bankHandler) VerifyBank(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req bank.FindByIDRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.bankService.VerifyBank(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *bankHandler) GetBankCodes(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.bankService.GetBankCodes(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
 func (h *bankHandler) FindAll(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 
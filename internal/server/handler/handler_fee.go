@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/fee"
+	"github.com/labstack/echo/v4"
+)
+
+type feeHandler struct {
+	feeService fee.Service
+}
+
+func NewFeeHandler() *feeHandler {
+	return &feeHandler{}
+}
+
+func (h *feeHandler) SetFeeService(service fee.Service) *feeHandler {
+	h.feeService = service
+	return h
+}
+
+func (h *feeHandler) Validate() *feeHandler {
+	if h.feeService == nil {
+		panic("feeService is nil")
+	}
+	return h
+}
+
+func (h *feeHandler) GetAll(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req fee.FindAllRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.feeService.GetAll(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *feeHandler) Create(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req fee.CreateRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.feeService.Create(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *feeHandler) Deactivate(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req fee.DeactivateRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.feeService.Deactivate(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
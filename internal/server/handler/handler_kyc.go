@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/kyc"
+	"github.com/labstack/echo/v4"
+)
+
+type kycHandler struct {
+	kycService kyc.Service
+}
+
+func NewKYCHandler() *kycHandler {
+	return &kycHandler{}
+}
+
+func (h *kycHandler) SetKYCService(service kyc.Service) *kycHandler {
+	h.kycService = service
+	return h
+}
+
+func (h *kycHandler) Validate() *kycHandler {
+	if h.kycService == nil {
+		panic("kycService is nil")
+	}
+	return h
+}
+
+func (h *kycHandler) Submit(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, "request must be a multipart form")
+	}
+	var req kyc.SubmitRequest
+	if err = utils.ValidateMultipartFormValue(ctx, form.Value, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	if files := form.File["document"]; len(files) > 0 {
+		req.Document = files[0]
+	}
+	res, err := h.kycService.Submit(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *kycHandler) GetStatus(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.kycService.GetStatus(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *kycHandler) GetAll(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req kyc.FindAllRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.kycService.GetAll(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *kycHandler) GetDetail(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req kyc.FindByIDRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.kycService.GetDetail(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *kycHandler) GetDocument(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req kyc.FindByIDRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	content, contentType, err := h.kycService.GetDocument(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	defer content.Close()
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.Stream(http.StatusOK, contentType, content)
+}
+
+func (h *kycHandler) Approve(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req kyc.ApproveRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.kycService.Approve(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *kycHandler) Reject(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req kyc.RejectRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.kycService.Reject(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/danielpnjt/speed-engine/internal/usecase/ledger"
+	"github.com/labstack/echo/v4"
+	"github.com/spf13/cast"
+)
+
+type ledgerHandler struct {
+	ledgerService ledger.Service
+}
+
+func NewLedgerHandler() *ledgerHandler {
+	return &ledgerHandler{}
+}
+
+func (h *ledgerHandler) SetLedgerService(service ledger.Service) *ledgerHandler {
+	h.ledgerService = service
+	return h
+}
+
+func (h *ledgerHandler) Validate() *ledgerHandler {
+	if h.ledgerService == nil {
+		panic("ledgerService is nil")
+	}
+	return h
+}
+
+func (h *ledgerHandler) Reconcile(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	userID, err := cast.ToIntE(c.Param("userID"))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed convert id in param into int", "error", err)
+		err = errors.New("invalid request format")
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	res, err := h.ledgerService.Reconcile(ctx, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
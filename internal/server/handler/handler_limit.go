@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/danielpnjt/speed-engine/internal/usecase/limit"
+	"github.com/labstack/echo/v4"
+)
+
+type limitHandler struct {
+	limitService limit.Service
+}
+
+func NewLimitHandler() *limitHandler {
+	return &limitHandler{}
+}
+
+func (h *limitHandler) SetLimitService(service limit.Service) *limitHandler {
+	h.limitService = service
+	return h
+}
+
+func (h *limitHandler) Validate() *limitHandler {
+	if h.limitService == nil {
+		panic("limitService is nil")
+	}
+	return h
+}
+
+func (h *limitHandler) GetRemaining(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.limitService.GetRemaining(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
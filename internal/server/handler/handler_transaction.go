@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
 	"github.com/danielpnjt/speed-engine/internal/usecase/transaction"
 	"github.com/labstack/echo/v4"
@@ -57,3 +60,135 @@ func (h *transactionHandler) Withdraw(c echo.Context) (err error) {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (h *transactionHandler) Quote(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req transaction.QuoteRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.transactionService.Quote(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *transactionHandler) FindAll(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req transaction.FindAllRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.transactionService.FindAll(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *transactionHandler) FindByReference(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req transaction.FindByReferenceRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.transactionService.FindByReference(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *transactionHandler) CallbackVA(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req transaction.VACallbackRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	req.CallbackID = callbackID(c, req.ID)
+
+	res, err := h.transactionService.CallbackVA(ctx, req)
+	if err != nil {
+		return callbackError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *transactionHandler) CallbackDisbursement(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req transaction.DisbursementCallbackRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	req.CallbackID = callbackID(c, fmt.Sprintf("%s:%s", req.ID, req.Status))
+
+	res, err := h.transactionService.CallbackDisbursement(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *transactionHandler) CallbackMidtrans(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req transaction.MidtransNotificationRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+	// * midtrans notifies every status change of a charge, each one is a callback of its own
+	req.CallbackID = fmt.Sprintf("%s:%s", req.TransactionID, req.TransactionStatus)
+
+	res, err := h.transactionService.CallbackMidtrans(ctx, req)
+	if err != nil {
+		return callbackError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *transactionHandler) SettleManual(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req transaction.SettleManualRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.transactionService.SettleManual(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// callbackError answers a callback that can never be processed with a 4xx, the provider gives up
+// on it. Anything else gets a 500, a non 2xx answer makes the provider deliver it again later.
+func callbackError(err error) error {
+	switch {
+	case errors.Is(err, constants.ErrUnknownReference):
+		return echo.NewHTTPError(http.StatusNotFound, err)
+	case errors.Is(err, constants.ErrCallbackMismatch):
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err)
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err)
+}
+
+// callbackID prefers the provider's webhook id header and falls back to an id taken from the payload.
+func callbackID(c echo.Context, fallback string) string {
+	if id := c.Request().Header.Get("webhook-id"); id != "" {
+		return id
+	}
+	return fallback
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "unknown reference", err: constants.ErrUnknownReference, want: http.StatusNotFound},
+		{name: "mismatch", err: fmt.Errorf("midtrans: %w", constants.ErrCallbackMismatch), want: http.StatusUnprocessableEntity},
+		{name: "temporary failure is redelivered", err: errors.New("failed to process va callback"), want: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var httpError *echo.HTTPError
+			require.ErrorAs(t, callbackError(tt.err), &httpError)
+			require.Equal(t, tt.want, httpError.Code)
+		})
+	}
+}
@@ -61,6 +61,163 @@ func (h *userHandler) Login(c echo.Context) (err error) {
 	return c.JSON(http.StatusOK, res)
 }
 
+func (h *userHandler) LoginTwoFactor(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.LoginTwoFactorRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.LoginTwoFactor(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) Refresh(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.RefreshRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.Refresh(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) VerifyEmail(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.VerifyEmailRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.VerifyEmail(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) ResendEmailVerification(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.userService.ResendEmailVerification(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) ForgotPassword(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.ForgotPasswordRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.ForgotPassword(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) ResetPassword(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.ResetPasswordRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.ResetPassword(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) ChangePassword(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.ChangePasswordRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.ChangePassword(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) SetupTwoFactor(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.userService.SetupTwoFactor(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) EnableTwoFactor(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.TwoFactorCodeRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.EnableTwoFactor(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) DisableTwoFactor(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.DisableTwoFactorRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.DisableTwoFactor(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) RegenerateRecoveryCodes(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.TwoFactorCodeRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+	res, err := h.userService.RegenerateRecoveryCodes(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
 func (h *userHandler) Logout(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 
@@ -72,12 +229,34 @@ func (h *userHandler) Logout(c echo.Context) (err error) {
 	return c.JSON(http.StatusOK, res)
 }
 
+func (h *userHandler) LogoutOthers(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.userService.LogoutOthers(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) GetSessions(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	res, err := h.userService.GetSessions(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
 func (h *userHandler) GetDetail(c echo.Context) (err error) {
 	ctx := c.Request().Context()
 
 	userID, err := cast.ToIntE(c.Param("userID"))
 	if err != nil {
-		slog.Error("failed convert id in param into int", err)
+		slog.Error("failed convert id in param into int", "error", err)
 		err = errors.New("invalid request format")
 		return
 	}
@@ -115,3 +294,99 @@ func (h *userHandler) GetDetailPlayer(c echo.Context) (err error) {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (h *userHandler) Freeze(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.ChangeStatusRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.userService.Freeze(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) Suspend(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.ChangeStatusRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.userService.Suspend(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) Close(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.ChangeStatusRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.userService.Close(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) Activate(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	var req user.ChangeStatusRequest
+	if err = utils.Validate(c, &req); err != nil {
+		return
+	}
+	res, err := h.userService.Activate(ctx, req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) Unlock(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	userID, err := cast.ToIntE(c.Param("userID"))
+	if err != nil {
+		slog.Error("failed convert id in param into int", "error", err)
+		err = errors.New("invalid request format")
+		return
+	}
+
+	res, err := h.userService.Unlock(ctx, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *userHandler) GetStatusLog(c echo.Context) (err error) {
+	ctx := c.Request().Context()
+
+	userID, err := cast.ToIntE(c.Param("userID"))
+	if err != nil {
+		slog.Error("failed convert id in param into int", "error", err)
+		err = errors.New("invalid request format")
+		return
+	}
+
+	res, err := h.userService.GetStatusLog(ctx, userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusOK, err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -1,50 +1,46 @@
 package handler
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"strings"
 
 	"github.com/danielpnjt/speed-engine/internal/config"
 	"github.com/danielpnjt/speed-engine/internal/domain/entities"
-	"github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
 	"github.com/danielpnjt/speed-engine/internal/pkg/types"
 	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/random"
 )
 
-func (h *Handler) BasicAuth(prefix string) func(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			ctx := c.Request().Context()
-			basicToken := c.Request().Header.Get("Authorization")
-			if basicToken == "" {
-				c.Set("unauthorized", true)
-				err := fmt.Errorf("authorization header is empty")
-				slog.ErrorContext(ctx, "authentication failed", err)
-				return err
-			}
-			sliceToken := strings.Split(basicToken, "Basic ")
-			if len(sliceToken) < 2 {
-				c.Set("unauthorized", true)
-				err := fmt.Errorf("basic token slice is not greater than 2")
-				slog.ErrorContext(ctx, "authentication failed", err)
-				return err
-			}
-			token := sliceToken[1]
-			username := config.GetString(fmt.Sprintf("basicAuth.%s.username", prefix))
-			password := config.GetString(fmt.Sprintf("basicAuth.%s.password", prefix))
-			if authToken := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password))); authToken != token {
-				c.Set("forbidden", true)
-				err := fmt.Errorf("invalid basic auth provided")
-				slog.ErrorContext(ctx, "authentication failed", prefix, err, token, authToken)
-				return err
-			}
-			return next(c)
+// RequestMeta tags every request with an X-Request-ID, reusing the one the caller sent, and
+// keeps it together with the client IP and user agent in the context for the audit log.
+func (h *Handler) RequestMeta(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestID := c.Request().Header.Get(echo.HeaderXRequestID)
+		if requestID == "" {
+			requestID = random.String(32)
 		}
+		c.Response().Header().Set(echo.HeaderXRequestID, requestID)
+
+		ctx := context.WithValue(c.Request().Context(), types.String("requestMeta"), audit.RequestMeta{
+			IP:        c.RealIP(),
+			UserAgent: c.Request().UserAgent(),
+			RequestID: requestID,
+		})
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
 	}
 }
 
@@ -59,14 +55,14 @@ func (h *Handler) Authentication(next echo.HandlerFunc) echo.HandlerFunc {
 		if bearerToken == "" {
 			c.Set("unauthorized", true)
 			err := fmt.Errorf("unauthorized [00]")
-			slog.ErrorContext(ctx, "authentication failed", err)
+			slog.ErrorContext(ctx, "authentication failed", "error", err)
 			return err
 		}
 		sliceToken := strings.Split(bearerToken, "Bearer ")
 		if len(sliceToken) < 2 {
 			c.Set("unauthorized", true)
 			err := fmt.Errorf("unauthorized [01]")
-			slog.ErrorContext(ctx, "authentication failed", err)
+			slog.ErrorContext(ctx, "authentication failed", "error", err)
 			return err
 		}
 		token = sliceToken[1]
@@ -77,26 +73,34 @@ func (h *Handler) Authentication(next echo.HandlerFunc) echo.HandlerFunc {
 			err = fmt.Errorf("unauthorized [02]")
 			return err
 		}
+		if cl.Issuer != utils.JwtIssuerUser {
+			c.Set("unauthorized", true)
+			slog.ErrorContext(ctx, "authentication failed", "unexpected issuer", cl.Issuer)
+			err = fmt.Errorf("unauthorized [02]")
+			return err
+		}
 		claims.ID = cl.ID
 		claims.Username = cl.Username
 
-		userData, err := redis.NewRedisConnection(h.redisClient).Get(ctx, cl.Username)
+		// * the access token only stands while its session does, logging a device out or
+		// * detecting a reused refresh token cuts it off before the token itself expires
+		sessionData, err := h.redisWrapper.Get(ctx, entities.SessionKey(cl.SessionID))
 		if err != nil {
 			c.Set("unauthorized", true)
-			slog.ErrorContext(ctx, "authentication failed", "failed to find user by token", err)
+			slog.ErrorContext(ctx, "authentication failed", "failed to find session by token", err)
 			err = fmt.Errorf("unauthorized [03]")
 			return err
 		}
 
-		var loginRequest entities.Login
-		jsonData, err := json.Marshal(userData)
+		var session entities.Session
+		jsonData, err := json.Marshal(sessionData)
 		if err != nil {
 			c.Set("unauthorized", true)
 			slog.ErrorContext(ctx, "authentication failed", "failed marshal", err)
 			err = fmt.Errorf("unauthorized [04]")
 			return err
 		}
-		err = json.Unmarshal(jsonData, &loginRequest)
+		err = json.Unmarshal(jsonData, &session)
 		if err != nil {
 			c.Set("unauthorized", true)
 			slog.ErrorContext(ctx, "authentication failed", "failed to unmarshal", err)
@@ -104,15 +108,302 @@ func (h *Handler) Authentication(next echo.HandlerFunc) echo.HandlerFunc {
 			return err
 		}
 
-		if token != loginRequest.Token {
+		if session.UserID != cl.ID {
 			c.Set("unauthorized", true)
-			slog.ErrorContext(ctx, "authentication failed", "token not match", err)
+			slog.ErrorContext(ctx, "authentication failed", "session does not belong to token", cl.SessionID)
 			err = fmt.Errorf("unauthorized [06]")
 			return err
 		}
 
+		// * a suspended or closed account loses access at once, even if dropping its session failed
+		loginRequest, err := h.userHandler.userService.GetActiveLogin(ctx, cl.ID)
+		if err != nil {
+			c.Set("unauthorized", true)
+			slog.ErrorContext(ctx, "authentication failed", "account is not active", err)
+			err = fmt.Errorf("unauthorized [07]")
+			return err
+		}
+		loginRequest.SessionID = session.ID
+		loginRequest.Token = token
+		loginRequest.ExpireAt = cl.ExpiresAt
+
 		ctx = context.WithValue(ctx, types.String("user"), loginRequest)
 		c.SetRequest(c.Request().WithContext(ctx))
 		return next(c)
 	}
 }
+
+// AdminAuthentication accepts a back-office bearer token issued by the admin login and still
+// backed by a session in redis, the session carries the role checked by RequirePermission.
+func (h *Handler) AdminAuthentication(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		sliceToken := strings.Split(c.Request().Header.Get("Authorization"), "Bearer ")
+		if len(sliceToken) < 2 || sliceToken[1] == "" {
+			c.Set("unauthorized", true)
+			err := fmt.Errorf("unauthorized [00]")
+			slog.ErrorContext(ctx, "admin authentication failed", "error", err)
+			return err
+		}
+		token := sliceToken[1]
+		cl, err := utils.JwtVerify(token)
+		if err != nil || cl.Issuer != utils.JwtIssuerAdmin {
+			c.Set("unauthorized", true)
+			slog.ErrorContext(ctx, "admin authentication failed", "error", err)
+			err = fmt.Errorf("unauthorized [01]")
+			return err
+		}
+
+		sessionData, err := h.redisWrapper.Get(ctx, entities.AdminSessionKey(cl.Username))
+		if err != nil {
+			c.Set("unauthorized", true)
+			slog.ErrorContext(ctx, "admin authentication failed", "error", err)
+			err = fmt.Errorf("unauthorized [02]")
+			return err
+		}
+
+		var session entities.AdminLogin
+		jsonData, err := json.Marshal(sessionData)
+		if err == nil {
+			err = json.Unmarshal(jsonData, &session)
+		}
+		if err != nil {
+			c.Set("unauthorized", true)
+			slog.ErrorContext(ctx, "admin authentication failed", "error", err)
+			err = fmt.Errorf("unauthorized [03]")
+			return err
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(session.Token)) != 1 {
+			c.Set("unauthorized", true)
+			err = fmt.Errorf("unauthorized [04]")
+			slog.ErrorContext(ctx, "admin authentication failed", "error", err)
+			return err
+		}
+
+		ctx = context.WithValue(ctx, types.String("admin"), session)
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
+	}
+}
+
+// RequirePermission lets the request through only when the admin's role grants the permission.
+// It has to run after AdminAuthentication.
+func (h *Handler) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			session, _ := ctx.Value(types.String("admin")).(entities.AdminLogin)
+			if !entities.RoleHasPermission(session.Role, permission) {
+				c.Set("forbidden", true)
+				err := fmt.Errorf("permission %s is required", permission)
+				slog.ErrorContext(ctx, "authorization failed", "username", session.Username, "role", session.Role, "error", err)
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequireStepUp asks players who turned on two factor authentication for a fresh code in the
+// X-OTP header before letting a sensitive request through.
+func (h *Handler) RequireStepUp(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		userData, _ := ctx.Value(types.String("user")).(entities.Login)
+		if !userData.TOTPEnabled {
+			return next(c)
+		}
+
+		err := h.userHandler.userService.VerifySecondFactor(ctx, c.Request().Header.Get("X-OTP"))
+		if err != nil {
+			c.Set("forbidden", true)
+			slog.ErrorContext(ctx, "step up verification failed", "userId", userData.ID, "error", err)
+			return err
+		}
+		return next(c)
+	}
+}
+
+// CallbackVerification accepts payment provider callbacks signed with the shared
+// HMAC key when one is configured, otherwise carrying the static callback token.
+func (h *Handler) CallbackVerification(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		if signatureKey := config.GetString("xendit.callbackSignatureKey"); signatureKey != "" {
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				c.Set("unauthorized", true)
+				slog.ErrorContext(ctx, "callback verification failed", "error", err)
+				err = fmt.Errorf("failed to read callback body")
+				return err
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			mac := hmac.New(sha256.New, []byte(signatureKey))
+			mac.Write(body)
+			expected := hex.EncodeToString(mac.Sum(nil))
+			if !hmac.Equal([]byte(expected), []byte(c.Request().Header.Get("x-callback-signature"))) {
+				c.Set("forbidden", true)
+				err = fmt.Errorf("invalid callback signature")
+				slog.ErrorContext(ctx, "callback verification failed", "error", err)
+				return err
+			}
+			return next(c)
+		}
+
+		token := c.Request().Header.Get("x-callback-token")
+		callbackToken := config.GetString("xendit.callbackToken")
+		if callbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(callbackToken)) != 1 {
+			c.Set("forbidden", true)
+			err := fmt.Errorf("invalid callback token")
+			slog.ErrorContext(ctx, "callback verification failed", "error", err)
+			return err
+		}
+		return next(c)
+	}
+}
+
+// MidtransVerification accepts Core API notifications whose signature_key is the SHA-512 of the
+// order id, status code, gross amount and the server key.
+func (h *Handler) MidtransVerification(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			c.Set("unauthorized", true)
+			slog.ErrorContext(ctx, "midtrans notification verification failed", "error", err)
+			err = fmt.Errorf("failed to read notification body")
+			return err
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		var notification struct {
+			OrderID      string `json:"order_id"`
+			StatusCode   string `json:"status_code"`
+			GrossAmount  string `json:"gross_amount"`
+			SignatureKey string `json:"signature_key"`
+		}
+		serverKey := config.GetString("midtrans.serverKey")
+		if serverKey == "" || json.Unmarshal(body, &notification) != nil {
+			c.Set("forbidden", true)
+			err = fmt.Errorf("invalid notification signature")
+			slog.ErrorContext(ctx, "midtrans notification verification failed", "error", err)
+			return err
+		}
+
+		sum := sha512.Sum512([]byte(notification.OrderID + notification.StatusCode + notification.GrossAmount + serverKey))
+		expected := hex.EncodeToString(sum[:])
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(notification.SignatureKey)) != 1 {
+			c.Set("forbidden", true)
+			err = fmt.Errorf("invalid notification signature")
+			slog.ErrorContext(ctx, "midtrans notification verification failed", "error", err)
+			return err
+		}
+		return next(c)
+	}
+}
+
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+type idempotencyWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Idempotency makes a request carrying an Idempotency-Key header safe to retry. The first
+// request with a key runs and its response is stored, a retry with the same body gets that
+// response replayed and a reuse of the key with another body is refused with 409. A request
+// that fails before the usecase marked a side effect keeps no record and may be retried with
+// the same key, once it did the response is stored even when it is an error.
+// Requests without the header run as usual. It has to run after Authentication.
+func (h *Handler) Idempotency(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+		idempotencyKey := c.Request().Header.Get("Idempotency-Key")
+		if idempotencyKey == "" {
+			return next(c)
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			slog.ErrorContext(ctx, "idempotency check failed", "error", err)
+			return fmt.Errorf("failed to read request body")
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := sha256.Sum256([]byte(c.Request().Method + " " + c.Path() + "\n" + string(body)))
+		userData, _ := ctx.Value(types.String("user")).(entities.Login)
+		key := fmt.Sprintf("idempotency:%d:%s", userData.ID, idempotencyKey)
+		record := idempotencyRecord{Fingerprint: hex.EncodeToString(fingerprint[:])}
+
+		ttl := config.GetDuration("idempotency.ttl")
+		isNew, err := h.redisWrapper.SetNX(ctx, key, ttl, record)
+		if err != nil {
+			slog.ErrorContext(ctx, "idempotency check failed", "key", key, "error", err)
+			return fmt.Errorf("failed to check idempotency key")
+		}
+
+		if !isNew {
+			stored, err := h.redisWrapper.Get(ctx, key)
+			if err != nil {
+				slog.ErrorContext(ctx, "idempotency check failed", "key", key, "error", err)
+				return fmt.Errorf("failed to check idempotency key")
+			}
+			var previous idempotencyRecord
+			jsonData, _ := json.Marshal(stored)
+			if err = json.Unmarshal(jsonData, &previous); err != nil {
+				slog.ErrorContext(ctx, "idempotency check failed", "key", key, "error", err)
+				return fmt.Errorf("failed to check idempotency key")
+			}
+
+			if previous.Fingerprint != record.Fingerprint {
+				return echo.NewHTTPError(http.StatusConflict, "idempotency key was already used for a different request")
+			}
+			if !previous.Completed {
+				return echo.NewHTTPError(http.StatusConflict, "a request with this idempotency key is still in progress")
+			}
+			c.Response().Header().Set("Idempotent-Replayed", "true")
+			return c.Blob(previous.StatusCode, previous.ContentType, previous.Body)
+		}
+
+		ctx = utils.TrackSideEffect(ctx)
+		c.SetRequest(c.Request().WithContext(ctx))
+		writer := &idempotencyWriter{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = writer
+		err = next(c)
+		if !utils.HadSideEffect(ctx) && (err != nil || c.Response().Status >= http.StatusInternalServerError) {
+			if errDelete := h.redisWrapper.Delete(ctx, key); errDelete != nil {
+				slog.ErrorContext(ctx, "failed to delete idempotency key", "key", key, "error", errDelete)
+			}
+			return err
+		}
+		// * the error is rendered here so the retry replays it instead of running the request again
+		if err != nil {
+			c.Error(err)
+		}
+
+		record.Completed = true
+		record.StatusCode = c.Response().Status
+		record.ContentType = c.Response().Header().Get(echo.HeaderContentType)
+		record.Body = writer.body.Bytes()
+		if errSet := h.redisWrapper.Set(ctx, key, ttl, record); errSet != nil {
+			slog.ErrorContext(ctx, "failed to store idempotent response", "key", key, "error", errSet)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedisWrapper keeps values in memory, marshalled the way the redis wrapper stores them.
+type fakeRedisWrapper struct {
+	redis.Wrapper
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newFakeRedisWrapper() *fakeRedisWrapper {
+	return &fakeRedisWrapper{values: make(map[string][]byte)}
+}
+
+func (r *fakeRedisWrapper) Set(ctx context.Context, key string, expirationTime time.Duration, req interface{}) error {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = raw
+	return nil
+}
+
+func (r *fakeRedisWrapper) SetNX(ctx context.Context, key string, expirationTime time.Duration, req interface{}) (bool, error) {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.values[key]; ok {
+		return false, nil
+	}
+	r.values[key] = raw
+	return true, nil
+}
+
+func (r *fakeRedisWrapper) Get(ctx context.Context, key string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	raw, ok := r.values[key]
+	if !ok {
+		return nil, errors.New("redis: nil")
+	}
+	var resp interface{}
+	err := json.Unmarshal(raw, &resp)
+	return resp, err
+}
+
+func (r *fakeRedisWrapper) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.values, key)
+	return nil
+}
+
+func newIdempotencyServer(handler echo.HandlerFunc) (*echo.Echo, *fakeRedisWrapper) {
+	redisWrapper := newFakeRedisWrapper()
+	h := &Handler{redisWrapper: redisWrapper}
+	e := echo.New()
+	e.POST("/withdraw", handler, h.Idempotency)
+	return e, redisWrapper
+}
+
+func serveIdempotent(e *echo.Echo, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/withdraw", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Idempotency-Key", key)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	calls := 0
+	e, _ := newIdempotencyServer(func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusOK, map[string]int{"call": calls})
+	})
+
+	first := serveIdempotent(e, "key-1", `{"amount":30000}`)
+	require.Equal(t, http.StatusOK, first.Code)
+
+	retry := serveIdempotent(e, "key-1", `{"amount":30000}`)
+	require.Equal(t, http.StatusOK, retry.Code)
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.JSONEq(t, first.Body.String(), retry.Body.String())
+	require.Equal(t, 1, calls)
+}
+
+func TestIdempotency_FingerprintMismatch(t *testing.T) {
+	calls := 0
+	e, _ := newIdempotencyServer(func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusOK, map[string]int{"call": calls})
+	})
+
+	serveIdempotent(e, "key-1", `{"amount":30000}`)
+	rec := serveIdempotent(e, "key-1", `{"amount":90000}`)
+	require.Equal(t, http.StatusConflict, rec.Code)
+	require.Contains(t, rec.Body.String(), "different request")
+	require.Equal(t, 1, calls)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	var e *echo.Echo
+	var inner *httptest.ResponseRecorder
+	e, _ = newIdempotencyServer(func(c echo.Context) error {
+		// * the retry arrives while the first request is still running
+		inner = serveIdempotent(e, "key-1", `{"amount":30000}`)
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	rec := serveIdempotent(e, "key-1", `{"amount":30000}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, http.StatusConflict, inner.Code)
+	require.Contains(t, inner.Body.String(), "still in progress")
+}
+
+func TestIdempotency_ErrorBeforeSideEffect(t *testing.T) {
+	calls := 0
+	e, redisWrapper := newIdempotencyServer(func(c echo.Context) error {
+		calls++
+		return echo.NewHTTPError(http.StatusOK, "insufficient funds")
+	})
+
+	serveIdempotent(e, "key-1", `{"amount":30000}`)
+	require.Empty(t, redisWrapper.values)
+
+	// * nothing was written, the retry runs again
+	serveIdempotent(e, "key-1", `{"amount":30000}`)
+	require.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ErrorAfterSideEffect(t *testing.T) {
+	calls := 0
+	e, _ := newIdempotencyServer(func(c echo.Context) error {
+		calls++
+		utils.MarkSideEffect(c.Request().Context())
+		return errors.New("failed to settle withdrawal")
+	})
+
+	first := serveIdempotent(e, "key-1", `{"amount":30000}`)
+	require.Equal(t, http.StatusInternalServerError, first.Code)
+
+	// * the payout may have gone out, the retry gets the stored error instead of paying again
+	retry := serveIdempotent(e, "key-1", `{"amount":30000}`)
+	require.Equal(t, http.StatusInternalServerError, retry.Code)
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, first.Body.String(), retry.Body.String())
+	require.Equal(t, 1, calls)
+}
+
+func TestMidtransVerification(t *testing.T) {
+	config.Set("midtrans.serverKey", "SB-Mid-server-key")
+	t.Cleanup(func() { config.Set("midtrans.serverKey", "") })
+
+	h := &Handler{}
+	e := echo.New()
+	e.POST("/callback/midtrans", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	}, h.MidtransVerification)
+
+	sum := sha512.Sum512([]byte("TF-ABCDE12345" + "200" + "50000.00" + "SB-Mid-server-key"))
+	tests := []struct {
+		name      string
+		signature string
+		wantPass  bool
+	}{
+		{name: "signed with the server key", signature: hex.EncodeToString(sum[:]), wantPass: true},
+		{name: "signed with another key", signature: strings.Repeat("0", 128), wantPass: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"order_id":"TF-ABCDE12345","status_code":"200","gross_amount":"50000.00","signature_key":"` + tt.signature + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/callback/midtrans", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			require.Equal(t, tt.wantPass, rec.Code == http.StatusOK)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/container"
 	"github.com/labstack/echo/v4"
 )
@@ -8,19 +9,91 @@ import (
 func SetupRouter(e *echo.Echo, cnt *container.Container) {
 	h := SetupHandler(cnt).Validate()
 
+	e.Use(h.RequestMeta)
 	e.GET("/", h.healthCheckHandler.HealthCheck)
 
 	v1 := e.Group("/v1")
 	{
 		// ======== ADMIN ========
-		admin := v1.Group("/admin", h.BasicAuth("admin"))
+		admin := v1.Group("/admin")
 		{
+			onboard := admin.Group("/onboard")
+			{
+				onboard.POST("/login", h.adminHandler.Login)
+			}
+		}
+
+		admin.Use(h.AdminAuthentication)
+		{
+			profile := admin.Group("/profile")
+			{
+				profile.GET("", h.adminHandler.GetProfile)
+				profile.POST("/logout", h.adminHandler.Logout)
+			}
+			account := admin.Group("/account", h.RequirePermission(entities.PermissionAdminManage))
+			{
+				account.GET("", h.adminHandler.GetAllAdmin)
+				account.POST("", h.adminHandler.CreateAdmin)
+				account.PUT("/:id", h.adminHandler.UpdateAdmin)
+			}
+			auditLog := admin.Group("/audit", h.RequirePermission(entities.PermissionAuditRead))
+			{
+				auditLog.GET("", h.auditHandler.Search)
+				auditLog.GET("/verify", h.auditHandler.Verify)
+			}
 			user := admin.Group("/user")
 			{
-				user.GET("", h.userHandler.GetAll)
-				user.GET("/:userID", h.userHandler.GetDetail)
+				user.GET("", h.userHandler.GetAll, h.RequirePermission(entities.PermissionUserRead))
+				user.GET("/:userID", h.userHandler.GetDetail, h.RequirePermission(entities.PermissionUserRead))
+				user.GET("/:userID/ledger", h.ledgerHandler.Reconcile, h.RequirePermission(entities.PermissionLedgerRead))
+				user.GET("/:userID/transaction", h.adminHandler.GetByUserID, h.RequirePermission(entities.PermissionTransactionRead))
+				user.GET("/:userID/status-log", h.userHandler.GetStatusLog, h.RequirePermission(entities.PermissionUserRead))
+				user.POST("/:userID/freeze", h.userHandler.Freeze, h.RequirePermission(entities.PermissionUserManage))
+				user.POST("/:userID/suspend", h.userHandler.Suspend, h.RequirePermission(entities.PermissionUserManage))
+				user.POST("/:userID/close", h.userHandler.Close, h.RequirePermission(entities.PermissionUserManage))
+				user.POST("/:userID/activate", h.userHandler.Activate, h.RequirePermission(entities.PermissionUserManage))
+				user.POST("/:userID/unlock", h.userHandler.Unlock, h.RequirePermission(entities.PermissionUserManage))
+			}
+			transaction := admin.Group("/transaction", h.RequirePermission(entities.PermissionTransactionRead))
+			{
+				transaction.GET("", h.adminHandler.GetAll)
+				transaction.GET("/:reference", h.adminHandler.GetByReference)
+				transaction.POST("/:reference/settle", h.transactionHandler.SettleManual, h.RequirePermission(entities.PermissionTransactionSettle))
+			}
+			adjustment := admin.Group("/adjustment")
+			{
+				adjustment.GET("", h.adminHandler.GetAllAdjustment, h.RequirePermission(entities.PermissionAdjustmentRead))
+				adjustment.POST("", h.adminHandler.ProposeAdjustment, h.RequirePermission(entities.PermissionAdjustmentPropose))
+				adjustment.POST("/:id/approve", h.adminHandler.ApproveAdjustment, h.RequirePermission(entities.PermissionAdjustmentReview))
+				adjustment.POST("/:id/reject", h.adminHandler.RejectAdjustment, h.RequirePermission(entities.PermissionAdjustmentReview))
+			}
+			kyc := admin.Group("/kyc")
+			{
+				kyc.GET("", h.kycHandler.GetAll, h.RequirePermission(entities.PermissionKYCRead))
+				kyc.GET("/:id", h.kycHandler.GetDetail, h.RequirePermission(entities.PermissionKYCRead))
+				kyc.GET("/:id/document", h.kycHandler.GetDocument, h.RequirePermission(entities.PermissionKYCRead))
+				kyc.POST("/:id/approve", h.kycHandler.Approve, h.RequirePermission(entities.PermissionKYCReview))
+				kyc.POST("/:id/reject", h.kycHandler.Reject, h.RequirePermission(entities.PermissionKYCReview))
+			}
+			fee := admin.Group("/fee")
+			{
+				fee.GET("", h.feeHandler.GetAll, h.RequirePermission(entities.PermissionFeeRead))
+				fee.POST("", h.feeHandler.Create, h.RequirePermission(entities.PermissionFeeManage))
+				fee.POST("/:id/deactivate", h.feeHandler.Deactivate, h.RequirePermission(entities.PermissionFeeManage))
+			}
+		}
+
+		// ======== CALLBACK ========
+		callback := v1.Group("/callback", h.CallbackVerification)
+		{
+			payment := callback.Group("/payment")
+			{
+				payment.POST("/va", h.transactionHandler.CallbackVA)
+				payment.POST("/disbursement", h.transactionHandler.CallbackDisbursement)
 			}
 		}
+		// * midtrans signs each notification with the server key instead of a callback token
+		v1.POST("/callback/midtrans", h.transactionHandler.CallbackMidtrans, h.MidtransVerification)
 
 		// ======== PLAYER ========
 		player := v1.Group("/player")
@@ -29,6 +102,11 @@ func SetupRouter(e *echo.Echo, cnt *container.Container) {
 			{
 				onboard.POST("/register", h.userHandler.Register)
 				onboard.POST("/login", h.userHandler.Login)
+				onboard.POST("/login/2fa", h.userHandler.LoginTwoFactor)
+				onboard.POST("/refresh", h.userHandler.Refresh)
+				onboard.POST("/verify-email", h.userHandler.VerifyEmail)
+				onboard.POST("/forgot-password", h.userHandler.ForgotPassword)
+				onboard.POST("/reset-password", h.userHandler.ResetPassword)
 			}
 		}
 
@@ -38,16 +116,42 @@ func SetupRouter(e *echo.Echo, cnt *container.Container) {
 			{
 				user.GET("", h.userHandler.GetDetailPlayer)
 				user.POST("/logout", h.userHandler.Logout)
+				user.GET("/session", h.userHandler.GetSessions)
+				user.POST("/logout-others", h.userHandler.LogoutOthers)
+				user.POST("/change-password", h.userHandler.ChangePassword)
+				user.POST("/resend-verification", h.userHandler.ResendEmailVerification)
+				user.POST("/2fa/setup", h.userHandler.SetupTwoFactor)
+				user.POST("/2fa/enable", h.userHandler.EnableTwoFactor)
+				user.POST("/2fa/disable", h.userHandler.DisableTwoFactor)
+				user.POST("/2fa/recovery-codes", h.userHandler.RegenerateRecoveryCodes)
 			}
 			bank := player.Group("/bank")
 			{
 				bank.GET("", h.bankHandler.FindAll)
-				bank.POST("/submit-bank", h.bankHandler.SubmitBank)
+				bank.GET("/catalogue", h.bankHandler.GetBankCodes)
+				bank.POST("/submit-bank", h.bankHandler.SubmitBank, h.RequireStepUp)
+				bank.PUT("/:id", h.bankHandler.UpdateBank, h.RequireStepUp)
+				bank.DELETE("/:id", h.bankHandler.DeleteBank, h.RequireStepUp)
+				bank.POST("/:id/primary", h.bankHandler.SetPrimary)
+				bank.POST("/:id/verify", h.bankHandler.VerifyBank)
+			}
+			kyc := player.Group("/kyc")
+			{
+				kyc.GET("", h.kycHandler.GetStatus)
+				kyc.POST("", h.kycHandler.Submit)
+			}
+			limit := player.Group("/limit")
+			{
+				limit.GET("", h.limitHandler.GetRemaining)
 			}
 			transaction := player.Group("/transaction")
 			{
-				transaction.POST("/generate", h.transactionHandler.Generate)
-				transaction.POST("/withdraw", h.transactionHandler.Withdraw)
+				transaction.GET("", h.transactionHandler.FindAll)
+				transaction.GET("/:reference", h.transactionHandler.FindByReference)
+				transaction.POST("/quote", h.transactionHandler.Quote)
+				transaction.POST("/generate", h.transactionHandler.Generate, h.Idempotency)
+				// * idempotency goes first so a retry replays the stored response instead of asking for another code
+				transaction.POST("/withdraw", h.transactionHandler.Withdraw, h.Idempotency, h.RequireStepUp)
 			}
 		}
 	}
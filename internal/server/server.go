@@ -2,8 +2,11 @@ package server
 
 import (
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 
+	"github.com/danielpnjt/speed-engine/internal/config"
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/container"
 	"github.com/danielpnjt/speed-engine/internal/server/handler"
 
@@ -28,9 +31,11 @@ func StartService(container *container.Container) {
 
 	e.Validator = &DataValidator{ValidatorData: validator.New()}
 	e.HTTPErrorHandler = e.DefaultHTTPErrorHandler
+	e.IPExtractor = ipExtractor(config.GetString("server.trustedProxies"))
 
 	handler.SetupRouter(e, container)
 	go container.QueueWorker.Run()
+	go container.AuditService.Run()
 
 	e.Server.Addr = fmt.Sprintf("%s:%s", container.Config.Apps.Address, container.Config.Apps.HttpPort)
 
@@ -38,4 +43,32 @@ func StartService(container *container.Container) {
 
 	// * HTTP/2 Cleartext Server (HTTP2 over HTTP)
 	gracehttp.Serve(&http.Server{Addr: e.Server.Addr, Handler: h2c.NewHandler(e, &http2.Server{MaxConcurrentStreams: 500, MaxReadFrameSize: 1048576})})
+
+	// * in-flight requests are done, write the audit records they queued before exiting
+	container.AuditService.Close()
+}
+
+// ipExtractor reads the client IP from X-Forwarded-For only when the request came through one of
+// the comma separated trusted proxy ranges. Without any the connection's address is used, a
+// client cannot pick the IP that login lockouts and the audit log see.
+func ipExtractor(trustedProxies string) echo.IPExtractor {
+	options := make([]echo.TrustOption, 0)
+	for _, cidr := range strings.Split(trustedProxies, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("invalid trusted proxy range %q", cidr))
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	if len(options) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	// * echo trusts loopback and private ranges by default, only the listed ones are meant
+	options = append(options, echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false))
+	return echo.ExtractIPFromXFFHeader(options...)
 }
@@ -1,11 +1,74 @@
 package admin
 
 import (
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 )
 
 // * Requests
-type ()
+type (
+	FindAllTransactionRequest struct {
+		constants.PaginationRequest
+		UserID    int    `query:"userId" validate:"omitempty,gte=1"`
+		Type      string `query:"type" validate:"omitempty,oneof=in out adjustment_in adjustment_out"`
+		Status    string `query:"status" validate:"omitempty,oneof=PENDING COMPLETED EXPIRED FAILED REVERSED"`
+		Provider  string `query:"provider"`
+		StartDate string `query:"startDate" validate:"omitempty,datetime=2006-01-02"`
+		EndDate   string `query:"endDate" validate:"omitempty,datetime=2006-01-02"`
+	}
+
+	FindTransactionByReferenceRequest struct {
+		Reference string `param:"reference" validate:"required"`
+	}
+
+	FindTransactionByUserIDRequest struct {
+		constants.PaginationRequest
+		UserID int `param:"userID" validate:"required"`
+	}
+
+	ProposeAdjustmentRequest struct {
+		UserID    int     `json:"userId" validate:"required"`
+		Direction string  `json:"direction" validate:"required,oneof=CREDIT DEBIT"`
+		Amount    float64 `json:"amount" validate:"required,gt=0"`
+		Reason    string  `json:"reason" validate:"required"`
+	}
+
+	ReviewAdjustmentRequest struct {
+		ID   int    `param:"id" validate:"required"`
+		Note string `json:"note"`
+	}
+
+	FindAllAdjustmentRequest struct {
+		constants.PaginationRequest
+		UserID int    `query:"userId" validate:"omitempty,gte=1"`
+		Status string `query:"status" validate:"omitempty,oneof=PENDING APPROVED REJECTED"`
+	}
+
+	LoginRequest struct {
+		Username string `json:"username" validate:"required"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	FindAllAdminRequest struct {
+		constants.PaginationRequest
+		Role string `query:"role" validate:"omitempty,oneof=viewer support finance superadmin"`
+	}
+
+	CreateAdminRequest struct {
+		Username string `json:"username" validate:"required"`
+		Name     string `json:"name" validate:"required"`
+		Password string `json:"password" validate:"required"`
+		Role     string `json:"role" validate:"required,oneof=viewer support finance superadmin"`
+	}
+
+	UpdateAdminRequest struct {
+		ID       int    `param:"id" validate:"required"`
+		Name     string `json:"name"`
+		Role     string `json:"role" validate:"omitempty,oneof=viewer support finance superadmin"`
+		Active   *bool  `json:"active"`
+		Password string `json:"password"`
+	}
+)
 
 // * Responses
 type (
@@ -23,4 +86,15 @@ type (
 	FindAllResponse struct {
 		constants.DefaultResponse
 	}
+
+	LoginResponseData struct {
+		Token    string `json:"token"`
+		ExpireAt int64  `json:"expireAt"`
+		Role     string `json:"role"`
+	}
+
+	TransactionDetailResponseData struct {
+		entities.Transaction
+		StatusHistory []entities.TransactionStatusHistory `json:"statusHistory"`
+	}
 )
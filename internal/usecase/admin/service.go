@@ -1,4 +1,24 @@
 package admin
 
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
 type Service interface {
+	Bootstrap(ctx context.Context, username string, password string) (err error)
+	Login(ctx context.Context, req LoginRequest) (res constants.DefaultResponse, err error)
+	Logout(ctx context.Context) (res constants.DefaultResponse, err error)
+	GetProfile(ctx context.Context) (res constants.DefaultResponse, err error)
+	GetAllAdmin(ctx context.Context, req FindAllAdminRequest) (res constants.DefaultResponse, err error)
+	CreateAdmin(ctx context.Context, req CreateAdminRequest) (res constants.DefaultResponse, err error)
+	UpdateAdmin(ctx context.Context, req UpdateAdminRequest) (res constants.DefaultResponse, err error)
+	GetAllTransaction(ctx context.Context, req FindAllTransactionRequest) (res constants.DefaultResponse, err error)
+	GetTransactionByReference(ctx context.Context, req FindTransactionByReferenceRequest) (res constants.DefaultResponse, err error)
+	GetTransactionByUserID(ctx context.Context, req FindTransactionByUserIDRequest) (res constants.DefaultResponse, err error)
+	GetAllAdjustment(ctx context.Context, req FindAllAdjustmentRequest) (res constants.DefaultResponse, err error)
+	ProposeAdjustment(ctx context.Context, req ProposeAdjustmentRequest) (res constants.DefaultResponse, err error)
+	ApproveAdjustment(ctx context.Context, req ReviewAdjustmentRequest) (res constants.DefaultResponse, err error)
+	RejectAdjustment(ctx context.Context, req ReviewAdjustmentRequest) (res constants.DefaultResponse, err error)
 }
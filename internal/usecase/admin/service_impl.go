@@ -1,15 +1,35 @@
 package admin
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
+	"github.com/danielpnjt/speed-engine/internal/usecase/ledger"
 	"gorm.io/gorm"
 )
 
 type service struct {
-	db                    *gorm.DB
-	transactionRepository repositories.Transaction
-	redisWrapper          redis.Wrapper
+	db                          *gorm.DB
+	unitOfWork                  repositories.UnitOfWork
+	adminRepository             repositories.Admin
+	transactionRepository       repositories.Transaction
+	userRepository              repositories.User
+	balanceAdjustmentRepository repositories.BalanceAdjustment
+	redisWrapper                redis.Wrapper
+	ledgerService               ledger.Service
+	auditService                audit.Service
 }
 
 func NewService() *service {
@@ -21,25 +41,676 @@ func (s *service) SetDB(db *gorm.DB) *service {
 	return s
 }
 
+func (s *service) SetUnitOfWork(unitOfWork repositories.UnitOfWork) *service {
+	s.unitOfWork = unitOfWork
+	return s
+}
+
+func (s *service) SetAdminRepository(repository repositories.Admin) *service {
+	s.adminRepository = repository
+	return s
+}
+
 func (s *service) SetTransactionRepository(repository repositories.Transaction) *service {
 	s.transactionRepository = repository
 	return s
 }
 
+func (s *service) SetUserRepository(repository repositories.User) *service {
+	s.userRepository = repository
+	return s
+}
+
+func (s *service) SetBalanceAdjustmentRepository(repository repositories.BalanceAdjustment) *service {
+	s.balanceAdjustmentRepository = repository
+	return s
+}
+
+func (s *service) SetLedgerService(service ledger.Service) *service {
+	s.ledgerService = service
+	return s
+}
+
 func (s *service) SetRedisWrapper(wrapper redis.Wrapper) *service {
 	s.redisWrapper = wrapper
 	return s
 }
 
+func (s *service) SetAuditService(service audit.Service) *service {
+	s.auditService = service
+	return s
+}
+
 func (s *service) Validate() Service {
 	if s.db == nil {
 		panic("db is nil")
 	}
+	if s.unitOfWork == nil {
+		panic("unitOfWork is nil")
+	}
+	if s.adminRepository == nil {
+		panic("adminRepository is nil")
+	}
 	if s.transactionRepository == nil {
 		panic("transactionRepository is nil")
 	}
+	if s.userRepository == nil {
+		panic("userRepository is nil")
+	}
+	if s.balanceAdjustmentRepository == nil {
+		panic("balanceAdjustmentRepository is nil")
+	}
 	if s.redisWrapper == nil {
 		panic("redisWrapper is nil")
 	}
+	if s.ledgerService == nil {
+		panic("ledgerService is nil")
+	}
+	if s.auditService == nil {
+		panic("auditService is nil")
+	}
 	return s
 }
+
+func (s *service) GetAllTransaction(ctx context.Context, req FindAllTransactionRequest) (res constants.DefaultResponse, err error) {
+	conds := make([]utils.DBCond, 0)
+	if req.UserID > 0 {
+		conds = append(conds, utils.DBCond{Where: "user_id = ?", WhereArgs: req.UserID})
+	}
+	if req.Type != "" {
+		conds = append(conds, utils.DBCond{Where: "type = ?", WhereArgs: req.Type})
+	}
+	if req.Status != "" {
+		conds = append(conds, utils.DBCond{Where: "status = ?", WhereArgs: req.Status})
+	}
+	if req.Provider != "" {
+		conds = append(conds, utils.DBCond{Where: "provider = ?", WhereArgs: req.Provider})
+	}
+	if req.StartDate != "" {
+		startDate, _ := time.ParseInLocation(time.DateOnly, req.StartDate, time.Local)
+		conds = append(conds, utils.DBCond{Where: "created_at >= ?", WhereArgs: startDate})
+	}
+	if req.EndDate != "" {
+		// * the end date is inclusive, the range runs up to the start of the next day
+		endDate, _ := time.ParseInLocation(time.DateOnly, req.EndDate, time.Local)
+		conds = append(conds, utils.DBCond{Where: "created_at < ?", WhereArgs: endDate.AddDate(0, 0, 1)})
+	}
+
+	return s.findAllTransaction(ctx, req.PaginationRequest, conds...)
+}
+
+func (s *service) GetTransactionByUserID(ctx context.Context, req FindTransactionByUserIDRequest) (res constants.DefaultResponse, err error) {
+	return s.findAllTransaction(ctx, req.PaginationRequest, utils.DBCond{Where: "user_id = ?", WhereArgs: req.UserID})
+}
+
+func (s *service) findAllTransaction(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) (res constants.DefaultResponse, err error) {
+	transactions, count, err := s.transactionRepository.FindAllAndCount(ctx, pagination, conds...)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find transactions", "error", err)
+		err = fmt.Errorf("failed to find transactions")
+		return
+	}
+
+	totalPages := uint(math.Ceil(float64(count) / float64(pagination.Limit)))
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: constants.PaginationResponseData{
+			Results: transactions,
+			PaginationData: constants.PaginationData{
+				Page:        pagination.Page,
+				Limit:       pagination.Limit,
+				TotalPages:  totalPages,
+				TotalItems:  uint(count),
+				HasNext:     pagination.Page < totalPages,
+				HasPrevious: pagination.Page > 1,
+			},
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+func (s *service) GetTransactionByReference(ctx context.Context, req FindTransactionByReferenceRequest) (res constants.DefaultResponse, err error) {
+	transaction, err := s.transactionRepository.FindByReference(ctx, req.Reference)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find transaction by reference", "reference", req.Reference, "error", err)
+		err = fmt.Errorf("transaction not found")
+		return
+	}
+
+	history, err := s.transactionRepository.FindStatusHistory(ctx, transaction.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find transaction status history", "reference", req.Reference, "error", err)
+		err = fmt.Errorf("failed to find transaction status history")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: TransactionDetailResponseData{
+			Transaction:   transaction,
+			StatusHistory: history,
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+func (s *service) GetAllAdjustment(ctx context.Context, req FindAllAdjustmentRequest) (res constants.DefaultResponse, err error) {
+	conds := make([]utils.DBCond, 0)
+	if req.UserID > 0 {
+		conds = append(conds, utils.DBCond{Where: "user_id = ?", WhereArgs: req.UserID})
+	}
+	if req.Status != "" {
+		conds = append(conds, utils.DBCond{Where: "status = ?", WhereArgs: req.Status})
+	}
+
+	adjustments, count, err := s.balanceAdjustmentRepository.FindAllAndCount(ctx, req.PaginationRequest, conds...)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find balance adjustments", "error", err)
+		err = fmt.Errorf("failed to find balance adjustments")
+		return
+	}
+
+	totalPages := uint(math.Ceil(float64(count) / float64(req.Limit)))
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: constants.PaginationResponseData{
+			Results: adjustments,
+			PaginationData: constants.PaginationData{
+				Page:        req.Page,
+				Limit:       req.Limit,
+				TotalPages:  totalPages,
+				TotalItems:  uint(count),
+				HasNext:     req.Page < totalPages,
+				HasPrevious: req.Page > 1,
+			},
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+// ProposeAdjustment records a manual credit or debit, nothing is posted until another admin approves it.
+func (s *service) ProposeAdjustment(ctx context.Context, req ProposeAdjustmentRequest) (res constants.DefaultResponse, err error) {
+	_, err = s.userRepository.FindByID(ctx, req.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user by id", "userId", req.UserID, "error", err)
+		err = fmt.Errorf("failed to find user by id")
+		return
+	}
+
+	adjustment := entities.BalanceAdjustment{
+		UserID:     req.UserID,
+		Direction:  req.Direction,
+		Amount:     req.Amount,
+		Reason:     req.Reason,
+		Status:     entities.BalanceAdjustmentStatusPending,
+		ProposedBy: adminUsername(ctx),
+	}
+	err = s.balanceAdjustmentRepository.Create(ctx, &adjustment)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create balance adjustment", "error", err)
+		err = fmt.Errorf("failed to create balance adjustment")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionProposeAdjustment,
+		TargetType: "balance_adjustment",
+		TargetID:   strconv.Itoa(adjustment.ID),
+		After:      adjustment,
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    adjustment,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// ApproveAdjustment posts a pending adjustment to the player's balance as a completed
+// transaction, so it shows up in their history and in the ledger.
+func (s *service) ApproveAdjustment(ctx context.Context, req ReviewAdjustmentRequest) (res constants.DefaultResponse, err error) {
+	reviewer := adminUsername(ctx)
+
+	var adjustment entities.BalanceAdjustment
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		adjustment, err = s.reviewAdjustment(ctx, req, reviewer, entities.BalanceAdjustmentStatusApproved)
+		if err != nil {
+			return
+		}
+
+		user, err := s.userRepository.FindByIDForUpdate(ctx, adjustment.UserID)
+		if err != nil {
+			return
+		}
+		// * the adjustment stays pending and can still be approved once the account is active again
+		if !user.CanMoveMoney() {
+			return constants.ErrAccountInactive
+		}
+
+		transaction := entities.Transaction{
+			UserID: adjustment.UserID,
+			Amount: adjustment.Amount,
+			Type:   entities.TransactionTypeAdjustmentIn,
+			Status: entities.TransactionStatusCompleted,
+		}
+		if adjustment.Direction == entities.BalanceAdjustmentDebit {
+			if user.AvailableBalance() < adjustment.Amount {
+				return constants.ErrInsufficientFunds
+			}
+			transaction.Type = entities.TransactionTypeAdjustmentOut
+			user.Balance -= adjustment.Amount
+		} else {
+			user.Balance += adjustment.Amount
+		}
+
+		transaction.Reference, err = utils.GeneratePaymentRef()
+		if err != nil {
+			return
+		}
+		err = s.transactionRepository.Create(ctx, &transaction)
+		if err != nil {
+			return
+		}
+
+		err = s.ledgerService.RecordAdjustment(ctx, transaction)
+		if err != nil {
+			return
+		}
+
+		err = s.userRepository.UpdateBalance(ctx, &user)
+		if err != nil {
+			return
+		}
+
+		adjustment.TransactionID = &transaction.ID
+		return s.balanceAdjustmentRepository.Update(ctx, &adjustment)
+	})
+	if err != nil {
+		err = s.reviewError(ctx, req.ID, err)
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionReviewAdjustment,
+		TargetType: "balance_adjustment",
+		TargetID:   strconv.Itoa(adjustment.ID),
+		Before:     map[string]interface{}{"status": entities.BalanceAdjustmentStatusPending},
+		After:      adjustment,
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    adjustment,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) RejectAdjustment(ctx context.Context, req ReviewAdjustmentRequest) (res constants.DefaultResponse, err error) {
+	reviewer := adminUsername(ctx)
+
+	var adjustment entities.BalanceAdjustment
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		adjustment, err = s.reviewAdjustment(ctx, req, reviewer, entities.BalanceAdjustmentStatusRejected)
+		if err != nil {
+			return
+		}
+		return s.balanceAdjustmentRepository.Update(ctx, &adjustment)
+	})
+	if err != nil {
+		err = s.reviewError(ctx, req.ID, err)
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionReviewAdjustment,
+		TargetType: "balance_adjustment",
+		TargetID:   strconv.Itoa(adjustment.ID),
+		Before:     map[string]interface{}{"status": entities.BalanceAdjustmentStatusPending},
+		After:      adjustment,
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    adjustment,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// reviewAdjustment locks a pending adjustment and stamps the review on it. The admin who
+// proposed an adjustment can never be the one reviewing it.
+func (s *service) reviewAdjustment(ctx context.Context, req ReviewAdjustmentRequest, reviewer string, status string) (adjustment entities.BalanceAdjustment, err error) {
+	adjustment, err = s.balanceAdjustmentRepository.FindByIDForUpdate(ctx, req.ID)
+	if err != nil {
+		return
+	}
+	if adjustment.Status != entities.BalanceAdjustmentStatusPending {
+		err = constants.ErrNotPending
+		return
+	}
+	if reviewer == "" || reviewer == adjustment.ProposedBy {
+		err = constants.ErrSelfReview
+		return
+	}
+
+	now := time.Now()
+	adjustment.Status = status
+	adjustment.ReviewedBy = reviewer
+	adjustment.ReviewNote = req.Note
+	adjustment.ReviewedAt = &now
+	return
+}
+
+func (s *service) reviewError(ctx context.Context, id int, err error) error {
+	slog.ErrorContext(ctx, "failed to review balance adjustment", "id", id, "error", err)
+	for _, known := range []error{constants.ErrNotPending, constants.ErrSelfReview, constants.ErrInsufficientFunds, constants.ErrAccountInactive} {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("balance adjustment not found")
+	}
+	return fmt.Errorf("failed to review balance adjustment")
+}
+
+// Bootstrap creates the first superadmin from config so a fresh database has someone to
+// log in with. An account that already exists under the username is left untouched. Without
+// a username nothing is bootstrapped, with one the password has to pass the password rules.
+func (s *service) Bootstrap(ctx context.Context, username string, password string) (err error) {
+	if username == "" {
+		return
+	}
+	if !utils.IsValidPassword(password) {
+		err = fmt.Errorf("bootstrap password for %s is empty or does not pass the password rules", username)
+		return
+	}
+
+	_, err = s.adminRepository.FindByUsername(ctx, username)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return
+	}
+	err = s.adminRepository.Create(ctx, &entities.Admin{
+		Username: username,
+		Name:     username,
+		Password: hashedPassword,
+		Role:     entities.AdminRoleSuperAdmin,
+		Active:   true,
+	})
+	if err != nil {
+		return
+	}
+	slog.InfoContext(ctx, "bootstrap superadmin created", "username", username)
+	return
+}
+
+func (s *service) Login(ctx context.Context, req LoginRequest) (res constants.DefaultResponse, err error) {
+	admin, err := s.adminRepository.FindByUsername(ctx, req.Username)
+	if err != nil || !admin.Active || !utils.CheckPasswordHash(admin.Password, req.Password) {
+		slog.ErrorContext(ctx, "admin login failed", "username", req.Username, "error", err)
+		s.auditService.Record(ctx, audit.Entry{
+			Action:     entities.AuditActionAdminLogin,
+			TargetType: "admin",
+			TargetID:   req.Username,
+			After:      map[string]interface{}{"success": false},
+		})
+		err = fmt.Errorf("username or password is wrong")
+		return
+	}
+
+	token, exp, err := utils.JwtSignAdmin(admin.ID, admin.Username)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to sign JWT token", "error", err)
+		err = fmt.Errorf("failed to sign JWT token")
+		return
+	}
+
+	session := entities.AdminLogin{
+		ID:       admin.ID,
+		Username: admin.Username,
+		Name:     admin.Name,
+		Role:     admin.Role,
+		Token:    token,
+		ExpireAt: exp,
+	}
+	err = s.redisWrapper.Set(ctx, entities.AdminSessionKey(admin.Username), time.Until(time.Unix(exp, 0)), session)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store admin session in Redis", "error", err)
+		err = fmt.Errorf("failed to create session")
+		return
+	}
+
+	now := time.Now()
+	admin.LastLoginAt = &now
+	if errUpdate := s.adminRepository.Update(ctx, &admin); errUpdate != nil {
+		slog.WarnContext(ctx, "failed to stamp admin last login", "username", admin.Username, "error", errUpdate)
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Actor:      entities.AdminActor(admin.Username),
+		Action:     entities.AuditActionAdminLogin,
+		TargetType: "admin",
+		TargetID:   admin.Username,
+		After:      map[string]interface{}{"success": true},
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: LoginResponseData{
+			Token:    token,
+			ExpireAt: exp,
+			Role:     admin.Role,
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+func (s *service) Logout(ctx context.Context) (res constants.DefaultResponse, err error) {
+	err = s.redisWrapper.Delete(ctx, entities.AdminSessionKey(adminUsername(ctx)))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to delete admin session from Redis", "error", err)
+		err = fmt.Errorf("failed to logout")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionAdminLogout,
+		TargetType: "admin",
+		TargetID:   adminUsername(ctx),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) GetProfile(ctx context.Context) (res constants.DefaultResponse, err error) {
+	session, _ := ctx.Value(types.String("admin")).(entities.AdminLogin)
+	admin, err := s.adminRepository.FindByID(ctx, session.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find admin by id", "error", err)
+		err = fmt.Errorf("failed to find admin by id")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    admin,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) GetAllAdmin(ctx context.Context, req FindAllAdminRequest) (res constants.DefaultResponse, err error) {
+	conds := make([]utils.DBCond, 0)
+	if req.Role != "" {
+		conds = append(conds, utils.DBCond{Where: "role = ?", WhereArgs: req.Role})
+	}
+
+	admins, count, err := s.adminRepository.FindAllAndCount(ctx, req.PaginationRequest, conds...)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find admins", "error", err)
+		err = fmt.Errorf("failed to find admins")
+		return
+	}
+
+	totalPages := uint(math.Ceil(float64(count) / float64(req.Limit)))
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: constants.PaginationResponseData{
+			Results: admins,
+			PaginationData: constants.PaginationData{
+				Page:        req.Page,
+				Limit:       req.Limit,
+				TotalPages:  totalPages,
+				TotalItems:  uint(count),
+				HasNext:     req.Page < totalPages,
+				HasPrevious: req.Page > 1,
+			},
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+func (s *service) CreateAdmin(ctx context.Context, req CreateAdminRequest) (res constants.DefaultResponse, err error) {
+	if !utils.IsValidPassword(req.Password) {
+		slog.ErrorContext(ctx, "password is not valid")
+		err = fmt.Errorf("password is not valid")
+		return
+	}
+
+	_, err = s.adminRepository.FindByUsername(ctx, req.Username)
+	if err == nil {
+		slog.ErrorContext(ctx, "admin already exists", "username", req.Username)
+		err = fmt.Errorf("admin already exists")
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to compile or hash new password", "error", err)
+		err = fmt.Errorf("failed to compile or hash new password")
+		return
+	}
+
+	admin := entities.Admin{
+		Username: req.Username,
+		Name:     req.Name,
+		Password: hashedPassword,
+		Role:     req.Role,
+		Active:   true,
+	}
+	err = s.adminRepository.Create(ctx, &admin)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create admin", "error", err)
+		err = fmt.Errorf("failed to create admin")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionCreateAdmin,
+		TargetType: "admin",
+		TargetID:   admin.Username,
+		After:      admin,
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    admin,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// UpdateAdmin changes another admin's name, role, password or active flag. Their session is
+// dropped so a new role or a deactivation takes effect on the next request, not at token expiry.
+// Admins cannot demote or deactivate themselves, that would risk locking everyone out.
+func (s *service) UpdateAdmin(ctx context.Context, req UpdateAdminRequest) (res constants.DefaultResponse, err error) {
+	admin, err := s.adminRepository.FindByID(ctx, req.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find admin by id", "id", req.ID, "error", err)
+		err = fmt.Errorf("failed to find admin by id")
+		return
+	}
+
+	if admin.Username == adminUsername(ctx) && ((req.Role != "" && req.Role != admin.Role) || (req.Active != nil && !*req.Active)) {
+		slog.ErrorContext(ctx, "admin tried to change own role or status", "username", admin.Username)
+		err = fmt.Errorf("cannot change own role or status")
+		return
+	}
+
+	before := admin
+	if req.Name != "" {
+		admin.Name = req.Name
+	}
+	if req.Role != "" {
+		admin.Role = req.Role
+	}
+	if req.Active != nil {
+		admin.Active = *req.Active
+	}
+	if req.Password != "" {
+		if !utils.IsValidPassword(req.Password) {
+			slog.ErrorContext(ctx, "password is not valid")
+			err = fmt.Errorf("password is not valid")
+			return
+		}
+		admin.Password, err = utils.HashPassword(req.Password)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to compile or hash new password", "error", err)
+			err = fmt.Errorf("failed to compile or hash new password")
+			return
+		}
+	}
+
+	err = s.adminRepository.Update(ctx, &admin)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update admin", "error", err)
+		err = fmt.Errorf("failed to update admin")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionUpdateAdmin,
+		TargetType: "admin",
+		TargetID:   admin.Username,
+		Before:     before,
+		After:      admin,
+	})
+
+	if errDelete := s.redisWrapper.Delete(ctx, entities.AdminSessionKey(admin.Username)); errDelete != nil {
+		slog.WarnContext(ctx, "failed to drop admin session", "username", admin.Username, "error", errDelete)
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    admin,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func adminUsername(ctx context.Context) string {
+	session, _ := ctx.Value(types.String("admin")).(entities.AdminLogin)
+	return session.Username
+}
@@ -0,0 +1,357 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
+	"github.com/danielpnjt/speed-engine/internal/usecase/ledger"
+	"github.com/danielpnjt/speed-engine/internal/usecase/transaction"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeUnitOfWork runs the work directly, the fakes below only write once every check passed.
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeUserRepository struct {
+	repositories.User
+	users map[int]entities.User
+}
+
+func (r *fakeUserRepository) FindByID(ctx context.Context, id int) (entities.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return user, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) FindByIDForUpdate(ctx context.Context, id int) (entities.User, error) {
+	return r.FindByID(ctx, id)
+}
+
+func (r *fakeUserRepository) UpdateBalance(ctx context.Context, entity *entities.User) error {
+	r.users[entity.ID] = *entity
+	return nil
+}
+
+type fakeTransactionRepository struct {
+	repositories.Transaction
+	transactions []entities.Transaction
+}
+
+func (r *fakeTransactionRepository) Create(ctx context.Context, entity *entities.Transaction) error {
+	entity.ID = len(r.transactions) + 1
+	r.transactions = append(r.transactions, *entity)
+	return nil
+}
+
+// FindAllAndCount only understands the user filter the player's history is built on.
+func (r *fakeTransactionRepository) FindAllAndCount(ctx context.Context, pagination constants.PaginationRequest, conds ...utils.DBCond) ([]entities.Transaction, int64, error) {
+	result := make([]entities.Transaction, 0)
+	for _, transaction := range r.transactions {
+		matches := true
+		for _, cond := range conds {
+			if cond.Where == "user_id = ?" && cond.WhereArgs != transaction.UserID {
+				matches = false
+			}
+		}
+		if matches {
+			result = append(result, transaction)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+type fakeBalanceAdjustmentRepository struct {
+	repositories.BalanceAdjustment
+	adjustments map[int]entities.BalanceAdjustment
+}
+
+func (r *fakeBalanceAdjustmentRepository) Create(ctx context.Context, entity *entities.BalanceAdjustment) error {
+	entity.ID = len(r.adjustments) + 1
+	r.adjustments[entity.ID] = *entity
+	return nil
+}
+
+func (r *fakeBalanceAdjustmentRepository) Update(ctx context.Context, entity *entities.BalanceAdjustment) error {
+	r.adjustments[entity.ID] = *entity
+	return nil
+}
+
+func (r *fakeBalanceAdjustmentRepository) FindByIDForUpdate(ctx context.Context, id int) (entities.BalanceAdjustment, error) {
+	adjustment, ok := r.adjustments[id]
+	if !ok {
+		return adjustment, gorm.ErrRecordNotFound
+	}
+	return adjustment, nil
+}
+
+// fakeLedgerRepository keeps accounts and journal entries in memory for the real ledger service.
+type fakeLedgerRepository struct {
+	repositories.Ledger
+	accounts []entities.LedgerAccount
+	entries  []entities.JournalEntry
+}
+
+func (r *fakeLedgerRepository) FirstOrCreateAccount(ctx context.Context, entity *entities.LedgerAccount) error {
+	account, err := r.FindAccountByCode(ctx, entity.Code)
+	if err == nil {
+		*entity = account
+		return nil
+	}
+	entity.ID = len(r.accounts) + 1
+	r.accounts = append(r.accounts, *entity)
+	return nil
+}
+
+func (r *fakeLedgerRepository) FindAccountByCode(ctx context.Context, code string) (entities.LedgerAccount, error) {
+	for _, account := range r.accounts {
+		if account.Code == code {
+			return account, nil
+		}
+	}
+	return entities.LedgerAccount{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLedgerRepository) CreateEntry(ctx context.Context, entity *entities.JournalEntry) error {
+	entity.ID = len(r.entries) + 1
+	r.entries = append(r.entries, *entity)
+	return nil
+}
+
+func (r *fakeLedgerRepository) FindEntriesByAccountID(ctx context.Context, accountID int) ([]entities.JournalEntry, error) {
+	entries := make([]entities.JournalEntry, 0)
+	for _, entry := range r.entries {
+		for _, posting := range entry.Postings {
+			if posting.LedgerAccountID == accountID {
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (r *fakeLedgerRepository) SumPostings(ctx context.Context, accountID int) (debit float64, credit float64, err error) {
+	for _, entry := range r.entries {
+		for _, posting := range entry.Postings {
+			if posting.LedgerAccountID != accountID {
+				continue
+			}
+			if posting.Direction == entities.PostingDirectionDebit {
+				debit += posting.Amount
+			} else {
+				credit += posting.Amount
+			}
+		}
+	}
+	return
+}
+
+type fakeAuditService struct {
+	audit.Service
+	entries []audit.Entry
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, entry audit.Entry) {
+	s.entries = append(s.entries, entry)
+}
+
+type adjustmentFixture struct {
+	service               *service
+	userRepository        *fakeUserRepository
+	transactionRepository *fakeTransactionRepository
+	ledgerService         ledger.Service
+}
+
+func newAdjustmentFixture(users ...entities.User) adjustmentFixture {
+	userRepository := &fakeUserRepository{users: make(map[int]entities.User)}
+	for _, user := range users {
+		userRepository.users[user.ID] = user
+	}
+	transactionRepository := &fakeTransactionRepository{}
+	ledgerService := ledger.NewService().
+		SetDB(&gorm.DB{}).
+		SetUnitOfWork(fakeUnitOfWork{}).
+		SetLedgerRepository(&fakeLedgerRepository{}).
+		SetUserRepository(userRepository).
+		Validate()
+
+	return adjustmentFixture{
+		service: &service{
+			unitOfWork:                  fakeUnitOfWork{},
+			userRepository:              userRepository,
+			transactionRepository:       transactionRepository,
+			balanceAdjustmentRepository: &fakeBalanceAdjustmentRepository{adjustments: make(map[int]entities.BalanceAdjustment)},
+			ledgerService:               ledgerService,
+			auditService:                &fakeAuditService{},
+		},
+		userRepository:        userRepository,
+		transactionRepository: transactionRepository,
+		ledgerService:         ledgerService,
+	}
+}
+
+func asAdmin(username string) context.Context {
+	return context.WithValue(context.TODO(), types.String("admin"), entities.AdminLogin{Username: username})
+}
+
+// propose files an adjustment as the maker and returns its id.
+func (f adjustmentFixture) propose(t *testing.T, userID int, direction string, amount float64) int {
+	res, err := f.service.ProposeAdjustment(asAdmin("maker"), ProposeAdjustmentRequest{
+		UserID:    userID,
+		Direction: direction,
+		Amount:    amount,
+		Reason:    "goodwill for a failed payout",
+	})
+	require.NoError(t, err)
+	return res.Data.(entities.BalanceAdjustment).ID
+}
+
+func TestAdminService_ApproveAdjustment(t *testing.T) {
+	f := newAdjustmentFixture(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 100000, Status: entities.UserStatusActive})
+	id := f.propose(t, 123, entities.BalanceAdjustmentCredit, 25000)
+
+	res, err := f.service.ApproveAdjustment(asAdmin("checker"), ReviewAdjustmentRequest{ID: id, Note: "checked the payout"})
+	require.NoError(t, err)
+	adjustment := res.Data.(entities.BalanceAdjustment)
+	require.Equal(t, entities.BalanceAdjustmentStatusApproved, adjustment.Status)
+	require.Equal(t, "checker", adjustment.ReviewedBy)
+	require.NotNil(t, adjustment.TransactionID)
+	require.Equal(t, float64(125000), f.userRepository.users[123].Balance)
+
+	// * the ledger carries the credit, the wallet reconciles against the new balance
+	reconcile, err := f.ledgerService.Reconcile(context.TODO(), 123)
+	require.NoError(t, err)
+	reconciled := reconcile.Data.(ledger.ReconcileResponseData)
+	require.Equal(t, float64(25000), reconciled.LedgerBalance)
+	require.Len(t, reconciled.Entries, 1)
+	require.Equal(t, f.transactionRepository.transactions[0].Reference, reconciled.Entries[0].Reference)
+
+	// * the player sees the adjustment in their own history
+	history, err := transaction.NewService().
+		SetTransactionRepository(f.transactionRepository).
+		FindAll(context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123}), transaction.FindAllRequest{
+			PaginationRequest: constants.PaginationRequest{Page: 1, Limit: 10},
+		})
+	require.NoError(t, err)
+	transactions := history.Data.(constants.PaginationResponseData).Results.([]entities.Transaction)
+	require.Len(t, transactions, 1)
+	require.Equal(t, *adjustment.TransactionID, transactions[0].ID)
+	require.Equal(t, entities.TransactionTypeAdjustmentIn, transactions[0].Type)
+	require.Equal(t, entities.TransactionStatusCompleted, transactions[0].Status)
+	require.Equal(t, float64(25000), transactions[0].Amount)
+}
+
+func TestAdminService_ApproveAdjustment_Refused(t *testing.T) {
+	t.Run("maker cannot approve their own adjustment", func(t *testing.T) {
+		f := newAdjustmentFixture(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 100000, Status: entities.UserStatusActive})
+		id := f.propose(t, 123, entities.BalanceAdjustmentCredit, 25000)
+
+		_, err := f.service.ApproveAdjustment(asAdmin("maker"), ReviewAdjustmentRequest{ID: id})
+		require.ErrorIs(t, err, constants.ErrSelfReview)
+		require.Equal(t, float64(100000), f.userRepository.users[123].Balance)
+		require.Empty(t, f.transactionRepository.transactions)
+	})
+
+	t.Run("adjustment is approved only once", func(t *testing.T) {
+		f := newAdjustmentFixture(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 100000, Status: entities.UserStatusActive})
+		id := f.propose(t, 123, entities.BalanceAdjustmentCredit, 25000)
+
+		_, err := f.service.ApproveAdjustment(asAdmin("checker"), ReviewAdjustmentRequest{ID: id})
+		require.NoError(t, err)
+		_, err = f.service.ApproveAdjustment(asAdmin("another.checker"), ReviewAdjustmentRequest{ID: id})
+		require.ErrorIs(t, err, constants.ErrNotPending)
+		require.Equal(t, float64(125000), f.userRepository.users[123].Balance)
+		require.Len(t, f.transactionRepository.transactions, 1)
+	})
+
+	t.Run("debit above the available balance", func(t *testing.T) {
+		f := newAdjustmentFixture(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 100000, HeldBalance: 90000, Status: entities.UserStatusActive})
+		id := f.propose(t, 123, entities.BalanceAdjustmentDebit, 25000)
+
+		_, err := f.service.ApproveAdjustment(asAdmin("checker"), ReviewAdjustmentRequest{ID: id})
+		require.ErrorIs(t, err, constants.ErrInsufficientFunds)
+		require.Equal(t, float64(100000), f.userRepository.users[123].Balance)
+		require.Empty(t, f.transactionRepository.transactions)
+	})
+
+	t.Run("frozen account", func(t *testing.T) {
+		f := newAdjustmentFixture(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 100000, Status: entities.UserStatusFrozen})
+		id := f.propose(t, 123, entities.BalanceAdjustmentCredit, 25000)
+
+		_, err := f.service.ApproveAdjustment(asAdmin("checker"), ReviewAdjustmentRequest{ID: id})
+		require.ErrorIs(t, err, constants.ErrAccountInactive)
+		require.Equal(t, float64(100000), f.userRepository.users[123].Balance)
+	})
+}
+
+func TestAdminService_ApproveAdjustment_Debit(t *testing.T) {
+	f := newAdjustmentFixture(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 100000, Status: entities.UserStatusActive})
+	id := f.propose(t, 123, entities.BalanceAdjustmentDebit, 40000)
+
+	_, err := f.service.ApproveAdjustment(asAdmin("checker"), ReviewAdjustmentRequest{ID: id})
+	require.NoError(t, err)
+	require.Equal(t, float64(60000), f.userRepository.users[123].Balance)
+	require.Equal(t, entities.TransactionTypeAdjustmentOut, f.transactionRepository.transactions[0].Type)
+
+	reconcile, err := f.ledgerService.Reconcile(context.TODO(), 123)
+	require.NoError(t, err)
+	require.Equal(t, float64(-40000), reconcile.Data.(ledger.ReconcileResponseData).LedgerBalance)
+}
+
+type fakeAdminRepository struct {
+	repositories.Admin
+	admins []entities.Admin
+}
+
+func (r *fakeAdminRepository) FindByUsername(ctx context.Context, username string) (entities.Admin, error) {
+	for _, admin := range r.admins {
+		if admin.Username == username {
+			return admin, nil
+		}
+	}
+	return entities.Admin{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAdminRepository) Create(ctx context.Context, entity *entities.Admin) error {
+	r.admins = append(r.admins, *entity)
+	return nil
+}
+
+func TestAdminService_Bootstrap(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		password   string
+		wantErr    bool
+		wantAdmins int
+	}{
+		{name: "nothing configured", wantAdmins: 0},
+		{name: "username without a password", username: "root", wantErr: true, wantAdmins: 0},
+		{name: "weak password", username: "root", password: "admin", wantErr: true, wantAdmins: 0},
+		{name: "strong password", username: "root", password: "Sup3rSecret", wantAdmins: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adminRepository := &fakeAdminRepository{}
+			service := &service{adminRepository: adminRepository}
+
+			err := service.Bootstrap(context.TODO(), tt.username, tt.password)
+			require.Equal(t, tt.wantErr, err != nil)
+			require.Len(t, adminRepository.admins, tt.wantAdmins)
+		})
+	}
+}
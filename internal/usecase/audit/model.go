@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+// * Requests
+type (
+	// RequestMeta describes the HTTP request an action came in with, the RequestMeta middleware
+	// puts it in the context under types.String("requestMeta").
+	RequestMeta struct {
+		IP        string
+		UserAgent string
+		RequestID string
+	}
+
+	// Entry is what a usecase records. Actor is taken from the logged in player or admin in
+	// the context when left empty, Before and After are marshalled to JSON.
+	Entry struct {
+		Actor      string
+		Action     string
+		TargetType string
+		TargetID   string
+		Before     interface{}
+		After      interface{}
+	}
+
+	SearchRequest struct {
+		constants.PaginationRequest
+		Actor      string `query:"actor"`
+		Action     string `query:"action"`
+		TargetType string `query:"targetType"`
+		TargetID   string `query:"targetId"`
+		RequestID  string `query:"requestId"`
+		StartDate  string `query:"startDate" validate:"omitempty,datetime=2006-01-02"`
+		EndDate    string `query:"endDate" validate:"omitempty,datetime=2006-01-02"`
+	}
+)
+
+// * Responses
+type (
+	VerifyResponseData struct {
+		Checked    int  `json:"checked"`
+		Valid      bool `json:"valid"`
+		BrokenAtID int  `json:"brokenAtId,omitempty"`
+	}
+)
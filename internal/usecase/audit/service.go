@@ -0,0 +1,15 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+type Service interface {
+	Record(ctx context.Context, entry Entry)
+	Run()
+	Close()
+	Search(ctx context.Context, req SearchRequest) (res constants.DefaultResponse, err error)
+	Verify(ctx context.Context) (res constants.DefaultResponse, err error)
+}
@@ -0,0 +1,250 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBufferSize = 1024
+	verifyBatchSize   = 500
+)
+
+type service struct {
+	db                 *gorm.DB
+	auditLogRepository repositories.AuditLog
+	bufferSize         int
+	queue              chan entities.AuditLog
+	// * mu keeps Record from sending on the queue once Close has closed it
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func NewService() *service {
+	return &service{}
+}
+
+func (s *service) SetDB(db *gorm.DB) *service {
+	s.db = db
+	return s
+}
+
+func (s *service) SetAuditLogRepository(repository repositories.AuditLog) *service {
+	s.auditLogRepository = repository
+	return s
+}
+
+func (s *service) SetBufferSize(size int) *service {
+	s.bufferSize = size
+	return s
+}
+
+func (s *service) Validate() Service {
+	if s.db == nil {
+		panic("db is nil")
+	}
+	if s.auditLogRepository == nil {
+		panic("auditLogRepository is nil")
+	}
+	if s.bufferSize <= 0 {
+		s.bufferSize = defaultBufferSize
+	}
+	s.queue = make(chan entities.AuditLog, s.bufferSize)
+	s.done = make(chan struct{})
+	return s
+}
+
+// Record queues an audit record for the background writer started by Run, the request
+// does not wait for the insert. When the queue is full the record is written inline
+// rather than dropped.
+func (s *service) Record(ctx context.Context, entry Entry) {
+	meta, _ := ctx.Value(types.String("requestMeta")).(RequestMeta)
+	log := entities.AuditLog{
+		Actor:      entry.Actor,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         meta.IP,
+		UserAgent:  meta.UserAgent,
+		RequestID:  meta.RequestID,
+		Before:     snapshot(ctx, entry.Before),
+		After:      snapshot(ctx, entry.After),
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if log.Actor == "" {
+		log.Actor = actor(ctx)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.write(log)
+		return
+	}
+
+	select {
+	case s.queue <- log:
+	default:
+		slog.WarnContext(ctx, "audit queue is full, writing inline", "action", log.Action)
+		s.write(log)
+	}
+}
+
+// Run writes queued records one by one, which keeps the hash chain in insertion order.
+func (s *service) Run() {
+	defer close(s.done)
+	for log := range s.queue {
+		s.write(log)
+	}
+}
+
+// Close stops queueing and waits for Run to write what is still in the queue, records that
+// arrive afterwards are written inline. Run must have been started.
+func (s *service) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *service) write(log entities.AuditLog) {
+	err := s.auditLogRepository.Append(context.Background(), &log)
+	if err != nil {
+		// * the full record goes to the log so the trace survives a failed insert
+		slog.Error("failed to append audit log", "error", err,
+			"actor", log.Actor, "action", log.Action, "targetType", log.TargetType, "targetId", log.TargetID,
+			"ip", log.IP, "requestId", log.RequestID, "before", string(log.Before), "after", string(log.After),
+			"createdAt", log.CreatedAt)
+	}
+}
+
+func (s *service) Search(ctx context.Context, req SearchRequest) (res constants.DefaultResponse, err error) {
+	conds := make([]utils.DBCond, 0)
+	if req.Actor != "" {
+		conds = append(conds, utils.DBCond{Where: "actor = ?", WhereArgs: req.Actor})
+	}
+	if req.Action != "" {
+		conds = append(conds, utils.DBCond{Where: "action = ?", WhereArgs: req.Action})
+	}
+	if req.TargetType != "" {
+		conds = append(conds, utils.DBCond{Where: "target_type = ?", WhereArgs: req.TargetType})
+	}
+	if req.TargetID != "" {
+		conds = append(conds, utils.DBCond{Where: "target_id = ?", WhereArgs: req.TargetID})
+	}
+	if req.RequestID != "" {
+		conds = append(conds, utils.DBCond{Where: "request_id = ?", WhereArgs: req.RequestID})
+	}
+	if req.StartDate != "" {
+		startDate, _ := time.ParseInLocation(time.DateOnly, req.StartDate, time.Local)
+		conds = append(conds, utils.DBCond{Where: "created_at >= ?", WhereArgs: startDate})
+	}
+	if req.EndDate != "" {
+		// * the end date is inclusive, the range runs up to the start of the next day
+		endDate, _ := time.ParseInLocation(time.DateOnly, req.EndDate, time.Local)
+		conds = append(conds, utils.DBCond{Where: "created_at < ?", WhereArgs: endDate.AddDate(0, 0, 1)})
+	}
+
+	logs, count, err := s.auditLogRepository.FindAllAndCount(ctx, req.PaginationRequest, conds...)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find audit logs", "error", err)
+		err = fmt.Errorf("failed to find audit logs")
+		return
+	}
+
+	totalPages := uint(math.Ceil(float64(count) / float64(req.Limit)))
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: constants.PaginationResponseData{
+			Results: logs,
+			PaginationData: constants.PaginationData{
+				Page:        req.Page,
+				Limit:       req.Limit,
+				TotalPages:  totalPages,
+				TotalItems:  uint(count),
+				HasNext:     req.Page < totalPages,
+				HasPrevious: req.Page > 1,
+			},
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+// Verify walks the whole chain and reports the first record whose link or content no longer matches.
+func (s *service) Verify(ctx context.Context) (res constants.DefaultResponse, err error) {
+	data := VerifyResponseData{Valid: true}
+	var lastID int
+	var prevHash string
+	for data.Valid {
+		logs, errFind := s.auditLogRepository.FindAfterID(ctx, lastID, verifyBatchSize)
+		if errFind != nil {
+			slog.ErrorContext(ctx, "failed to read audit logs", "afterId", lastID, "error", errFind)
+			err = fmt.Errorf("failed to read audit logs")
+			return
+		}
+		for _, log := range logs {
+			if log.PrevHash != prevHash || log.Hash != log.ComputeHash() {
+				data.Valid = false
+				data.BrokenAtID = log.ID
+				break
+			}
+			data.Checked++
+			prevHash = log.Hash
+			lastID = log.ID
+		}
+		if len(logs) < verifyBatchSize {
+			break
+		}
+	}
+	if !data.Valid {
+		slog.ErrorContext(ctx, "audit log chain is broken", "id", data.BrokenAtID)
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func actor(ctx context.Context) string {
+	if admin, ok := ctx.Value(types.String("admin")).(entities.AdminLogin); ok {
+		return entities.AdminActor(admin.Username)
+	}
+	if user, ok := ctx.Value(types.String("user")).(entities.Login); ok {
+		return entities.UserActor(user.ID)
+	}
+	return "anonymous"
+}
+
+func snapshot(ctx context.Context, v interface{}) entities.AuditSnapshot {
+	if v == nil {
+		return ""
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to marshal audit snapshot", "error", err)
+		return ""
+	}
+	return entities.AuditSnapshot(raw)
+}
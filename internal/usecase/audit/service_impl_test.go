@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuditLogRepository chains records in memory the way the postgres repository does.
+type fakeAuditLogRepository struct {
+	repositories.AuditLog
+	logs []entities.AuditLog
+}
+
+func (r *fakeAuditLogRepository) Append(ctx context.Context, entity *entities.AuditLog) error {
+	entity.ID = len(r.logs) + 1
+	if len(r.logs) > 0 {
+		entity.PrevHash = r.logs[len(r.logs)-1].Hash
+	}
+	entity.Hash = entity.ComputeHash()
+	r.logs = append(r.logs, *entity)
+	return nil
+}
+
+func (r *fakeAuditLogRepository) FindAfterID(ctx context.Context, id int, limit int) ([]entities.AuditLog, error) {
+	result := make([]entities.AuditLog, 0)
+	for _, log := range r.logs {
+		if log.ID > id && len(result) < limit {
+			result = append(result, log)
+		}
+	}
+	return result, nil
+}
+
+func TestAuditService_Verify(t *testing.T) {
+	repository := &fakeAuditLogRepository{}
+	service := &service{auditLogRepository: repository, queue: make(chan entities.AuditLog, 10), done: make(chan struct{})}
+
+	ctx := context.WithValue(context.TODO(), types.String("requestMeta"), RequestMeta{IP: "10.0.0.1", UserAgent: "curl", RequestID: "req-1"})
+	ctx = context.WithValue(ctx, types.String("user"), entities.Login{ID: 123})
+	for _, action := range []string{entities.AuditActionLogin, entities.AuditActionSubmitBank, entities.AuditActionWithdraw} {
+		service.Record(ctx, Entry{Action: action, TargetType: "user", TargetID: "123", After: map[string]interface{}{"amount": 50000}})
+	}
+	go service.Run()
+	service.Close()
+
+	require.Len(t, repository.logs, 3)
+	require.Equal(t, "user:123", repository.logs[0].Actor)
+	require.Equal(t, "10.0.0.1", repository.logs[0].IP)
+	require.Equal(t, "req-1", repository.logs[0].RequestID)
+	require.Equal(t, entities.AuditSnapshot(`{"amount":50000}`), repository.logs[0].After)
+
+	res, err := service.Verify(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, VerifyResponseData{Checked: 3, Valid: true}, res.Data)
+
+	repository.logs[1].After = `{"amount":1}`
+	res, err = service.Verify(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, VerifyResponseData{Checked: 1, Valid: false, BrokenAtID: 2}, res.Data)
+}
+
+func TestAuditService_Close(t *testing.T) {
+	repository := &fakeAuditLogRepository{}
+	service := &service{auditLogRepository: repository, queue: make(chan entities.AuditLog, 10), done: make(chan struct{})}
+
+	// * nothing is written until Run starts, Close has to drain the queue
+	service.Record(context.TODO(), Entry{Action: entities.AuditActionLogin, TargetType: "user", TargetID: "123"})
+	service.Record(context.TODO(), Entry{Action: entities.AuditActionWithdraw, TargetType: "user", TargetID: "123"})
+	require.Empty(t, repository.logs)
+
+	go service.Run()
+	service.Close()
+	require.Len(t, repository.logs, 2)
+
+	service.Record(context.TODO(), Entry{Action: entities.AuditActionLogout, TargetType: "user", TargetID: "123"})
+	require.Len(t, repository.logs, 3)
+}
@@ -6,10 +6,24 @@ import (
 
 // * Requests
 type (
+	// SubmitBank takes the bank as a code from the bank catalogue, the account number has to
+	// fit that bank's format.
 	SubmitBank struct {
-		AccountName   string `json:"accountName"`
-		AccountNumber string `json:"accountNumber"`
-		BankName      string `json:"bankName"`
+		AccountName   string `json:"accountName" validate:"required"`
+		AccountNumber string `json:"accountNumber" validate:"required,numeric"`
+		BankCode      string `json:"bankCode" validate:"required"`
+		IsPrimary     bool   `json:"isPrimary"`
+	}
+
+	UpdateBank struct {
+		ID            int    `param:"id" validate:"required"`
+		AccountName   string `json:"accountName" validate:"required"`
+		AccountNumber string `json:"accountNumber" validate:"required,numeric"`
+		BankCode      string `json:"bankCode" validate:"required"`
+	}
+
+	FindByIDRequest struct {
+		ID int `param:"id" validate:"required"`
 	}
 )
 
@@ -8,5 +8,10 @@ import (
 
 type Service interface {
 	SubmitBank(ctx context.Context, req SubmitBank) (res constants.DefaultResponse, err error)
+	UpdateBank(ctx context.Context, req UpdateBank) (res constants.DefaultResponse, err error)
+	DeleteBank(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error)
+	SetPrimary(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error)
+	VerifyBank(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error)
 	FindAll(ctx context.Context) (res constants.DefaultResponse, err error)
+	GetBankCodes(ctx context.Context) (res constants.DefaultResponse, err error)
 }
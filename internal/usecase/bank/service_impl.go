@@ -2,21 +2,45 @@ package bank
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/payment"
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
 	"gorm.io/gorm"
 )
 
+const (
+	defaultMaxAccounts        = 5
+	defaultNameMatchThreshold = 0.8
+
+	// * name inquiries are paid provider calls, a player gets a handful per window
+	inquiryLimit  = 10
+	inquiryWindow = time.Hour
+)
+
 type service struct {
 	db             *gorm.DB
+	unitOfWork     repositories.UnitOfWork
 	bankRepository repositories.Bank
+	userRepository repositories.User
 	redisWrapper   redis.Wrapper
+	paymentWrapper payment.Wrapper
+	auditService   audit.Service
+	maxAccounts    int
+	// nameMatchThreshold is the lowest utils.NameSimilarity between the account holder and the
+	// player's registered name that still verifies the account
+	nameMatchThreshold float64
 }
 
 func NewService() *service {
@@ -28,46 +52,260 @@ func (s *service) SetDB(db *gorm.DB) *service {
 	return s
 }
 
+func (s *service) SetUnitOfWork(unitOfWork repositories.UnitOfWork) *service {
+	s.unitOfWork = unitOfWork
+	return s
+}
+
 func (s *service) SetBankRepository(repository repositories.Bank) *service {
 	s.bankRepository = repository
 	return s
 }
 
+func (s *service) SetUserRepository(repository repositories.User) *service {
+	s.userRepository = repository
+	return s
+}
+
 func (s *service) SetRedisWrapper(wrapper redis.Wrapper) *service {
 	s.redisWrapper = wrapper
 	return s
 }
 
+func (s *service) SetPaymentWrapper(wrapper payment.Wrapper) *service {
+	s.paymentWrapper = wrapper
+	return s
+}
+
+func (s *service) SetAuditService(service audit.Service) *service {
+	s.auditService = service
+	return s
+}
+
+// SetMaxAccounts caps how many bank accounts a player may keep at once.
+func (s *service) SetMaxAccounts(max int) *service {
+	s.maxAccounts = max
+	return s
+}
+
+func (s *service) SetNameMatchThreshold(threshold float64) *service {
+	s.nameMatchThreshold = threshold
+	return s
+}
+
 func (s *service) Validate() Service {
 	if s.db == nil {
 		panic("db is nil")
 	}
+	if s.unitOfWork == nil {
+		panic("unitOfWork is nil")
+	}
 	if s.bankRepository == nil {
 		panic("bankRepository is nil")
 	}
+	if s.userRepository == nil {
+		panic("userRepository is nil")
+	}
 	if s.redisWrapper == nil {
 		panic("redisWrapper is nil")
 	}
+	if s.paymentWrapper == nil {
+		panic("paymentWrapper is nil")
+	}
+	if s.auditService == nil {
+		panic("auditService is nil")
+	}
+	if s.maxAccounts <= 0 {
+		s.maxAccounts = defaultMaxAccounts
+	}
+	if s.nameMatchThreshold <= 0 {
+		s.nameMatchThreshold = defaultNameMatchThreshold
+	}
 	return s
 }
 
+// SubmitBank stores a new withdrawal account and runs the name inquiry on it, an account the
+// inquiry cannot verify is kept and can be retried with VerifyBank. A player's first account
+// becomes primary on its own.
 func (s *service) SubmitBank(ctx context.Context, req SubmitBank) (res constants.DefaultResponse, err error) {
 	userData, _ := ctx.Value(types.String("user")).(entities.Login)
-	fmt.Println("===========", userData, userData.Username)
 	userID := userData.ID
 
+	bankCode, err := accountFormat(req.BankCode, req.AccountNumber)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid bank account", "userId", userID, "bankCode", req.BankCode, "error", err)
+		return
+	}
 	bank := &entities.Bank{
 		UserID:        userID,
-		AccountName:   req.AccountName,
+		AccountName:   strings.TrimSpace(req.AccountName),
 		AccountNumber: req.AccountNumber,
-		BankName:      req.BankName,
+		BankCode:      bankCode.Code,
+		BankName:      bankCode.Name,
+	}
+	// * the user row lock serializes account changes of one player, so the limit and the
+	// * single primary account hold under concurrent requests
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		_, err = s.userRepository.FindByIDForUpdate(ctx, userID)
+		if err != nil {
+			return
+		}
+
+		count, err := s.bankRepository.CountByUserID(ctx, userID)
+		if err != nil {
+			return
+		}
+		if count >= int64(s.maxAccounts) {
+			return constants.ErrBankLimitReached
+		}
+
+		exists, err := s.bankRepository.ExistsAccount(ctx, userID, bank.BankCode, bank.AccountNumber, 0)
+		if err != nil {
+			return
+		}
+		if exists {
+			return constants.ErrBankDuplicate
+		}
+
+		err = s.bankRepository.Create(ctx, bank)
+		if err != nil {
+			return
+		}
+		if count == 0 || req.IsPrimary {
+			err = s.bankRepository.SetPrimary(ctx, bank)
+		}
+		return
+	})
+	if err != nil {
+		err = s.bankError(ctx, "failed to submit bank", userID, err)
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionSubmitBank,
+		TargetType: "bank",
+		TargetID:   strconv.Itoa(bank.ID),
+		After:      bank,
+	})
+	if errVerify := s.verifyAccount(ctx, bank); errVerify != nil {
+		slog.WarnContext(ctx, "bank account left unverified", "userId", userID, "bankId", bank.ID, "error", errVerify)
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    bank,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// UpdateBank edits an account, changing the bank or the number drops its verification until
+// the name inquiry matches again.
+func (s *service) UpdateBank(ctx context.Context, req UpdateBank) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	userID := userData.ID
+
+	bankCode, err := accountFormat(req.BankCode, req.AccountNumber)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid bank account", "userId", userID, "bankCode", req.BankCode, "error", err)
+		return
 	}
-	err = s.bankRepository.Create(ctx, bank)
+
+	var before, bank entities.Bank
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		_, err = s.userRepository.FindByIDForUpdate(ctx, userID)
+		if err != nil {
+			return
+		}
+
+		before, err = s.bankRepository.FindByIDAndUserID(ctx, req.ID, userID)
+		if err != nil {
+			return
+		}
+		bank = before
+		bank.AccountName = strings.TrimSpace(req.AccountName)
+		bank.AccountNumber = req.AccountNumber
+		bank.BankCode = bankCode.Code
+		bank.BankName = bankCode.Name
+		if bank.AccountNumber != before.AccountNumber || bank.BankCode != before.BankCode {
+			bank.VerifiedName = ""
+			bank.VerifiedAt = nil
+		}
+
+		exists, err := s.bankRepository.ExistsAccount(ctx, userID, bank.BankCode, bank.AccountNumber, bank.ID)
+		if err != nil {
+			return
+		}
+		if exists {
+			return constants.ErrBankDuplicate
+		}
+		return s.bankRepository.Update(ctx, &bank)
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to submit bank", err)
-		err = fmt.Errorf("failed to submit bank")
+		err = s.bankError(ctx, "failed to update bank", userID, err)
 		return
 	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionUpdateBank,
+		TargetType: "bank",
+		TargetID:   strconv.Itoa(bank.ID),
+		Before:     before,
+		After:      bank,
+	})
+	if !bank.Verified() {
+		if errVerify := s.verifyAccount(ctx, &bank); errVerify != nil {
+			slog.WarnContext(ctx, "bank account left unverified", "userId", userID, "bankId", bank.ID, "error", errVerify)
+		}
+	}
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    bank,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// DeleteBank removes the account from the player's list. When it was the primary account the
+// oldest remaining one takes over.
+func (s *service) DeleteBank(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	userID := userData.ID
+
+	var bank entities.Bank
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		_, err = s.userRepository.FindByIDForUpdate(ctx, userID)
+		if err != nil {
+			return
+		}
+
+		bank, err = s.bankRepository.FindByIDAndUserID(ctx, req.ID, userID)
+		if err != nil {
+			return
+		}
+		wasPrimary := bank.IsPrimary
+		err = s.bankRepository.Delete(ctx, &bank)
+		if err != nil || !wasPrimary {
+			return
+		}
+
+		remaining, err := s.bankRepository.FindByUserID(ctx, userID)
+		if err != nil || len(remaining) == 0 {
+			return
+		}
+		return s.bankRepository.SetPrimary(ctx, &remaining[0])
+	})
+	if err != nil {
+		err = s.bankError(ctx, "failed to delete bank", userID, err)
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionDeleteBank,
+		TargetType: "bank",
+		TargetID:   strconv.Itoa(bank.ID),
+		Before:     map[string]interface{}{"deletedAt": nil},
+		After:      bank,
+	})
 	res = constants.DefaultResponse{
 		Status:  constants.STATUS_SUCCESS,
 		Message: constants.MESSAGE_SUCCESS,
@@ -77,13 +315,149 @@ func (s *service) SubmitBank(ctx context.Context, req SubmitBank) (res constants
 	return
 }
 
+func (s *service) SetPrimary(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	userID := userData.ID
+
+	var bank entities.Bank
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		_, err = s.userRepository.FindByIDForUpdate(ctx, userID)
+		if err != nil {
+			return
+		}
+
+		bank, err = s.bankRepository.FindByIDAndUserID(ctx, req.ID, userID)
+		if err != nil {
+			return
+		}
+		return s.bankRepository.SetPrimary(ctx, &bank)
+	})
+	if err != nil {
+		err = s.bankError(ctx, "failed to set primary bank", userID, err)
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionSetPrimaryBank,
+		TargetType: "bank",
+		TargetID:   strconv.Itoa(bank.ID),
+		After:      bank,
+	})
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    bank,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// VerifyBank runs the name inquiry again for an account that is not verified yet.
+func (s *service) VerifyBank(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	userID := userData.ID
+
+	bank, err := s.bankRepository.FindByIDAndUserID(ctx, req.ID, userID)
+	if err != nil {
+		err = s.bankError(ctx, "failed to find bank", userID, err)
+		return
+	}
+	if !bank.Verified() {
+		err = s.verifyAccount(ctx, &bank)
+		if err != nil {
+			err = s.bankError(ctx, "failed to verify bank", userID, err)
+			return
+		}
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    bank,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// verifyAccount asks the payment provider who holds the account and marks it verified when the
+// holder is close enough to the player's registered name.
+func (s *service) verifyAccount(ctx context.Context, bank *entities.Bank) (err error) {
+	count, err := s.redisWrapper.Incr(ctx, entities.BankInquiryKey(bank.UserID), inquiryWindow)
+	if err != nil {
+		return
+	}
+	if count > inquiryLimit {
+		return constants.ErrBankInquiryLimit
+	}
+
+	user, err := s.userRepository.FindByID(ctx, bank.UserID)
+	if err != nil {
+		return
+	}
+	inquiry, err := s.paymentWrapper.AccountInquiry(ctx, payment.AccountInquiryRequest{
+		BankCode:           bank.BankCode,
+		AccountNumber:      bank.AccountNumber,
+		ExpectedHolderName: bank.AccountName,
+	})
+	if err != nil {
+		return
+	}
+
+	holder := strings.TrimSpace(inquiry.Data.AccountHolderName)
+	score := utils.NameSimilarity(holder, user.Name)
+	if score < s.nameMatchThreshold {
+		slog.WarnContext(ctx, "bank account holder does not match player", "userId", user.ID, "bankId", bank.ID, "score", score)
+		return constants.ErrBankNameMismatch
+	}
+
+	now := time.Now()
+	bank.AccountName = holder
+	bank.VerifiedName = holder
+	bank.VerifiedAt = &now
+	err = s.bankRepository.UpdateVerification(ctx, bank)
+	if err != nil {
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionVerifyBank,
+		TargetType: "bank",
+		TargetID:   strconv.Itoa(bank.ID),
+		After:      map[string]interface{}{"verifiedName": holder, "provider": inquiry.Provider, "score": score},
+	})
+	return
+}
+
+// accountFormat resolves the bank code against the catalogue and checks the account number
+// fits that bank.
+func accountFormat(code string, accountNumber string) (bankCode entities.BankCode, err error) {
+	bankCode, ok := entities.FindBankCode(code)
+	if !ok {
+		err = constants.ErrInvalidBankCode
+		return
+	}
+	if !bankCode.ValidAccountNumber(accountNumber) {
+		err = constants.ErrInvalidAccountNumber
+		return
+	}
+	return
+}
+
+func (s *service) GetBankCodes(ctx context.Context) (res constants.DefaultResponse, err error) {
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    entities.BankCodes(),
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
 func (s *service) FindAll(ctx context.Context) (res constants.DefaultResponse, err error) {
 	userData, _ := ctx.Value(types.String("user")).(entities.Login)
 	userID := userData.ID
 
 	banks, err := s.bankRepository.FindByUserID(ctx, userID)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to find bank by userID", err)
+		slog.ErrorContext(ctx, "failed to find bank by userID", "error", err)
 		err = fmt.Errorf("failed to find bank by userID")
 		return
 	}
@@ -95,3 +469,16 @@ func (s *service) FindAll(ctx context.Context) (res constants.DefaultResponse, e
 	}
 	return
 }
+
+func (s *service) bankError(ctx context.Context, msg string, userID int, err error) error {
+	slog.ErrorContext(ctx, msg, "userId", userID, "error", err)
+	for _, known := range []error{constants.ErrBankLimitReached, constants.ErrBankDuplicate, constants.ErrBankNameMismatch, constants.ErrBankInquiryLimit} {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return constants.ErrBankNotFound
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,41 @@
+package fee
+
+import (
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+// * Requests
+type (
+	FindAllRequest struct {
+		constants.PaginationRequest
+		Operation string `query:"operation" validate:"omitempty,oneof=topup withdraw"`
+		Active    string `query:"active" validate:"omitempty,oneof=true false"`
+	}
+
+	CreateRequest struct {
+		Operation  string  `json:"operation" validate:"required,oneof=topup withdraw"`
+		Provider   string  `json:"provider"`
+		BankCode   string  `json:"bankCode"`
+		MinAmount  float64 `json:"minAmount" validate:"gte=0"`
+		MaxAmount  float64 `json:"maxAmount" validate:"gte=0"`
+		FlatFee    float64 `json:"flatFee" validate:"gte=0"`
+		PercentFee float64 `json:"percentFee" validate:"gte=0,lte=100"`
+		MinFee     float64 `json:"minFee" validate:"gte=0"`
+		MaxFee     float64 `json:"maxFee" validate:"gte=0"`
+	}
+
+	DeactivateRequest struct {
+		ID int `param:"id" validate:"required"`
+	}
+)
+
+// * Responses
+type (
+	// Charge is the fee a movement is priced at, RuleID is nil when no rule matched and the
+	// movement is free.
+	Charge struct {
+		Provider string  `json:"provider"`
+		RuleID   *int    `json:"ruleId"`
+		Fee      float64 `json:"fee"`
+	}
+)
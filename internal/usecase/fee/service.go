@@ -0,0 +1,14 @@
+package fee
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+type Service interface {
+	Calculate(ctx context.Context, operation string, bankCode string, amount float64) (charge Charge, err error)
+	GetAll(ctx context.Context, req FindAllRequest) (res constants.DefaultResponse, err error)
+	Create(ctx context.Context, req CreateRequest) (res constants.DefaultResponse, err error)
+	Deactivate(ctx context.Context, req DeactivateRequest) (res constants.DefaultResponse, err error)
+}
@@ -0,0 +1,243 @@
+package fee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/payment"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
+	"gorm.io/gorm"
+)
+
+// paymentOperations maps a fee operation to the provider call it prices.
+var paymentOperations = map[string]payment.Operation{
+	entities.FeeOperationTopUp:    payment.OperationCreateVA,
+	entities.FeeOperationWithdraw: payment.OperationWithdraw,
+}
+
+type service struct {
+	db                *gorm.DB
+	unitOfWork        repositories.UnitOfWork
+	feeRuleRepository repositories.FeeRule
+	paymentResolver   payment.Resolver
+	auditService      audit.Service
+}
+
+func NewService() *service {
+	return &service{}
+}
+
+func (s *service) SetDB(db *gorm.DB) *service {
+	s.db = db
+	return s
+}
+
+func (s *service) SetUnitOfWork(unitOfWork repositories.UnitOfWork) *service {
+	s.unitOfWork = unitOfWork
+	return s
+}
+
+func (s *service) SetFeeRuleRepository(repository repositories.FeeRule) *service {
+	s.feeRuleRepository = repository
+	return s
+}
+
+func (s *service) SetPaymentResolver(resolver payment.Resolver) *service {
+	s.paymentResolver = resolver
+	return s
+}
+
+func (s *service) SetAuditService(service audit.Service) *service {
+	s.auditService = service
+	return s
+}
+
+func (s *service) Validate() Service {
+	if s.db == nil {
+		panic("db is nil")
+	}
+	if s.unitOfWork == nil {
+		panic("unitOfWork is nil")
+	}
+	if s.feeRuleRepository == nil {
+		panic("feeRuleRepository is nil")
+	}
+	if s.paymentResolver == nil {
+		panic("paymentResolver is nil")
+	}
+	if s.auditService == nil {
+		panic("auditService is nil")
+	}
+	return s
+}
+
+// Calculate prices a movement with the provider it will be routed to first, the caller stores
+// the charge on the transaction so later rule changes leave it alone.
+func (s *service) Calculate(ctx context.Context, operation string, bankCode string, amount float64) (charge Charge, err error) {
+	paymentOperation, ok := paymentOperations[operation]
+	if !ok {
+		err = fmt.Errorf("unknown fee operation %q", operation)
+		return
+	}
+	charge.Provider = s.paymentResolver.Provider(paymentOperation, bankCode, amount)
+
+	rules, err := s.feeRuleRepository.FindActiveByOperation(ctx, operation)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find fee rules", "operation", operation, "error", err)
+		err = fmt.Errorf("failed to find fee rules")
+		return
+	}
+	rule, ok := entities.SelectFeeRule(rules, operation, charge.Provider, bankCode, amount)
+	if !ok {
+		return
+	}
+	charge.RuleID = &rule.ID
+	charge.Fee = rule.Charge(amount)
+	return
+}
+
+func (s *service) GetAll(ctx context.Context, req FindAllRequest) (res constants.DefaultResponse, err error) {
+	conds := make([]utils.DBCond, 0)
+	if req.Operation != "" {
+		conds = append(conds, utils.DBCond{Where: "operation = ?", WhereArgs: req.Operation})
+	}
+	if req.Active != "" {
+		conds = append(conds, utils.DBCond{Where: "active = ?", WhereArgs: req.Active == "true"})
+	}
+
+	rules, count, err := s.feeRuleRepository.FindAllAndCount(ctx, req.PaginationRequest, conds...)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find fee rules", "error", err)
+		err = fmt.Errorf("failed to find fee rules")
+		return
+	}
+
+	totalPages := uint(math.Ceil(float64(count) / float64(req.Limit)))
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: constants.PaginationResponseData{
+			Results: rules,
+			PaginationData: constants.PaginationData{
+				Page:        req.Page,
+				Limit:       req.Limit,
+				TotalPages:  totalPages,
+				TotalItems:  uint(count),
+				HasNext:     req.Page < totalPages,
+				HasPrevious: req.Page > 1,
+			},
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+// Create adds a rule, it applies to every transaction created from now on.
+func (s *service) Create(ctx context.Context, req CreateRequest) (res constants.DefaultResponse, err error) {
+	if req.MaxAmount > 0 && req.MaxAmount < req.MinAmount {
+		err = fmt.Errorf("maxAmount must not be below minAmount")
+		return
+	}
+	if req.MaxFee > 0 && req.MaxFee < req.MinFee {
+		err = fmt.Errorf("maxFee must not be below minFee")
+		return
+	}
+
+	rule := entities.FeeRule{
+		Operation:  req.Operation,
+		Provider:   strings.ToLower(strings.TrimSpace(req.Provider)),
+		BankCode:   strings.ToUpper(strings.TrimSpace(req.BankCode)),
+		MinAmount:  req.MinAmount,
+		MaxAmount:  req.MaxAmount,
+		FlatFee:    req.FlatFee,
+		PercentFee: req.PercentFee,
+		MinFee:     req.MinFee,
+		MaxFee:     req.MaxFee,
+		Active:     true,
+		CreatedBy:  adminUsername(ctx),
+	}
+	err = s.feeRuleRepository.Create(ctx, &rule)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create fee rule", "error", err)
+		err = fmt.Errorf("failed to create fee rule")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionCreateFeeRule,
+		TargetType: "fee_rule",
+		TargetID:   strconv.Itoa(rule.ID),
+		After:      rule,
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    rule,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// Deactivate retires a rule. Transactions already charged under it keep their fee and point
+// back at the rule.
+func (s *service) Deactivate(ctx context.Context, req DeactivateRequest) (res constants.DefaultResponse, err error) {
+	var rule entities.FeeRule
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		rule, err = s.feeRuleRepository.FindByIDForUpdate(ctx, req.ID)
+		if err != nil {
+			return
+		}
+		if !rule.Active {
+			return constants.ErrFeeRuleInactive
+		}
+
+		now := time.Now()
+		rule.Active = false
+		rule.DeactivatedBy = adminUsername(ctx)
+		rule.DeactivatedAt = &now
+		return s.feeRuleRepository.Update(ctx, &rule)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to deactivate fee rule", "id", req.ID, "error", err)
+		switch {
+		case errors.Is(err, constants.ErrFeeRuleInactive):
+			err = constants.ErrFeeRuleInactive
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = fmt.Errorf("fee rule not found")
+		default:
+			err = fmt.Errorf("failed to deactivate fee rule")
+		}
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionDeactivateFeeRule,
+		TargetType: "fee_rule",
+		TargetID:   strconv.Itoa(rule.ID),
+		Before:     map[string]interface{}{"active": true},
+		After:      rule,
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    rule,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func adminUsername(ctx context.Context) string {
+	session, _ := ctx.Value(types.String("admin")).(entities.AdminLogin)
+	return session.Username
+}
@@ -0,0 +1,49 @@
+package kyc
+
+import (
+	"mime/multipart"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+// * Requests
+type (
+	// SubmitRequest is read from a multipart form, Document is the uploaded identity document.
+	SubmitRequest struct {
+		FullName    string                `json:"fullName" validate:"required"`
+		NationalID  string                `json:"nationalId" validate:"required,numeric,len=16"`
+		DateOfBirth string                `json:"dateOfBirth" validate:"required,datetime=2006-01-02"`
+		Document    *multipart.FileHeader `json:"-"`
+	}
+
+	FindAllRequest struct {
+		constants.PaginationRequest
+		UserID int    `query:"userId" validate:"omitempty,gte=1"`
+		Status string `query:"status" validate:"omitempty,oneof=PENDING APPROVED REJECTED"`
+	}
+
+	FindByIDRequest struct {
+		ID int `param:"id" validate:"required"`
+	}
+
+	ApproveRequest struct {
+		ID    int    `param:"id" validate:"required"`
+		Level int    `json:"level" validate:"required,oneof=1 2"`
+		Note  string `json:"note"`
+	}
+
+	RejectRequest struct {
+		ID   int    `param:"id" validate:"required"`
+		Note string `json:"note" validate:"required"`
+	}
+)
+
+// * Responses
+type (
+	StatusResponseData struct {
+		Level         int                     `json:"level"`
+		WithdrawLimit float64                 `json:"withdrawLimit"`
+		Submission    *entities.KYCSubmission `json:"submission"`
+	}
+)
@@ -0,0 +1,18 @@
+package kyc
+
+import (
+	"context"
+	"io"
+
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+type Service interface {
+	Submit(ctx context.Context, req SubmitRequest) (res constants.DefaultResponse, err error)
+	GetStatus(ctx context.Context) (res constants.DefaultResponse, err error)
+	GetAll(ctx context.Context, req FindAllRequest) (res constants.DefaultResponse, err error)
+	GetDetail(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error)
+	GetDocument(ctx context.Context, req FindByIDRequest) (content io.ReadCloser, contentType string, err error)
+	Approve(ctx context.Context, req ApproveRequest) (res constants.DefaultResponse, err error)
+	Reject(ctx context.Context, req RejectRequest) (res constants.DefaultResponse, err error)
+}
@@ -0,0 +1,479 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/storage"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaxDocumentSize = 5 << 20
+	minimumAge             = 18
+)
+
+// documentTypes maps the content types accepted for identity documents to the extension they
+// are stored under, the type is sniffed from the file rather than trusted from the upload.
+var documentTypes = map[string]string{
+	"image/jpeg":      "jpg",
+	"image/png":       "png",
+	"application/pdf": "pdf",
+}
+
+type service struct {
+	db                      *gorm.DB
+	unitOfWork              repositories.UnitOfWork
+	kycSubmissionRepository repositories.KYCSubmission
+	userRepository          repositories.User
+	storageWrapper          storage.Wrapper
+	auditService            audit.Service
+	withdrawLimits          []float64
+	maxDocumentSize         int64
+}
+
+func NewService() *service {
+	return &service{}
+}
+
+func (s *service) SetDB(db *gorm.DB) *service {
+	s.db = db
+	return s
+}
+
+func (s *service) SetUnitOfWork(unitOfWork repositories.UnitOfWork) *service {
+	s.unitOfWork = unitOfWork
+	return s
+}
+
+func (s *service) SetKYCSubmissionRepository(repository repositories.KYCSubmission) *service {
+	s.kycSubmissionRepository = repository
+	return s
+}
+
+func (s *service) SetUserRepository(repository repositories.User) *service {
+	s.userRepository = repository
+	return s
+}
+
+func (s *service) SetStorageWrapper(wrapper storage.Wrapper) *service {
+	s.storageWrapper = wrapper
+	return s
+}
+
+func (s *service) SetAuditService(service audit.Service) *service {
+	s.auditService = service
+	return s
+}
+
+func (s *service) SetWithdrawLimits(limits []float64) *service {
+	s.withdrawLimits = limits
+	return s
+}
+
+func (s *service) SetMaxDocumentSize(size int64) *service {
+	s.maxDocumentSize = size
+	return s
+}
+
+func (s *service) Validate() Service {
+	if s.db == nil {
+		panic("db is nil")
+	}
+	if s.unitOfWork == nil {
+		panic("unitOfWork is nil")
+	}
+	if s.kycSubmissionRepository == nil {
+		panic("kycSubmissionRepository is nil")
+	}
+	if s.userRepository == nil {
+		panic("userRepository is nil")
+	}
+	if s.storageWrapper == nil {
+		panic("storageWrapper is nil")
+	}
+	if s.auditService == nil {
+		panic("auditService is nil")
+	}
+	if s.withdrawLimits == nil {
+		panic("withdrawLimits is nil")
+	}
+	if s.maxDocumentSize <= 0 {
+		s.maxDocumentSize = defaultMaxDocumentSize
+	}
+	return s
+}
+
+// Submit hands in the player's identity for review. Only one submission can wait for review at
+// a time, and a national id verified on another account is refused.
+func (s *service) Submit(ctx context.Context, req SubmitRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+
+	dateOfBirth, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid date of birth", "error", err)
+		err = fmt.Errorf("invalid date of birth")
+		return
+	}
+	if dateOfBirth.AddDate(minimumAge, 0, 0).After(time.Now()) {
+		slog.ErrorContext(ctx, "player is under age", "userId", userData.ID)
+		err = fmt.Errorf("player must be at least %d years old", minimumAge)
+		return
+	}
+
+	latest, err := s.kycSubmissionRepository.FindLatestByUserID(ctx, userData.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "failed to find kyc submission", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("failed to find kyc submission")
+		return
+	}
+	if err == nil && latest.Status == entities.KYCStatusPending {
+		slog.ErrorContext(ctx, "kyc submission already pending", "userId", userData.ID, "id", latest.ID)
+		err = constants.ErrKYCPending
+		return
+	}
+
+	taken, err := s.kycSubmissionRepository.ExistsApprovedNationalID(ctx, req.NationalID, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check national id", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("failed to check national id")
+		return
+	}
+	if taken {
+		slog.ErrorContext(ctx, "national id verified on another account", "userId", userData.ID)
+		err = constants.ErrNationalIDTaken
+		return
+	}
+
+	documentPath, err := s.storeDocument(ctx, userData.ID, req.Document)
+	if err != nil {
+		return
+	}
+
+	submission := entities.KYCSubmission{
+		UserID:       userData.ID,
+		FullName:     req.FullName,
+		NationalID:   req.NationalID,
+		DateOfBirth:  dateOfBirth,
+		DocumentPath: documentPath,
+		Status:       entities.KYCStatusPending,
+	}
+	err = s.kycSubmissionRepository.Create(ctx, &submission)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create kyc submission", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("failed to create kyc submission")
+		return
+	}
+	// * the identity data itself stays out of the audit log, the submission id points to it
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionSubmitKYC,
+		TargetType: "kyc_submission",
+		TargetID:   strconv.Itoa(submission.ID),
+		After:      map[string]interface{}{"userId": submission.UserID, "status": submission.Status},
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    submission,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// storeDocument checks the upload is a jpeg, png or pdf within the size limit and saves it under
+// a name that cannot collide with another player's document.
+func (s *service) storeDocument(ctx context.Context, userID int, document *multipart.FileHeader) (name string, err error) {
+	if document == nil {
+		slog.ErrorContext(ctx, "kyc document is missing", "userId", userID)
+		err = fmt.Errorf("document is required")
+		return
+	}
+	if document.Size > s.maxDocumentSize {
+		slog.ErrorContext(ctx, "kyc document too large", "userId", userID, "size", document.Size)
+		err = fmt.Errorf("document must not be larger than %d bytes", s.maxDocumentSize)
+		return
+	}
+
+	file, err := document.Open()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to open kyc document", "userId", userID, "error", err)
+		err = fmt.Errorf("failed to read document")
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		slog.ErrorContext(ctx, "failed to read kyc document", "userId", userID, "error", err)
+		err = fmt.Errorf("failed to read document")
+		return
+	}
+	head = head[:n]
+
+	extension, ok := documentTypes[http.DetectContentType(head)]
+	if !ok {
+		slog.ErrorContext(ctx, "unsupported kyc document type", "userId", userID, "contentType", http.DetectContentType(head))
+		err = fmt.Errorf("document must be a jpeg, png or pdf")
+		return
+	}
+
+	suffix, err := utils.RandomHex(8)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate document name", "error", err)
+		err = fmt.Errorf("failed to store document")
+		return
+	}
+	name = fmt.Sprintf("%d-%s.%s", userID, suffix, extension)
+
+	content := io.LimitReader(io.MultiReader(bytes.NewReader(head), file), s.maxDocumentSize)
+	err = s.storageWrapper.Put(ctx, name, content)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store kyc document", "userId", userID, "error", err)
+		err = fmt.Errorf("failed to store document")
+		return
+	}
+	return
+}
+
+func (s *service) GetStatus(ctx context.Context) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+
+	data := StatusResponseData{
+		Level:         user.KYCLevel,
+		WithdrawLimit: entities.KYCWithdrawLimit(s.withdrawLimits, user.KYCLevel),
+	}
+	latest, err := s.kycSubmissionRepository.FindLatestByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "failed to find kyc submission", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to find kyc submission")
+		return
+	}
+	if err == nil {
+		data.Submission = &latest
+	}
+	err = nil
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) GetAll(ctx context.Context, req FindAllRequest) (res constants.DefaultResponse, err error) {
+	conds := make([]utils.DBCond, 0)
+	if req.UserID > 0 {
+		conds = append(conds, utils.DBCond{Where: "user_id = ?", WhereArgs: req.UserID})
+	}
+	if req.Status != "" {
+		conds = append(conds, utils.DBCond{Where: "status = ?", WhereArgs: req.Status})
+	}
+
+	submissions, count, err := s.kycSubmissionRepository.FindAllAndCount(ctx, req.PaginationRequest, conds...)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find kyc submissions", "error", err)
+		err = fmt.Errorf("failed to find kyc submissions")
+		return
+	}
+
+	totalPages := uint(math.Ceil(float64(count) / float64(req.Limit)))
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: constants.PaginationResponseData{
+			Results: submissions,
+			PaginationData: constants.PaginationData{
+				Page:        req.Page,
+				Limit:       req.Limit,
+				TotalPages:  totalPages,
+				TotalItems:  uint(count),
+				HasNext:     req.Page < totalPages,
+				HasPrevious: req.Page > 1,
+			},
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+func (s *service) GetDetail(ctx context.Context, req FindByIDRequest) (res constants.DefaultResponse, err error) {
+	submission, err := s.kycSubmissionRepository.FindByID(ctx, req.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find kyc submission", "id", req.ID, "error", err)
+		err = fmt.Errorf("kyc submission not found")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    submission,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// GetDocument opens the submitted document for a reviewer, the caller closes content.
+func (s *service) GetDocument(ctx context.Context, req FindByIDRequest) (content io.ReadCloser, contentType string, err error) {
+	submission, err := s.kycSubmissionRepository.FindByID(ctx, req.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find kyc submission", "id", req.ID, "error", err)
+		err = fmt.Errorf("kyc submission not found")
+		return
+	}
+
+	content, err = s.storageWrapper.Open(ctx, submission.DocumentPath)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to open kyc document", "id", req.ID, "error", err)
+		err = fmt.Errorf("failed to open document")
+		return
+	}
+	contentType = "application/octet-stream"
+	for known, extension := range documentTypes {
+		if filepath.Ext(submission.DocumentPath) == "."+extension {
+			contentType = known
+		}
+	}
+	return
+}
+
+// Approve grants the player the reviewed level, which raises how much they may withdraw.
+func (s *service) Approve(ctx context.Context, req ApproveRequest) (res constants.DefaultResponse, err error) {
+	var submission entities.KYCSubmission
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		submission, err = s.review(ctx, req.ID, entities.KYCStatusApproved, req.Note)
+		if err != nil {
+			return
+		}
+
+		taken, err := s.kycSubmissionRepository.ExistsApprovedNationalID(ctx, submission.NationalID, submission.UserID)
+		if err != nil {
+			return
+		}
+		if taken {
+			return constants.ErrNationalIDTaken
+		}
+
+		user, err := s.userRepository.FindByIDForUpdate(ctx, submission.UserID)
+		if err != nil {
+			return
+		}
+		user.KYCLevel = req.Level
+		err = s.userRepository.UpdateKYCLevel(ctx, &user)
+		if err != nil {
+			return
+		}
+
+		submission.Level = req.Level
+		return s.kycSubmissionRepository.Update(ctx, &submission)
+	})
+	if err != nil {
+		err = s.reviewError(ctx, req.ID, err)
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionReviewKYC,
+		TargetType: "kyc_submission",
+		TargetID:   strconv.Itoa(submission.ID),
+		Before:     map[string]interface{}{"status": entities.KYCStatusPending},
+		After:      map[string]interface{}{"userId": submission.UserID, "status": submission.Status, "level": submission.Level, "note": submission.ReviewNote},
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    submission,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// Reject closes the submission without changing the player's level, they may submit again.
+func (s *service) Reject(ctx context.Context, req RejectRequest) (res constants.DefaultResponse, err error) {
+	var submission entities.KYCSubmission
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		submission, err = s.review(ctx, req.ID, entities.KYCStatusRejected, req.Note)
+		if err != nil {
+			return
+		}
+		return s.kycSubmissionRepository.Update(ctx, &submission)
+	})
+	if err != nil {
+		err = s.reviewError(ctx, req.ID, err)
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionReviewKYC,
+		TargetType: "kyc_submission",
+		TargetID:   strconv.Itoa(submission.ID),
+		Before:     map[string]interface{}{"status": entities.KYCStatusPending},
+		After:      map[string]interface{}{"userId": submission.UserID, "status": submission.Status, "note": submission.ReviewNote},
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    submission,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// review locks a pending submission and stamps the reviewing admin on it.
+func (s *service) review(ctx context.Context, id int, status string, note string) (submission entities.KYCSubmission, err error) {
+	submission, err = s.kycSubmissionRepository.FindByIDForUpdate(ctx, id)
+	if err != nil {
+		return
+	}
+	if submission.Status != entities.KYCStatusPending {
+		err = constants.ErrNotPending
+		return
+	}
+
+	session, _ := ctx.Value(types.String("admin")).(entities.AdminLogin)
+	now := time.Now()
+	submission.Status = status
+	submission.ReviewedBy = session.Username
+	submission.ReviewNote = note
+	submission.ReviewedAt = &now
+	return
+}
+
+func (s *service) reviewError(ctx context.Context, id int, err error) error {
+	slog.ErrorContext(ctx, "failed to review kyc submission", "id", id, "error", err)
+	for _, known := range []error{constants.ErrNotPending, constants.ErrNationalIDTaken} {
+		if errors.Is(err, known) {
+			return known
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("kyc submission not found")
+	}
+	return fmt.Errorf("failed to review kyc submission")
+}
@@ -0,0 +1,34 @@
+package ledger
+
+import (
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+)
+
+// * Requests
+type (
+	// Line is one side of a journal entry before the ledger accounts are resolved.
+	Line struct {
+		AccountCode string
+		Direction   string
+		Amount      float64
+	}
+)
+
+// * Responses
+type (
+	ReconcileResponseData struct {
+		UserID        int                     `json:"userId"`
+		AccountCode   string                  `json:"accountCode"`
+		Balance       float64                 `json:"balance"`
+		LedgerBalance float64                 `json:"ledgerBalance"`
+		Difference    float64                 `json:"difference"`
+		Reconciled    bool                    `json:"reconciled"`
+		Entries       []entities.JournalEntry `json:"entries"`
+	}
+
+	OpenBalancesResponseData struct {
+		Checked int     `json:"checked"`
+		Opened  int     `json:"opened"`
+		Amount  float64 `json:"amount"`
+	}
+)
@@ -0,0 +1,17 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+type Service interface {
+	RecordTopUp(ctx context.Context, transaction entities.Transaction) (err error)
+	RecordWithdrawal(ctx context.Context, transaction entities.Transaction) (err error)
+	RecordWithdrawalReversal(ctx context.Context, transaction entities.Transaction) (err error)
+	RecordAdjustment(ctx context.Context, transaction entities.Transaction) (err error)
+	OpenBalances(ctx context.Context) (res constants.DefaultResponse, err error)
+	Reconcile(ctx context.Context, userID int) (res constants.DefaultResponse, err error)
+}
@@ -0,0 +1,334 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"gorm.io/gorm"
+)
+
+type service struct {
+	db               *gorm.DB
+	unitOfWork       repositories.UnitOfWork
+	ledgerRepository repositories.Ledger
+	userRepository   repositories.User
+}
+
+func NewService() *service {
+	return &service{}
+}
+
+func (s *service) SetDB(db *gorm.DB) *service {
+	s.db = db
+	return s
+}
+
+func (s *service) SetUnitOfWork(unitOfWork repositories.UnitOfWork) *service {
+	s.unitOfWork = unitOfWork
+	return s
+}
+
+func (s *service) SetLedgerRepository(repository repositories.Ledger) *service {
+	s.ledgerRepository = repository
+	return s
+}
+
+func (s *service) SetUserRepository(repository repositories.User) *service {
+	s.userRepository = repository
+	return s
+}
+
+func (s *service) Validate() Service {
+	if s.db == nil {
+		panic("db is nil")
+	}
+	if s.unitOfWork == nil {
+		panic("unitOfWork is nil")
+	}
+	if s.ledgerRepository == nil {
+		panic("ledgerRepository is nil")
+	}
+	if s.userRepository == nil {
+		panic("userRepository is nil")
+	}
+	return s
+}
+
+// RecordTopUp books a settled top-up: the gross amount arrives at the provider clearing
+// account, the player's wallet is credited with the net amount and the fee goes to revenue.
+func (s *service) RecordTopUp(ctx context.Context, transaction entities.Transaction) (err error) {
+	lines := []Line{
+		{AccountCode: entities.LedgerAccountProviderClearing, Direction: entities.PostingDirectionDebit, Amount: transaction.Amount},
+		{AccountCode: entities.UserWalletAccountCode(transaction.UserID), Direction: entities.PostingDirectionCredit, Amount: transaction.Amount - transaction.Fee},
+	}
+	if transaction.Fee > 0 {
+		lines = append(lines, Line{AccountCode: entities.LedgerAccountFeeRevenue, Direction: entities.PostingDirectionCredit, Amount: transaction.Fee})
+	}
+
+	return s.post(ctx, transaction, "top up", lines)
+}
+
+// RecordWithdrawal books a completed disbursement out of the player's wallet, the fee is
+// charged on top of the amount paid out and goes to revenue.
+func (s *service) RecordWithdrawal(ctx context.Context, transaction entities.Transaction) (err error) {
+	lines := []Line{
+		{AccountCode: entities.UserWalletAccountCode(transaction.UserID), Direction: entities.PostingDirectionDebit, Amount: transaction.Amount + transaction.Fee},
+		{AccountCode: entities.LedgerAccountProviderClearing, Direction: entities.PostingDirectionCredit, Amount: transaction.Amount},
+	}
+	if transaction.Fee > 0 {
+		lines = append(lines, Line{AccountCode: entities.LedgerAccountFeeRevenue, Direction: entities.PostingDirectionCredit, Amount: transaction.Fee})
+	}
+
+	return s.post(ctx, transaction, "withdrawal", lines)
+}
+
+// RecordWithdrawalReversal books a disbursement that came back after it was completed, the
+// player gets the fee back along with the amount.
+func (s *service) RecordWithdrawalReversal(ctx context.Context, transaction entities.Transaction) (err error) {
+	lines := []Line{
+		{AccountCode: entities.LedgerAccountProviderClearing, Direction: entities.PostingDirectionDebit, Amount: transaction.Amount},
+		{AccountCode: entities.UserWalletAccountCode(transaction.UserID), Direction: entities.PostingDirectionCredit, Amount: transaction.Amount + transaction.Fee},
+	}
+	if transaction.Fee > 0 {
+		lines = append(lines, Line{AccountCode: entities.LedgerAccountFeeRevenue, Direction: entities.PostingDirectionDebit, Amount: transaction.Fee})
+	}
+
+	return s.post(ctx, transaction, "withdrawal reversal", lines)
+}
+
+// RecordAdjustment books an approved manual adjustment against the adjustment expense account.
+func (s *service) RecordAdjustment(ctx context.Context, transaction entities.Transaction) (err error) {
+	wallet := entities.UserWalletAccountCode(transaction.UserID)
+	lines := []Line{
+		{AccountCode: entities.LedgerAccountBalanceAdjustment, Direction: entities.PostingDirectionDebit, Amount: transaction.Amount},
+		{AccountCode: wallet, Direction: entities.PostingDirectionCredit, Amount: transaction.Amount},
+	}
+	if transaction.Type == entities.TransactionTypeAdjustmentOut {
+		lines = []Line{
+			{AccountCode: wallet, Direction: entities.PostingDirectionDebit, Amount: transaction.Amount},
+			{AccountCode: entities.LedgerAccountBalanceAdjustment, Direction: entities.PostingDirectionCredit, Amount: transaction.Amount},
+		}
+	}
+
+	return s.post(ctx, transaction, "balance adjustment", lines)
+}
+
+// OpenBalances books the opening balance of every wallet that has none yet, against the opening
+// balance equity account. Wallets funded before the ledger existed carry a balance no entry
+// explains, the opening entry is the part of users.balance the ledger is missing, so a wallet
+// that already has entries from after the cutover is not counted twice. It is run once when the
+// ledger goes live, running it again skips the wallets already opened.
+func (s *service) OpenBalances(ctx context.Context) (res constants.DefaultResponse, err error) {
+	userIDs, err := s.userRepository.FindAllIDs(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find users", "error", err)
+		err = fmt.Errorf("failed to find users")
+		return
+	}
+
+	data := OpenBalancesResponseData{Checked: len(userIDs)}
+	for _, userID := range userIDs {
+		var opening float64
+		err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+			// * the row lock keeps top ups and withdrawals out while the wallet is compared
+			user, err := s.userRepository.FindByIDForUpdate(ctx, userID)
+			if err != nil {
+				return
+			}
+			reference := entities.OpeningBalanceReference(user.ID)
+			opened, err := s.ledgerRepository.ExistsEntryByReference(ctx, reference)
+			if err != nil || opened {
+				return
+			}
+			ledgerBalance, err := s.walletBalance(ctx, user.ID)
+			if err != nil {
+				return
+			}
+
+			opening = user.Balance - ledgerBalance
+			if math.Abs(opening) < 0.005 {
+				opening = 0
+				return
+			}
+			wallet := entities.UserWalletAccountCode(user.ID)
+			lines := []Line{
+				{AccountCode: entities.LedgerAccountOpeningBalance, Direction: entities.PostingDirectionDebit, Amount: opening},
+				{AccountCode: wallet, Direction: entities.PostingDirectionCredit, Amount: opening},
+			}
+			if opening < 0 {
+				lines = []Line{
+					{AccountCode: wallet, Direction: entities.PostingDirectionDebit, Amount: -opening},
+					{AccountCode: entities.LedgerAccountOpeningBalance, Direction: entities.PostingDirectionCredit, Amount: -opening},
+				}
+			}
+			return s.post(ctx, entities.Transaction{UserID: user.ID, Reference: reference}, "opening balance", lines)
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to open wallet balance", "userId", userID, "error", err)
+			err = fmt.Errorf("failed to open wallet balance")
+			return
+		}
+		if opening != 0 {
+			slog.InfoContext(ctx, "opened wallet balance", "userId", userID, "amount", opening)
+			data.Opened++
+			data.Amount += opening
+		}
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// walletBalance is the balance the ledger holds for a player, zero while the wallet has no account.
+func (s *service) walletBalance(ctx context.Context, userID int) (balance float64, err error) {
+	account, err := s.ledgerRepository.FindAccountByCode(ctx, entities.UserWalletAccountCode(userID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+	debit, credit, err := s.ledgerRepository.SumPostings(ctx, account.ID)
+	// * wallet is a liability account, so its balance grows on the credit side
+	balance = credit - debit
+	return
+}
+
+func (s *service) post(ctx context.Context, transaction entities.Transaction, description string, lines []Line) (err error) {
+	var debit, credit float64
+	postings := make([]entities.Posting, 0, len(lines))
+	for _, line := range lines {
+		if line.Amount <= 0 {
+			slog.ErrorContext(ctx, "posting amount must be positive", "reference", transaction.Reference, "account", line.AccountCode, "amount", line.Amount)
+			err = fmt.Errorf("invalid ledger posting")
+			return
+		}
+
+		account, errAccount := s.account(ctx, line.AccountCode, transaction.UserID)
+		if errAccount != nil {
+			slog.ErrorContext(ctx, "failed to resolve ledger account", "account", line.AccountCode, "error", errAccount)
+			err = fmt.Errorf("failed to resolve ledger account")
+			return
+		}
+
+		switch line.Direction {
+		case entities.PostingDirectionDebit:
+			debit += line.Amount
+		case entities.PostingDirectionCredit:
+			credit += line.Amount
+		}
+		postings = append(postings, entities.Posting{
+			LedgerAccountID: account.ID,
+			Direction:       line.Direction,
+			Amount:          line.Amount,
+		})
+	}
+
+	if debit != credit {
+		slog.ErrorContext(ctx, "journal entry is not balanced", "reference", transaction.Reference, "debit", debit, "credit", credit)
+		err = fmt.Errorf("journal entry is not balanced")
+		return
+	}
+
+	entry := &entities.JournalEntry{
+		TransactionID: transaction.ID,
+		Reference:     transaction.Reference,
+		Description:   description,
+		Postings:      postings,
+	}
+	err = s.ledgerRepository.CreateEntry(ctx, entry)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create journal entry", "reference", transaction.Reference, "error", err)
+		err = fmt.Errorf("failed to create journal entry")
+		return
+	}
+	return
+}
+
+func (s *service) account(ctx context.Context, code string, userID int) (account entities.LedgerAccount, err error) {
+	account = entities.LedgerAccount{Code: code}
+	switch code {
+	case entities.LedgerAccountProviderClearing:
+		account.Name = "Payment provider clearing"
+		account.Type = entities.LedgerAccountTypeAsset
+	case entities.LedgerAccountFeeRevenue:
+		account.Name = "Admin fee revenue"
+		account.Type = entities.LedgerAccountTypeRevenue
+	case entities.LedgerAccountBalanceAdjustment:
+		account.Name = "Manual balance adjustment"
+		account.Type = entities.LedgerAccountTypeExpense
+	case entities.LedgerAccountOpeningBalance:
+		account.Name = "Opening balance"
+		account.Type = entities.LedgerAccountTypeEquity
+	default:
+		account.Name = "Player wallet"
+		account.Type = entities.LedgerAccountTypeLiability
+		account.UserID = &userID
+	}
+
+	err = s.ledgerRepository.FirstOrCreateAccount(ctx, &account)
+	return
+}
+
+func (s *service) Reconcile(ctx context.Context, userID int) (res constants.DefaultResponse, err error) {
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user by id", "error", err)
+		err = fmt.Errorf("failed to find user by id")
+		return
+	}
+
+	data := ReconcileResponseData{
+		UserID:      user.ID,
+		AccountCode: entities.UserWalletAccountCode(user.ID),
+		Balance:     user.Balance,
+		Entries:     make([]entities.JournalEntry, 0),
+	}
+
+	account, err := s.ledgerRepository.FindAccountByCode(ctx, data.AccountCode)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "failed to find ledger account", "error", err)
+		err = fmt.Errorf("failed to find ledger account")
+		return
+	}
+	if err == nil {
+		debit, credit, errSum := s.ledgerRepository.SumPostings(ctx, account.ID)
+		if errSum != nil {
+			slog.ErrorContext(ctx, "failed to sum postings", "error", errSum)
+			err = fmt.Errorf("failed to sum postings")
+			return
+		}
+		// * wallet is a liability account, so its balance grows on the credit side
+		data.LedgerBalance = credit - debit
+
+		data.Entries, err = s.ledgerRepository.FindEntriesByAccountID(ctx, account.ID)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to find journal entries", "error", err)
+			err = fmt.Errorf("failed to find journal entries")
+			return
+		}
+	}
+	err = nil
+
+	data.Difference = data.Balance - data.LedgerBalance
+	data.Reconciled = math.Abs(data.Difference) < 0.005
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+		Errors:  make([]string, 0),
+	}
+	return
+}
@@ -0,0 +1,148 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeUserRepository struct {
+	repositories.User
+	users []entities.User
+}
+
+func (r *fakeUserRepository) FindAllIDs(ctx context.Context) ([]int, error) {
+	ids := make([]int, 0, len(r.users))
+	for _, user := range r.users {
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+func (r *fakeUserRepository) FindByID(ctx context.Context, id int) (entities.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return entities.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) FindByIDForUpdate(ctx context.Context, id int) (entities.User, error) {
+	return r.FindByID(ctx, id)
+}
+
+// fakeLedgerRepository keeps accounts and journal entries in memory.
+type fakeLedgerRepository struct {
+	repositories.Ledger
+	accounts []entities.LedgerAccount
+	entries  []entities.JournalEntry
+}
+
+func (r *fakeLedgerRepository) FirstOrCreateAccount(ctx context.Context, entity *entities.LedgerAccount) error {
+	account, err := r.FindAccountByCode(ctx, entity.Code)
+	if err == nil {
+		*entity = account
+		return nil
+	}
+	entity.ID = len(r.accounts) + 1
+	r.accounts = append(r.accounts, *entity)
+	return nil
+}
+
+func (r *fakeLedgerRepository) FindAccountByCode(ctx context.Context, code string) (entities.LedgerAccount, error) {
+	for _, account := range r.accounts {
+		if account.Code == code {
+			return account, nil
+		}
+	}
+	return entities.LedgerAccount{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeLedgerRepository) CreateEntry(ctx context.Context, entity *entities.JournalEntry) error {
+	entity.ID = len(r.entries) + 1
+	r.entries = append(r.entries, *entity)
+	return nil
+}
+
+func (r *fakeLedgerRepository) ExistsEntryByReference(ctx context.Context, reference string) (bool, error) {
+	for _, entry := range r.entries {
+		if entry.Reference == reference {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeLedgerRepository) FindEntriesByAccountID(ctx context.Context, accountID int) ([]entities.JournalEntry, error) {
+	return make([]entities.JournalEntry, 0), nil
+}
+
+func (r *fakeLedgerRepository) SumPostings(ctx context.Context, accountID int) (debit float64, credit float64, err error) {
+	for _, entry := range r.entries {
+		for _, posting := range entry.Postings {
+			if posting.LedgerAccountID != accountID {
+				continue
+			}
+			if posting.Direction == entities.PostingDirectionDebit {
+				debit += posting.Amount
+			} else {
+				credit += posting.Amount
+			}
+		}
+	}
+	return
+}
+
+func TestLedgerService_OpenBalances(t *testing.T) {
+	userRepository := &fakeUserRepository{users: []entities.User{
+		// * funded before the ledger, no entries at all
+		{ID: 1, Balance: 50000},
+		// * funded before the ledger and topped up since, only the top up is booked
+		{ID: 2, Balance: 80000},
+		// * signed up after the ledger went live
+		{ID: 3, Balance: 30000},
+		{ID: 4, Balance: 0},
+	}}
+	service := NewService().
+		SetDB(&gorm.DB{}).
+		SetUnitOfWork(fakeUnitOfWork{}).
+		SetLedgerRepository(&fakeLedgerRepository{}).
+		SetUserRepository(userRepository).
+		Validate()
+
+	ctx := context.TODO()
+	require.NoError(t, service.RecordTopUp(ctx, entities.Transaction{ID: 10, UserID: 2, Reference: "TF-2", Type: "in", Amount: 20000}))
+	require.NoError(t, service.RecordTopUp(ctx, entities.Transaction{ID: 11, UserID: 3, Reference: "TF-3", Type: "in", Amount: 30000}))
+
+	for _, user := range userRepository.users {
+		res, err := service.Reconcile(ctx, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, user.ID >= 3, res.Data.(ReconcileResponseData).Reconciled, "user %d", user.ID)
+	}
+
+	res, err := service.OpenBalances(ctx)
+	require.NoError(t, err)
+	require.Equal(t, OpenBalancesResponseData{Checked: 4, Opened: 2, Amount: 110000}, res.Data)
+
+	for _, user := range userRepository.users {
+		res, err := service.Reconcile(ctx, user.ID)
+		require.NoError(t, err)
+		require.True(t, res.Data.(ReconcileResponseData).Reconciled, "user %d", user.ID)
+	}
+
+	// * a second run finds every wallet opened or already in line
+	res, err = service.OpenBalances(ctx)
+	require.NoError(t, err)
+	require.Equal(t, OpenBalancesResponseData{Checked: 4}, res.Data)
+}
@@ -0,0 +1,36 @@
+package limit
+
+import (
+	"time"
+)
+
+// * Responses
+type (
+	RemainingResponseData struct {
+		KYCLevel int              `json:"kycLevel"`
+		Limits   []RemainingLimit `json:"limits"`
+	}
+
+	// RemainingLimit is what is left of the player's limits for one transaction type, MaxAmount
+	// already takes the kyc withdrawal limit into account.
+	RemainingLimit struct {
+		Type             string              `json:"type"`
+		MinAmount        float64             `json:"minAmount"`
+		MaxAmount        float64             `json:"maxAmount"`
+		DailyCap         float64             `json:"dailyCap"`
+		DailyUsed        float64             `json:"dailyUsed"`
+		DailyRemaining   float64             `json:"dailyRemaining"`
+		MonthlyCap       float64             `json:"monthlyCap"`
+		MonthlyUsed      float64             `json:"monthlyUsed"`
+		MonthlyRemaining float64             `json:"monthlyRemaining"`
+		Velocity         []RemainingVelocity `json:"velocity"`
+	}
+
+	RemainingVelocity struct {
+		Window    string     `json:"window"`
+		Max       int64      `json:"max"`
+		Used      int64      `json:"used"`
+		Remaining int64      `json:"remaining"`
+		ResetAt   *time.Time `json:"resetAt"`
+	}
+)
@@ -0,0 +1,14 @@
+package limit
+
+import (
+	"context"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+)
+
+type Service interface {
+	Check(ctx context.Context, user entities.User, transactionType string, amount float64) (err error)
+	Record(ctx context.Context, userID int, transactionType string) (err error)
+	GetRemaining(ctx context.Context) (res constants.DefaultResponse, err error)
+}
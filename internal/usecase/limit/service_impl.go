@@ -0,0 +1,290 @@
+package limit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// limitedTypes are the player initiated movements limits apply to, adjustments are made by admins
+// and are not limited.
+var limitedTypes = []string{entities.TransactionTypeIn, entities.TransactionTypeOut}
+
+// exceededErrors are the errors Check refuses a movement with.
+var exceededErrors = []error{
+	constants.ErrAmountBelowMinimum,
+	constants.ErrAmountAboveMaximum,
+	constants.ErrKYCLimitExceeded,
+	constants.ErrDailyLimitExceeded,
+	constants.ErrMonthlyLimitExceeded,
+	constants.ErrVelocityLimitExceeded,
+}
+
+// Exceeded reports whether err is Check refusing a movement, callers hand those to the player as is.
+func Exceeded(err error) bool {
+	for _, target := range exceededErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+type service struct {
+	db                    *gorm.DB
+	transactionRepository repositories.Transaction
+	userRepository        repositories.User
+	redisWrapper          redis.Wrapper
+	policies              map[string]entities.LimitPolicy
+	velocityRules         []entities.VelocityRule
+	kycWithdrawLimits     []float64
+}
+
+func NewService() *service {
+	return &service{
+		policies: map[string]entities.LimitPolicy{},
+	}
+}
+
+func (s *service) SetDB(db *gorm.DB) *service {
+	s.db = db
+	return s
+}
+
+func (s *service) SetTransactionRepository(repository repositories.Transaction) *service {
+	s.transactionRepository = repository
+	return s
+}
+
+func (s *service) SetUserRepository(repository repositories.User) *service {
+	s.userRepository = repository
+	return s
+}
+
+func (s *service) SetRedisWrapper(wrapper redis.Wrapper) *service {
+	s.redisWrapper = wrapper
+	return s
+}
+
+// SetPolicy sets the amount limits of one transaction type.
+func (s *service) SetPolicy(transactionType string, policy entities.LimitPolicy) *service {
+	s.policies[transactionType] = policy
+	return s
+}
+
+func (s *service) SetVelocityRules(rules []entities.VelocityRule) *service {
+	s.velocityRules = rules
+	return s
+}
+
+// SetKYCWithdrawLimits sets the largest single withdrawal per kyc level, see entities.KYCWithdrawLimit.
+func (s *service) SetKYCWithdrawLimits(limits []float64) *service {
+	s.kycWithdrawLimits = limits
+	return s
+}
+
+func (s *service) Validate() Service {
+	if s.db == nil {
+		panic("db is nil")
+	}
+	if s.transactionRepository == nil {
+		panic("transactionRepository is nil")
+	}
+	if s.userRepository == nil {
+		panic("userRepository is nil")
+	}
+	if s.redisWrapper == nil {
+		panic("redisWrapper is nil")
+	}
+	for _, transactionType := range limitedTypes {
+		if _, ok := s.policies[transactionType]; !ok {
+			panic(fmt.Sprintf("limit policy for %s is not set", transactionType))
+		}
+	}
+	if s.kycWithdrawLimits == nil {
+		panic("kycWithdrawLimits is nil")
+	}
+	return s
+}
+
+// Check refuses a movement that breaks one of the player's limits. Withdrawals call it with the
+// player's row locked so concurrent ones are counted against the caps one after the other.
+func (s *service) Check(ctx context.Context, user entities.User, transactionType string, amount float64) (err error) {
+	limit := s.limit(user, transactionType)
+	if amount < limit.MinAmount {
+		return constants.ErrAmountBelowMinimum
+	}
+	if amount > limit.MaxAmount {
+		return constants.ErrAmountAboveMaximum
+	}
+	if transactionType == entities.TransactionTypeOut && amount > entities.KYCWithdrawLimit(s.kycWithdrawLimits, user.KYCLevel) {
+		return constants.ErrKYCLimitExceeded
+	}
+
+	now := time.Now()
+	daily, err := s.transactionRepository.SumAmountByUserSince(ctx, user.ID, transactionType, entities.LimitDayStart(now))
+	if err != nil {
+		return
+	}
+	if daily+amount > limit.DailyCap {
+		return constants.ErrDailyLimitExceeded
+	}
+	monthly, err := s.transactionRepository.SumAmountByUserSince(ctx, user.ID, transactionType, entities.LimitMonthStart(now))
+	if err != nil {
+		return
+	}
+	if monthly+amount > limit.MonthlyCap {
+		return constants.ErrMonthlyLimitExceeded
+	}
+
+	for _, rule := range s.rules(transactionType) {
+		count, _, errCount := s.velocityCount(ctx, entities.VelocityKey(transactionType, rule.Window, user.ID))
+		if errCount != nil {
+			return errCount
+		}
+		if count >= rule.Max {
+			return constants.ErrVelocityLimitExceeded
+		}
+	}
+	return
+}
+
+// Record counts a movement the player started against the velocity rules of its type. Callers
+// record once the movement is committed and only log a failure, the movement already happened.
+func (s *service) Record(ctx context.Context, userID int, transactionType string) (err error) {
+	for _, rule := range s.rules(transactionType) {
+		_, err = s.redisWrapper.Incr(ctx, entities.VelocityKey(transactionType, rule.Window, userID), rule.Window)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (s *service) GetRemaining(ctx context.Context) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+
+	now := time.Now()
+	data := RemainingResponseData{
+		KYCLevel: user.KYCLevel,
+		Limits:   make([]RemainingLimit, 0, len(limitedTypes)),
+	}
+	for _, transactionType := range limitedTypes {
+		limit := s.limit(user, transactionType)
+		if transactionType == entities.TransactionTypeOut {
+			limit.MaxAmount = math.Min(limit.MaxAmount, entities.KYCWithdrawLimit(s.kycWithdrawLimits, user.KYCLevel))
+		}
+		remaining := RemainingLimit{
+			Type:       transactionType,
+			MinAmount:  limit.MinAmount,
+			MaxAmount:  limit.MaxAmount,
+			DailyCap:   limit.DailyCap,
+			MonthlyCap: limit.MonthlyCap,
+			Velocity:   make([]RemainingVelocity, 0),
+		}
+		remaining.DailyUsed, err = s.transactionRepository.SumAmountByUserSince(ctx, user.ID, transactionType, entities.LimitDayStart(now))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to sum daily transactions", "userId", user.ID, "type", transactionType, "error", err)
+			err = fmt.Errorf("failed to get limits")
+			return
+		}
+		remaining.MonthlyUsed, err = s.transactionRepository.SumAmountByUserSince(ctx, user.ID, transactionType, entities.LimitMonthStart(now))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to sum monthly transactions", "userId", user.ID, "type", transactionType, "error", err)
+			err = fmt.Errorf("failed to get limits")
+			return
+		}
+		remaining.DailyRemaining = math.Max(remaining.DailyCap-remaining.DailyUsed, 0)
+		remaining.MonthlyRemaining = math.Max(remaining.MonthlyCap-remaining.MonthlyUsed, 0)
+
+		for _, rule := range s.rules(transactionType) {
+			count, ttl, errCount := s.velocityCount(ctx, entities.VelocityKey(transactionType, rule.Window, user.ID))
+			if errCount != nil {
+				slog.ErrorContext(ctx, "failed to get velocity counter", "userId", user.ID, "type", transactionType, "error", errCount)
+				err = fmt.Errorf("failed to get limits")
+				return
+			}
+			velocity := RemainingVelocity{
+				Window:    rule.Window.String(),
+				Max:       rule.Max,
+				Used:      count,
+				Remaining: max(rule.Max-count, 0),
+			}
+			if ttl > 0 {
+				resetAt := now.Add(ttl)
+				velocity.ResetAt = &resetAt
+			}
+			remaining.Velocity = append(remaining.Velocity, velocity)
+		}
+		data.Limits = append(data.Limits, remaining)
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) limit(user entities.User, transactionType string) entities.TransactionLimit {
+	return s.policies[transactionType].ForLevel(user.KYCLevel)
+}
+
+func (s *service) rules(transactionType string) (rules []entities.VelocityRule) {
+	for _, rule := range s.velocityRules {
+		if rule.Type == transactionType {
+			rules = append(rules, rule)
+		}
+	}
+	return
+}
+
+// velocityCount reads a velocity counter and how long until its window closes, a counter that
+// does not exist has nothing counted in the current window.
+func (s *service) velocityCount(ctx context.Context, key string) (count int64, ttl time.Duration, err error) {
+	ttl, err = s.redisWrapper.GetTTL(ctx, key)
+	if err != nil {
+		return
+	}
+	// * redis answers -2 for a missing key
+	if ttl == -2 {
+		return 0, 0, nil
+	}
+	// * and -1 for a key without expiry, a window that never ends is dropped so the next
+	// * increment starts a new one
+	if ttl == -1 {
+		err = s.redisWrapper.Delete(ctx, key)
+		return 0, 0, err
+	}
+
+	// * the key can expire between the two reads
+	value, err := s.redisWrapper.Get(ctx, key)
+	if errors.Is(err, goredis.Nil) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return
+	}
+	counted, _ := value.(float64)
+	count = int64(counted)
+	return
+}
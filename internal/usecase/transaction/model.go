@@ -9,7 +9,8 @@ import (
 // * Requests
 type (
 	GenerateRequest struct {
-		Amount float64 `json:"amount" validate:"required"`
+		Amount   float64 `json:"amount" validate:"required"`
+		BankCode string  `json:"bankCode"`
 	}
 
 	TopUpRequest struct {
@@ -18,7 +19,75 @@ type (
 
 	WithdrawRequest struct {
 		BankID int `json:"bankId" validate:"required"`
-		Amount int `json:"amount" validate:"required"`
+		Amount int `json:"amount" validate:"required,gt=0"`
+	}
+
+	// QuoteRequest prices a top up or a withdrawal before the player confirms it, BankID is the
+	// payout account of a withdrawal and BankCode the virtual account bank of a top up.
+	QuoteRequest struct {
+		Type     string  `json:"type" validate:"required,oneof=in out"`
+		Amount   float64 `json:"amount" validate:"required,gt=0"`
+		BankID   int     `json:"bankId" validate:"required_if=Type out"`
+		BankCode string  `json:"bankCode"`
+	}
+
+	// VACallbackRequest is the provider's virtual account payment callback.
+	VACallbackRequest struct {
+		CallbackID               string    `json:"-"`
+		ID                       string    `json:"id" validate:"required"`
+		PaymentID                string    `json:"payment_id"`
+		CallbackVirtualAccountID string    `json:"callback_virtual_account_id"`
+		ExternalID               string    `json:"external_id" validate:"required"`
+		BankCode                 string    `json:"bank_code"`
+		AccountNumber            string    `json:"account_number"`
+		Amount                   float64   `json:"amount" validate:"required"`
+		TransactionTimestamp     time.Time `json:"transaction_timestamp"`
+	}
+
+	// DisbursementCallbackRequest is the provider's disbursement status callback.
+	DisbursementCallbackRequest struct {
+		CallbackID        string `json:"-"`
+		ID                string `json:"id" validate:"required"`
+		ExternalID        string `json:"external_id" validate:"required"`
+		BankCode          string `json:"bank_code"`
+		AccountHolderName string `json:"account_holder_name"`
+		Amount            int    `json:"amount"`
+		Status            string `json:"status" validate:"required,oneof=COMPLETED FAILED"`
+		FailureCode       string `json:"failure_code"`
+	}
+
+	// MidtransNotificationRequest is a Core API payment notification. Only the order id is acted
+	// on, the status is read back from midtrans.
+	MidtransNotificationRequest struct {
+		CallbackID        string `json:"-"`
+		TransactionID     string `json:"transaction_id" validate:"required"`
+		OrderID           string `json:"order_id" validate:"required"`
+		StatusCode        string `json:"status_code"`
+		GrossAmount       string `json:"gross_amount"`
+		TransactionStatus string `json:"transaction_status" validate:"required"`
+		PaymentType       string `json:"payment_type"`
+		FraudStatus       string `json:"fraud_status"`
+	}
+
+	// SettleManualRequest is an operator's outcome for a manual bank transfer.
+	SettleManualRequest struct {
+		Reference string `param:"reference" validate:"required"`
+		Status    string `json:"status" validate:"required,oneof=COMPLETED FAILED"`
+		Note      string `json:"note"`
+	}
+
+	FindAllRequest struct {
+		constants.PaginationRequest
+		Type      string  `query:"type" validate:"omitempty,oneof=in out adjustment_in adjustment_out"`
+		Status    string  `query:"status" validate:"omitempty,oneof=PENDING COMPLETED EXPIRED FAILED REVERSED"`
+		StartDate string  `query:"startDate" validate:"omitempty,datetime=2006-01-02"`
+		EndDate   string  `query:"endDate" validate:"omitempty,datetime=2006-01-02"`
+		MinAmount float64 `query:"minAmount" validate:"omitempty,gte=0"`
+		MaxAmount float64 `query:"maxAmount" validate:"omitempty,gte=0"`
+	}
+
+	FindByReferenceRequest struct {
+		Reference string `param:"reference" validate:"required"`
 	}
 )
 
@@ -29,6 +98,24 @@ type (
 		ReferenceID string    `json:"referenceId"`
 		ExpiredAt   time.Time `json:"expiredAt"`
 	}
+
+	// QuoteResponseData is what the player is charged, Total is debited from the wallet for a
+	// withdrawal and paid into the virtual account for a top up, which credits Amount minus Fee.
+	QuoteResponseData struct {
+		Type      string  `json:"type"`
+		Amount    float64 `json:"amount"`
+		Fee       float64 `json:"fee"`
+		FeeRuleID *int    `json:"feeRuleId"`
+		Total     float64 `json:"total"`
+		Credited  float64 `json:"credited"`
+	}
+
+	ExpireTopUpsResponseData struct {
+		Found   int `json:"found"`
+		Expired int `json:"expired"`
+		Settled int `json:"settled"`
+	}
+
 	DefaultResponse struct {
 		Status  string      `json:"status"`
 		Message string      `json:"message"`
@@ -10,4 +10,13 @@ type Service interface {
 	Generate(ctx context.Context, req GenerateRequest) (res constants.DefaultResponse, err error)
 	TopUp(ctx context.Context, reference string) (res constants.DefaultResponse, err error)
 	Withdraw(ctx context.Context, req WithdrawRequest) (res constants.DefaultResponse, err error)
+	Quote(ctx context.Context, req QuoteRequest) (res constants.DefaultResponse, err error)
+	CallbackVA(ctx context.Context, req VACallbackRequest) (res constants.DefaultResponse, err error)
+	CallbackDisbursement(ctx context.Context, req DisbursementCallbackRequest) (res constants.DefaultResponse, err error)
+	CallbackMidtrans(ctx context.Context, req MidtransNotificationRequest) (res constants.DefaultResponse, err error)
+	SettleManual(ctx context.Context, req SettleManualRequest) (res constants.DefaultResponse, err error)
+	ExpireWithdraw(ctx context.Context, reference string) (res constants.DefaultResponse, err error)
+	ExpireTopUps(ctx context.Context) (res constants.DefaultResponse, err error)
+	FindAll(ctx context.Context, req FindAllRequest) (res constants.DefaultResponse, err error)
+	FindByReference(ctx context.Context, req FindByReferenceRequest) (res constants.DefaultResponse, err error)
 }
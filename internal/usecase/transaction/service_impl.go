@@ -2,8 +2,11 @@ package transaction
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"time"
 
 	"github.com/RichardKnop/machinery/v1"
@@ -16,17 +19,28 @@ import (
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 	"github.com/danielpnjt/speed-engine/internal/pkg/types"
 	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
+	"github.com/danielpnjt/speed-engine/internal/usecase/fee"
+	"github.com/danielpnjt/speed-engine/internal/usecase/ledger"
+	"github.com/danielpnjt/speed-engine/internal/usecase/limit"
 	"gorm.io/gorm"
 )
 
 type service struct {
-	db                    *gorm.DB
-	transactionRepository repositories.Transaction
-	userRepository        repositories.User
-	bankRepository        repositories.Bank
-	redisWrapper          redis.Wrapper
-	paymentWrapper        payment.Wrapper
-	worker                *machinery.Server
+	db                        *gorm.DB
+	unitOfWork                repositories.UnitOfWork
+	transactionRepository     repositories.Transaction
+	balanceHoldRepository     repositories.BalanceHold
+	paymentCallbackRepository repositories.PaymentCallback
+	userRepository            repositories.User
+	bankRepository            repositories.Bank
+	redisWrapper              redis.Wrapper
+	paymentWrapper            payment.Wrapper
+	worker                    *machinery.Server
+	ledgerService             ledger.Service
+	auditService              audit.Service
+	feeService                fee.Service
+	limitService              limit.Service
 }
 
 func NewService() *service {
@@ -38,11 +52,26 @@ func (s *service) SetDB(db *gorm.DB) *service {
 	return s
 }
 
+func (s *service) SetUnitOfWork(unitOfWork repositories.UnitOfWork) *service {
+	s.unitOfWork = unitOfWork
+	return s
+}
+
 func (s *service) SetTransactionRepository(repository repositories.Transaction) *service {
 	s.transactionRepository = repository
 	return s
 }
 
+func (s *service) SetBalanceHoldRepository(repository repositories.BalanceHold) *service {
+	s.balanceHoldRepository = repository
+	return s
+}
+
+func (s *service) SetPaymentCallbackRepository(repository repositories.PaymentCallback) *service {
+	s.paymentCallbackRepository = repository
+	return s
+}
+
 func (s *service) SetUserRepository(repository repositories.User) *service {
 	s.userRepository = repository
 	return s
@@ -68,13 +97,42 @@ func (s *service) SetWorker(worker *machinery.Server) *service {
 	return s
 }
 
+func (s *service) SetLedgerService(service ledger.Service) *service {
+	s.ledgerService = service
+	return s
+}
+
+func (s *service) SetAuditService(service audit.Service) *service {
+	s.auditService = service
+	return s
+}
+
+func (s *service) SetFeeService(service fee.Service) *service {
+	s.feeService = service
+	return s
+}
+
+func (s *service) SetLimitService(service limit.Service) *service {
+	s.limitService = service
+	return s
+}
+
 func (s *service) Validate() Service {
 	if s.db == nil {
 		panic("db is nil")
 	}
+	if s.unitOfWork == nil {
+		panic("unitOfWork is nil")
+	}
 	if s.transactionRepository == nil {
 		panic("transactionRepository is nil")
 	}
+	if s.balanceHoldRepository == nil {
+		panic("balanceHoldRepository is nil")
+	}
+	if s.paymentCallbackRepository == nil {
+		panic("paymentCallbackRepository is nil")
+	}
 	if s.userRepository == nil {
 		panic("userRepository is nil")
 	}
@@ -90,45 +148,119 @@ func (s *service) Validate() Service {
 	if s.worker == nil {
 		panic("worker is nil")
 	}
+	if s.ledgerService == nil {
+		panic("ledgerService is nil")
+	}
+	if s.auditService == nil {
+		panic("auditService is nil")
+	}
+	if s.feeService == nil {
+		panic("feeService is nil")
+	}
+	if s.limitService == nil {
+		panic("limitService is nil")
+	}
 	return s
 }
 
 func (s *service) Generate(ctx context.Context, req GenerateRequest) (res constants.DefaultResponse, err error) {
 	userData, _ := ctx.Value(types.String("user")).(entities.Login)
-	reference, err := utils.GeneratePaymentRef(userData.Username)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user by id", "error", err)
+		err = fmt.Errorf("failed to find user by id")
+		return
+	}
+	if !user.CanMoveMoney() {
+		slog.ErrorContext(ctx, "top up refused for inactive account", "userId", user.ID, "status", user.Status)
+		err = constants.ErrAccountInactive
+		return
+	}
+	if user.EmailVerificationRequired(emailPolicy(), false) {
+		slog.ErrorContext(ctx, "top up refused until email is verified", "userId", user.ID)
+		err = constants.ErrEmailNotVerified
+		return
+	}
+	// * top ups are not serialised per player, two generated at once may both fit under a cap
+	err = s.limitService.Check(ctx, user, entities.TransactionTypeIn, req.Amount)
+	if limit.Exceeded(err) {
+		slog.ErrorContext(ctx, "top up refused by limits", "userId", user.ID, "amount", req.Amount, "error", err)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to check limits", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to check limits")
+		return
+	}
+
+	reference, err := utils.GeneratePaymentRef()
 	if err != nil {
-		slog.ErrorContext(ctx, "can't generate payment ref", err)
+		slog.ErrorContext(ctx, "can't generate payment ref", "error", err)
 		err = fmt.Errorf("can't generate payment ref")
 		return
 	}
 
+	bankCode := req.BankCode
+	if bankCode == "" {
+		bankCode = config.GetString("payment.vaBankCode")
+	}
+	charge, err := s.feeService.Calculate(ctx, entities.FeeOperationTopUp, bankCode, req.Amount)
+	if err != nil {
+		slog.ErrorContext(ctx, "can't calculate top up fee", "error", err)
+		err = fmt.Errorf("can't calculate fee")
+		return
+	}
+	// * the fee comes out of the top up, nothing would be left to credit
+	if charge.Fee >= req.Amount {
+		slog.ErrorContext(ctx, "top up amount does not cover the fee", "userId", user.ID, "amount", req.Amount, "fee", charge.Fee)
+		err = constants.ErrAmountBelowFee
+		return
+	}
+
+	expirationDate := time.Now().Add(config.GetDuration("payment.vaExpiry")).UTC()
 	vaRequest := payment.CreateVARequest{
 		ExternalID:     reference,
+		BankCode:       bankCode,
+		Name:           userData.Name,
+		IsClosed:       true,
+		IsSingleUse:    true,
 		ExpectedAmount: req.Amount,
+		ExpirationDate: &expirationDate,
 	}
 	va, err := s.paymentWrapper.CreateVA(ctx, vaRequest)
 	if err != nil {
-		slog.ErrorContext(ctx, "can't generate va", err)
+		slog.ErrorContext(ctx, "can't generate va", "error", err)
 		err = fmt.Errorf("can't generate va")
 		return
 	}
+	utils.MarkSideEffect(ctx)
 
+	if va.Data.ExpirationDate != nil {
+		expirationDate = *va.Data.ExpirationDate
+	}
 	entryData := &entities.Transaction{
-		UserID:    userData.ID,
-		BankID:    0,
-		Amount:    va.Data.ExpectedAmount,
-		Type:      "in",
-		Reference: va.Data.ExternalID,
-		Status:    va.Data.Status,
-		ExpiredAt: *va.Data.ExpirationDate,
+		UserID:            userData.ID,
+		BankID:            0,
+		Amount:            va.Data.ExpectedAmount,
+		Fee:               charge.Fee,
+		FeeRuleID:         charge.RuleID,
+		Type:              "in",
+		Reference:         va.Data.ExternalID,
+		Provider:          va.Provider,
+		ProviderReference: va.Data.ID,
+		Status:            entities.TransactionStatusPending,
+		ExpiredAt:         expirationDate,
 	}
 
 	err = s.transactionRepository.Create(ctx, entryData)
 	if err != nil {
-		slog.ErrorContext(ctx, "can't generate transfer", err)
+		slog.ErrorContext(ctx, "can't generate transfer", "error", err)
 		err = fmt.Errorf("can't generate transfer")
 		return
 	}
+	if errRecord := s.limitService.Record(ctx, user.ID, entities.TransactionTypeIn); errRecord != nil {
+		slog.ErrorContext(ctx, "failed to record top up against velocity limits", "userId", user.ID, "error", errRecord)
+	}
 
 	eta := time.Now().UTC().Add(config.GetDuration("worker.speed_engine.delayFirstRetry"))
 	signature := &tasks.Signature{
@@ -143,7 +275,8 @@ func (s *service) Generate(ctx context.Context, req GenerateRequest) (res consta
 
 	_, err = s.worker.SendTaskWithContext(ctx, signature)
 	if err != nil {
-		slog.ErrorContext(ctx, "send task worker", err)
+		slog.ErrorContext(ctx, "send task worker", "error", err)
+		err = nil
 	}
 
 	res = constants.DefaultResponse{
@@ -156,25 +289,38 @@ func (s *service) Generate(ctx context.Context, req GenerateRequest) (res consta
 }
 
 func (s *service) TopUp(ctx context.Context, reference string) (res constants.DefaultResponse, err error) {
-	userData, _ := ctx.Value(types.String("user")).(entities.Login)
 	transaction, err := s.transactionRepository.FindByReference(ctx, reference)
 	if err != nil {
-		slog.ErrorContext(ctx, "can't get transaction", err)
+		slog.ErrorContext(ctx, "can't get transaction", "error", err)
 		err = fmt.Errorf("can't get transaction")
 		return
 	}
+	// * settled by a payment callback or expired already, polling stops here
+	if transaction.Status != entities.TransactionStatusPending {
+		return
+	}
 
 	topUpRequest := payment.TopUpRequest{
+		Provider:   transaction.Provider,
+		ID:         transaction.ProviderReference,
 		ExternalID: reference,
 	}
 	topUp, err := s.paymentWrapper.TopUp(ctx, topUpRequest)
 	if err != nil {
-		slog.ErrorContext(ctx, "can't generate va", err)
+		slog.ErrorContext(ctx, "can't generate va", "error", err)
 		err = fmt.Errorf("can't generate va")
 		return
 	}
 
-	if topUp.Data.Status != "COMPLETED" {
+	switch topUp.Data.Status {
+	case entities.TransactionStatusExpired, entities.TransactionStatusFailed:
+		err = s.transactionRepository.UpdateStatus(ctx, &transaction, topUp.Data.Status, entities.TransactionActorSystem)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to update transaction status", "reference", reference, "status", topUp.Data.Status, "error", err)
+			err = fmt.Errorf("failed to update transaction status")
+		}
+		return
+	case entities.TransactionStatusPending:
 		eta := time.Now().UTC().Add(config.GetDuration("worker.speed_engine.delayFirstRetry"))
 		signature := &tasks.Signature{
 			Name: "enqueue-speed_engine-topup",
@@ -188,31 +334,14 @@ func (s *service) TopUp(ctx context.Context, reference string) (res constants.De
 
 		_, err = s.worker.SendTaskWithContext(ctx, signature)
 		if err != nil {
-			slog.ErrorContext(ctx, "send task worker", err)
+			slog.ErrorContext(ctx, "send task worker", "error", err)
 		}
 		return
 	}
 
-	transaction.Status = topUp.Data.Status
-	err = s.transactionRepository.Create(ctx, &transaction)
-	if err != nil {
-		slog.ErrorContext(ctx, "can't create transfer", err)
-		err = fmt.Errorf("can't create transfer")
-		return
-	}
-
-	newUser, err := s.userRepository.FindByID(ctx, userData.ID)
+	err = s.settleTopUp(ctx, reference, entities.TransactionActorSystem, true)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to find user", err)
-		err = fmt.Errorf("failed to find user")
-		return
-	}
-
-	adminFee := float64(4500)
-	newUser.Balance += transaction.Amount - adminFee
-	err = s.userRepository.Create(ctx, &newUser)
-	if err != nil {
-		slog.ErrorContext(ctx, "failed to modify balance", err)
+		slog.ErrorContext(ctx, "failed to settle top up", "reference", reference, "error", err)
 		err = fmt.Errorf("failed to modify balance")
 		return
 	}
@@ -228,63 +357,383 @@ func (s *service) TopUp(ctx context.Context, reference string) (res constants.De
 
 func (s *service) Withdraw(ctx context.Context, req WithdrawRequest) (res constants.DefaultResponse, err error) {
 	userData, _ := ctx.Value(types.String("user")).(entities.Login)
-	reference, err := utils.GeneratePaymentRef(userData.Username)
+	reference, err := utils.GeneratePaymentRef()
 	if err != nil {
-		slog.ErrorContext(ctx, "can't generate payment ref", err)
+		slog.ErrorContext(ctx, "can't generate payment ref", "error", err)
 		err = fmt.Errorf("can't generate payment ref")
 		return
 	}
 
-	bank, err := s.bankRepository.FindByID(ctx, req.BankID)
+	// * only accounts the player still holds can receive a payout
+	bank, err := s.bankRepository.FindByIDAndUserID(ctx, req.BankID, userData.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "bank not found for user", "userId", userData.ID, "bankId", req.BankID)
+		err = constants.ErrBankNotFound
+		return
+	}
 	if err != nil {
-		slog.ErrorContext(ctx, "can't get bank", err)
+		slog.ErrorContext(ctx, "can't get bank", "error", err)
 		err = fmt.Errorf("can't get bank")
 		return
 	}
+	if !bank.Verified() {
+		slog.ErrorContext(ctx, "bank account not verified", "userId", userData.ID, "bankId", bank.ID)
+		err = constants.ErrBankNotVerified
+		return
+	}
+	charge, err := s.feeService.Calculate(ctx, entities.FeeOperationWithdraw, bank.BankCode, float64(req.Amount))
+	if err != nil {
+		slog.ErrorContext(ctx, "can't calculate withdrawal fee", "error", err)
+		err = fmt.Errorf("can't calculate fee")
+		return
+	}
+
+	// * the fee is charged on top of the payout, the hold covers both
+	transaction := entities.Transaction{
+		UserID:    userData.ID,
+		BankID:    bank.ID,
+		Amount:    float64(req.Amount),
+		Fee:       charge.Fee,
+		FeeRuleID: charge.RuleID,
+		Type:      "out",
+		Reference: reference,
+		Status:    entities.TransactionStatusPending,
+	}
+	hold := entities.BalanceHold{
+		UserID:    userData.ID,
+		Amount:    transaction.Amount + transaction.Fee,
+		Status:    entities.BalanceHoldStatusHeld,
+		ExpiresAt: time.Now().Add(config.GetDuration("worker.speed_engine.withdrawHoldTimeout")),
+	}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		user, err := s.userRepository.FindByIDForUpdate(ctx, userData.ID)
+		if err != nil {
+			return
+		}
+		if !user.CanMoveMoney() {
+			return constants.ErrAccountInactive
+		}
+		if user.EmailVerificationRequired(emailPolicy(), true) {
+			return constants.ErrEmailNotVerified
+		}
+		err = s.limitService.Check(ctx, user, entities.TransactionTypeOut, transaction.Amount)
+		if err != nil {
+			return
+		}
+		if user.AvailableBalance() < hold.Amount {
+			return constants.ErrInsufficientFunds
+		}
+
+		err = s.transactionRepository.Create(ctx, &transaction)
+		if err != nil {
+			return
+		}
+
+		hold.TransactionID = transaction.ID
+		err = s.balanceHoldRepository.Create(ctx, &hold)
+		if err != nil {
+			return
+		}
+
+		user.HeldBalance += hold.Amount
+		return s.userRepository.UpdateBalance(ctx, &user)
+	})
+	if errors.Is(err, constants.ErrInsufficientFunds) {
+		slog.ErrorContext(ctx, "insufficient balance for withdrawal", "userId", userData.ID, "amount", req.Amount)
+		return
+	}
+	if errors.Is(err, constants.ErrAccountInactive) {
+		slog.ErrorContext(ctx, "withdrawal refused for inactive account", "userId", userData.ID)
+		return
+	}
+	if errors.Is(err, constants.ErrEmailNotVerified) {
+		slog.ErrorContext(ctx, "withdrawal refused until email is verified", "userId", userData.ID)
+		return
+	}
+	if limit.Exceeded(err) {
+		slog.ErrorContext(ctx, "withdrawal refused by limits", "userId", userData.ID, "amount", req.Amount, "error", err)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to hold balance", "error", err)
+		err = fmt.Errorf("failed to hold balance")
+		return
+	}
+	utils.MarkSideEffect(ctx)
+	if errRecord := s.limitService.Record(ctx, userData.ID, entities.TransactionTypeOut); errRecord != nil {
+		slog.ErrorContext(ctx, "failed to record withdrawal against velocity limits", "userId", userData.ID, "error", errRecord)
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionWithdraw,
+		TargetType: "transaction",
+		TargetID:   transaction.Reference,
+		After:      transaction,
+	})
 
 	withdrawRequest := payment.WithdrawRequest{
 		ExternalID:        reference,
-		BankCode:          bank.BankName,
+		BankCode:          bank.BankCode,
 		AccountHolderName: bank.AccountName,
 		AccountNumber:     bank.AccountNumber,
 		Amount:            req.Amount,
 		Description:       "disbursement to user",
 	}
 
+	// * only a refusal or a failed status proves nothing was paid out, anything else keeps the hold
 	withdraw, err := s.paymentWrapper.Withdraw(ctx, withdrawRequest)
+	if payment.Rejected(err) || (err == nil && withdraw.Data.Status == entities.TransactionStatusFailed) {
+		slog.ErrorContext(ctx, "failed to disburse withdrawal", "reference", reference, "error", err)
+		if errRelease := s.releaseWithdraw(ctx, reference, "disbursement failed", entities.TransactionActorSystem); errRelease != nil {
+			slog.ErrorContext(ctx, "failed to release withdrawal hold", "reference", reference, "error", errRelease)
+		}
+		err = fmt.Errorf("failed to process withdrawal")
+		return
+	}
 	if err != nil {
-		slog.ErrorContext(ctx, "can't generate va", err)
-		err = fmt.Errorf("can't generate va")
+		slog.ErrorContext(ctx, "disbursement outcome unknown, keeping the hold", "reference", reference, "provider", withdraw.Provider, "error", err)
+		err = nil
+	}
+
+	transaction.Provider = withdraw.Provider
+	transaction.ProviderReference = withdraw.Data.ID
+	err = s.transactionRepository.Update(ctx, &transaction)
+	if err != nil {
+		slog.ErrorContext(ctx, "can't update transfer", "error", err)
+	}
+
+	if withdraw.Data.Status == entities.TransactionStatusCompleted {
+		err = s.settleWithdraw(ctx, reference, entities.UserActor(userData.ID))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to settle withdrawal", "reference", reference, "error", err)
+			err = fmt.Errorf("failed to settle withdrawal")
+			return
+		}
+	} else {
+		// * the provider is still processing, ask it again once the hold expires
+		s.scheduleWithdrawCheck(ctx, reference, hold.ExpiresAt)
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// Quote prices a movement the way Generate and Withdraw will charge it, so the player sees the
+// fee before confirming.
+func (s *service) Quote(ctx context.Context, req QuoteRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+
+	operation := entities.FeeOperationTopUp
+	bankCode := req.BankCode
+	if req.Type == "out" {
+		operation = entities.FeeOperationWithdraw
+		var bank entities.Bank
+		bank, err = s.bankRepository.FindByIDAndUserID(ctx, req.BankID, userData.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.ErrorContext(ctx, "bank not found for user", "userId", userData.ID, "bankId", req.BankID)
+			err = constants.ErrBankNotFound
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "can't get bank", "error", err)
+			err = fmt.Errorf("can't get bank")
+			return
+		}
+		bankCode = bank.BankCode
+	} else if bankCode == "" {
+		bankCode = config.GetString("payment.vaBankCode")
+	}
+
+	charge, err := s.feeService.Calculate(ctx, operation, bankCode, req.Amount)
+	if err != nil {
+		slog.ErrorContext(ctx, "can't calculate fee", "error", err)
+		err = fmt.Errorf("can't calculate fee")
 		return
 	}
 
-	transaction := entities.Transaction{
-		UserID:    userData.ID,
-		Amount:    float64(withdraw.Data.Amount),
-		Type:      "out",
-		Reference: reference,
-		Status:    withdraw.Data.Status,
+	data := QuoteResponseData{
+		Type:      req.Type,
+		Amount:    req.Amount,
+		Fee:       charge.Fee,
+		FeeRuleID: charge.RuleID,
+		Total:     req.Amount + charge.Fee,
+		Credited:  req.Amount,
+	}
+	if req.Type == "in" {
+		if charge.Fee >= req.Amount {
+			err = constants.ErrAmountBelowFee
+			return
+		}
+		data.Total = req.Amount
+		data.Credited = req.Amount - charge.Fee
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// FindAll lists the logged in player's transactions, filtered and paginated.
+func (s *service) FindAll(ctx context.Context, req FindAllRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+
+	conds := []utils.DBCond{
+		{Where: "user_id = ?", WhereArgs: userData.ID},
+	}
+	if req.Type != "" {
+		conds = append(conds, utils.DBCond{Where: "type = ?", WhereArgs: req.Type})
+	}
+	if req.Status != "" {
+		conds = append(conds, utils.DBCond{Where: "status = ?", WhereArgs: req.Status})
+	}
+	if req.StartDate != "" {
+		startDate, _ := time.ParseInLocation(time.DateOnly, req.StartDate, time.Local)
+		conds = append(conds, utils.DBCond{Where: "created_at >= ?", WhereArgs: startDate})
+	}
+	if req.EndDate != "" {
+		// * the end date is inclusive, the range runs up to the start of the next day
+		endDate, _ := time.ParseInLocation(time.DateOnly, req.EndDate, time.Local)
+		conds = append(conds, utils.DBCond{Where: "created_at < ?", WhereArgs: endDate.AddDate(0, 0, 1)})
+	}
+	if req.MinAmount > 0 {
+		conds = append(conds, utils.DBCond{Where: "amount >= ?", WhereArgs: req.MinAmount})
 	}
-	err = s.transactionRepository.Create(ctx, &transaction)
+	if req.MaxAmount > 0 {
+		conds = append(conds, utils.DBCond{Where: "amount <= ?", WhereArgs: req.MaxAmount})
+	}
+
+	transactions, count, err := s.transactionRepository.FindAllAndCount(ctx, req.PaginationRequest, conds...)
 	if err != nil {
-		slog.ErrorContext(ctx, "can't create transfer", err)
-		err = fmt.Errorf("can't create transfer")
+		slog.ErrorContext(ctx, "failed to find transactions", "error", err)
+		err = fmt.Errorf("failed to find transactions")
 		return
 	}
 
-	newUser, err := s.userRepository.FindByID(ctx, userData.ID)
+	totalPages := uint(math.Ceil(float64(count) / float64(req.Limit)))
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: constants.PaginationResponseData{
+			Results: transactions,
+			PaginationData: constants.PaginationData{
+				Page:        req.Page,
+				Limit:       req.Limit,
+				TotalPages:  totalPages,
+				TotalItems:  uint(count),
+				HasNext:     req.Page < totalPages,
+				HasPrevious: req.Page > 1,
+			},
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+// FindByReference returns one of the logged in player's transactions. Another player's
+// reference is reported as not found rather than forbidden.
+func (s *service) FindByReference(ctx context.Context, req FindByReferenceRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+
+	transaction, err := s.transactionRepository.FindByReference(ctx, req.Reference)
+	if err == nil && transaction.UserID != userData.ID {
+		err = gorm.ErrRecordNotFound
+	}
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to modify balance", err)
-		err = fmt.Errorf("failed to modify balance")
+		slog.ErrorContext(ctx, "failed to find transaction by reference", "reference", req.Reference, "error", err)
+		err = fmt.Errorf("transaction not found")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    transaction,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// CallbackVA settles a top up from the provider's virtual account payment callback.
+// Redelivered callbacks are recognised by their callback id and acknowledged without effect.
+// A payment landing on a top up that expired already is credited once the provider confirms it.
+func (s *service) CallbackVA(ctx context.Context, req VACallbackRequest) (res constants.DefaultResponse, err error) {
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		isNew, err := s.recordCallback(ctx, req.CallbackID, "va", req.ExternalID, req)
+		if err != nil || !isNew {
+			return
+		}
+
+		transaction, err := s.callbackTransaction(ctx, req.ExternalID)
+		if err != nil {
+			return
+		}
+		if transaction.Type != "in" || transaction.Amount != req.Amount {
+			slog.ErrorContext(ctx, "va callback does not match transaction", "reference", req.ExternalID, "amount", req.Amount, "expected", transaction.Amount)
+			return constants.ErrCallbackMismatch
+		}
+		confirmed := false
+		if transaction.CanSettleLate() {
+			err = s.verifyLatePayment(ctx, transaction, req.PaymentID)
+			if err != nil {
+				return
+			}
+			confirmed = true
+		}
+
+		return s.settleTopUp(ctx, req.ExternalID, entities.TransactionActorCallback, confirmed)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to process va callback", "reference", req.ExternalID, "error", err)
+		if !permanentCallbackError(err) {
+			err = fmt.Errorf("failed to process va callback")
+		}
 		return
 	}
 
-	newUser.Balance -= transaction.Amount
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// CallbackDisbursement settles or releases a withdrawal from the provider's disbursement callback.
+func (s *service) CallbackDisbursement(ctx context.Context, req DisbursementCallbackRequest) (res constants.DefaultResponse, err error) {
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		isNew, err := s.recordCallback(ctx, req.CallbackID, "disbursement", req.ExternalID, req)
+		if err != nil || !isNew {
+			return
+		}
 
-	err = s.userRepository.Create(ctx, &newUser)
+		switch req.Status {
+		case entities.TransactionStatusCompleted:
+			return s.settleWithdraw(ctx, req.ExternalID, entities.TransactionActorCallback)
+		case entities.TransactionStatusFailed:
+			transaction, err := s.transactionRepository.FindByReference(ctx, req.ExternalID)
+			if err != nil {
+				return err
+			}
+			// * the bank bounced a disbursement the provider had already reported as completed
+			if transaction.Status == entities.TransactionStatusCompleted {
+				return s.reverseWithdraw(ctx, req.ExternalID, entities.TransactionActorCallback)
+			}
+			return s.releaseWithdraw(ctx, req.ExternalID, fmt.Sprintf("disbursement failed: %s", req.FailureCode), entities.TransactionActorCallback)
+		}
+		return
+	})
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to create new user", err)
-		err = fmt.Errorf("failed to create new user")
+		slog.ErrorContext(ctx, "failed to process disbursement callback", "reference", req.ExternalID, "error", err)
+		err = fmt.Errorf("failed to process disbursement callback")
 		return
 	}
 
@@ -296,3 +745,480 @@ func (s *service) Withdraw(ctx context.Context, req WithdrawRequest) (res consta
 	}
 	return
 }
+
+// CallbackMidtrans handles a Core API payment notification. The notification only tells that a
+// charge changed, its status is read back from midtrans before the top up is settled or closed.
+func (s *service) CallbackMidtrans(ctx context.Context, req MidtransNotificationRequest) (res constants.DefaultResponse, err error) {
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		isNew, err := s.recordCallback(ctx, req.CallbackID, "midtrans", req.OrderID, req)
+		if err != nil || !isNew {
+			return
+		}
+
+		transaction, err := s.callbackTransaction(ctx, req.OrderID)
+		if err != nil {
+			return
+		}
+		if transaction.Type != "in" {
+			slog.ErrorContext(ctx, "midtrans notification for a non top up transaction", "reference", req.OrderID, "type", transaction.Type)
+			return constants.ErrCallbackMismatch
+		}
+
+		topUpRequest := payment.TopUpRequest{
+			Provider:   transaction.Provider,
+			ID:         transaction.ProviderReference,
+			ExternalID: req.OrderID,
+		}
+		topUp, err := s.paymentWrapper.TopUp(ctx, topUpRequest)
+		if err != nil {
+			return
+		}
+
+		switch topUp.Data.Status {
+		case entities.TransactionStatusCompleted:
+			if topUp.Data.ExpectedAmount != transaction.Amount {
+				slog.ErrorContext(ctx, "midtrans charge does not match transaction", "reference", req.OrderID, "amount", topUp.Data.ExpectedAmount, "expected", transaction.Amount)
+				return constants.ErrCallbackMismatch
+			}
+			return s.settleTopUp(ctx, req.OrderID, entities.TransactionActorCallback, true)
+		case entities.TransactionStatusExpired, entities.TransactionStatusFailed:
+			if transaction.Status != entities.TransactionStatusPending {
+				return
+			}
+			return s.transactionRepository.UpdateStatus(ctx, &transaction, topUp.Data.Status, entities.TransactionActorCallback)
+		}
+		return
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to process midtrans notification", "reference", req.OrderID, "error", err)
+		if !permanentCallbackError(err) {
+			err = fmt.Errorf("failed to process midtrans notification")
+		}
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// callbackTransaction finds the transaction a payment callback refers to, a reference that is not
+// ours is reported as constants.ErrUnknownReference.
+func (s *service) callbackTransaction(ctx context.Context, reference string) (transaction entities.Transaction, err error) {
+	transaction, err = s.transactionRepository.FindByReference(ctx, reference)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "callback for an unknown reference", "reference", reference)
+		err = constants.ErrUnknownReference
+	}
+	return
+}
+
+// permanentCallbackError tells the callbacks that will fail the same way on every delivery, the
+// handlers answer them with a 4xx so the provider stops retrying. They are logged as errors for
+// someone to look at.
+func permanentCallbackError(err error) bool {
+	return errors.Is(err, constants.ErrUnknownReference) || errors.Is(err, constants.ErrCallbackMismatch)
+}
+
+// SettleManual records the outcome an operator checked by hand for a manual bank transfer, the
+// manual provider never reports one. A top up is credited or failed, a payout is settled,
+// released or, when it bounced after being confirmed, reversed.
+func (s *service) SettleManual(ctx context.Context, req SettleManualRequest) (res constants.DefaultResponse, err error) {
+	session, _ := ctx.Value(types.String("admin")).(entities.AdminLogin)
+
+	before, err := s.transactionRepository.FindByReference(ctx, req.Reference)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find transaction by reference", "reference", req.Reference, "error", err)
+		err = fmt.Errorf("transaction not found")
+		return
+	}
+	if before.Provider != payment.ProviderManual {
+		err = constants.ErrNotManualTransfer
+		return
+	}
+
+	switch {
+	case before.Type == entities.TransactionTypeIn && req.Status == entities.TransactionStatusCompleted:
+		err = s.settleTopUp(ctx, req.Reference, session.Username, false)
+	case before.Type == entities.TransactionTypeIn:
+		err = s.failTopUp(ctx, req.Reference, session.Username)
+	case req.Status == entities.TransactionStatusCompleted:
+		err = s.settleWithdraw(ctx, req.Reference, session.Username)
+	case before.Status == entities.TransactionStatusCompleted:
+		err = s.reverseWithdraw(ctx, req.Reference, session.Username)
+	default:
+		err = s.releaseWithdraw(ctx, req.Reference, fmt.Sprintf("manual transfer failed: %s", req.Note), session.Username)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to settle manual transfer", "reference", req.Reference, "status", req.Status, "error", err)
+		err = fmt.Errorf("failed to settle manual transfer")
+		return
+	}
+
+	after, err := s.transactionRepository.FindByReference(ctx, req.Reference)
+	if err != nil {
+		slog.ErrorContext(ctx, "can't get transaction", "error", err)
+		err = fmt.Errorf("can't get transaction")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionSettleManualTransfer,
+		TargetType: "transaction",
+		TargetID:   req.Reference,
+		Before:     map[string]interface{}{"status": before.Status},
+		After:      map[string]interface{}{"status": after.Status, "note": req.Note},
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    after,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// failTopUp marks a top up that was never paid as failed.
+func (s *service) failTopUp(ctx context.Context, reference string, actor string) (err error) {
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, err := s.transactionRepository.FindByReferenceForUpdate(ctx, reference)
+		if err != nil || transaction.Status != entities.TransactionStatusPending {
+			return
+		}
+		return s.transactionRepository.UpdateStatus(ctx, &transaction, entities.TransactionStatusFailed, actor)
+	})
+}
+
+// verifyLatePayment asks the provider whether a payment on an expired top up really happened.
+func (s *service) verifyLatePayment(ctx context.Context, transaction entities.Transaction, paymentID string) (err error) {
+	topUpRequest := payment.TopUpRequest{
+		Provider:   transaction.Provider,
+		ID:         transaction.ProviderReference,
+		ExternalID: transaction.Reference,
+		PaymentID:  paymentID,
+	}
+	topUp, err := s.paymentWrapper.TopUp(ctx, topUpRequest)
+	if err != nil {
+		return
+	}
+	if topUp.Data.Status != entities.TransactionStatusCompleted {
+		slog.ErrorContext(ctx, "payment on expired top up not confirmed by provider", "reference", transaction.Reference, "paymentId", paymentID, "status", topUp.Data.Status)
+		return fmt.Errorf("payment on expired top up not confirmed")
+	}
+	slog.WarnContext(ctx, "crediting payment on expired top up", "reference", transaction.Reference, "paymentId", paymentID, "amount", transaction.Amount)
+	return
+}
+
+// recordCallback stores the callback and reports whether it is seen for the first time.
+func (s *service) recordCallback(ctx context.Context, callbackID, callbackType, reference string, req interface{}) (isNew bool, err error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+
+	callback := &entities.PaymentCallback{
+		CallbackID: callbackID,
+		Type:       callbackType,
+		Reference:  reference,
+		Payload:    string(payload),
+	}
+	isNew, err = s.paymentCallbackRepository.CreateIfNotExists(ctx, callback)
+	if err == nil && !isNew {
+		slog.InfoContext(ctx, "skip duplicate payment callback", "callbackId", callbackID, "reference", reference)
+	}
+	return
+}
+
+// settleTopUp credits a paid top up to the player exactly once. confirmed tells that the
+// provider's status API reported the payment, only then a top up that already expired or
+// failed is completed.
+func (s *service) settleTopUp(ctx context.Context, reference string, actor string, confirmed bool) (err error) {
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, err := s.transactionRepository.FindByReferenceForUpdate(ctx, reference)
+		if err != nil {
+			return
+		}
+		// * a concurrent callback or polling run already settled this top up
+		if transaction.Status == entities.TransactionStatusCompleted {
+			return
+		}
+		if transaction.Status != entities.TransactionStatusPending && !(confirmed && transaction.CanSettleLate()) {
+			return fmt.Errorf("%w: %s top up %s is not settled without provider confirmation", constants.ErrInvalidTransition, transaction.Status, reference)
+		}
+
+		err = s.transactionRepository.UpdateStatus(ctx, &transaction, entities.TransactionStatusCompleted, actor)
+		if err != nil {
+			return
+		}
+
+		err = s.ledgerService.RecordTopUp(ctx, transaction)
+		if err != nil {
+			return
+		}
+
+		user, err := s.userRepository.FindByIDForUpdate(ctx, transaction.UserID)
+		if err != nil {
+			return
+		}
+		user.Balance += transaction.Amount - transaction.Fee
+		return s.userRepository.UpdateBalance(ctx, &user)
+	})
+}
+
+// ExpireTopUps expires the top ups whose virtual account ran out unpaid. The provider is asked
+// first so a payment whose callback never arrived is settled instead of expired, then each VA is
+// closed before the transaction is marked so a late payment is refused there. Polling for an
+// expired top up stops on its next run.
+func (s *service) ExpireTopUps(ctx context.Context) (res constants.DefaultResponse, err error) {
+	transactions, err := s.transactionRepository.FindExpiredPendingTopUps(ctx, time.Now(), config.GetInt("worker.speed_engine.expireTopUpBatch"))
+	if err != nil {
+		slog.ErrorContext(ctx, "can't get expired top ups", "error", err)
+		err = fmt.Errorf("can't get expired top ups")
+		return
+	}
+
+	data := ExpireTopUpsResponseData{Found: len(transactions)}
+	for _, transaction := range transactions {
+		settled, errExpire := s.expireTopUp(ctx, transaction)
+		if errExpire != nil {
+			slog.ErrorContext(ctx, "failed to expire top up", "reference", transaction.Reference, "error", errExpire)
+			continue
+		}
+		if settled {
+			data.Settled++
+			continue
+		}
+		data.Expired++
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    data,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) expireTopUp(ctx context.Context, transaction entities.Transaction) (settled bool, err error) {
+	topUpRequest := payment.TopUpRequest{
+		Provider:   transaction.Provider,
+		ID:         transaction.ProviderReference,
+		ExternalID: transaction.Reference,
+	}
+	topUp, err := s.paymentWrapper.TopUp(ctx, topUpRequest)
+	if err != nil {
+		return
+	}
+	// * paid at the provider but the callback got lost, settle instead of expiring
+	if topUp.Data.Status == entities.TransactionStatusCompleted {
+		return true, s.settleTopUp(ctx, transaction.Reference, entities.TransactionActorSystem, true)
+	}
+
+	closeVARequest := payment.CloseVARequest{
+		Provider:   transaction.Provider,
+		ID:         transaction.ProviderReference,
+		ExternalID: transaction.Reference,
+	}
+	_, err = s.paymentWrapper.CloseVA(ctx, closeVARequest)
+	if err != nil {
+		return
+	}
+
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, err := s.transactionRepository.FindByReferenceForUpdate(ctx, transaction.Reference)
+		// * paid while the sweep was running
+		if err != nil || transaction.Status != entities.TransactionStatusPending {
+			return
+		}
+		return s.transactionRepository.UpdateStatus(ctx, &transaction, entities.TransactionStatusExpired, entities.TransactionActorSystem)
+	})
+	return
+}
+
+// ExpireWithdraw runs when a withdrawal hold times out. The provider gets the last word: a
+// disbursement it completed is settled and one it failed or never received gives the held funds
+// back. While the outcome is still unknown the hold stays and the provider is asked again later.
+func (s *service) ExpireWithdraw(ctx context.Context, reference string) (res constants.DefaultResponse, err error) {
+	transaction, err := s.transactionRepository.FindByReference(ctx, reference)
+	if err != nil {
+		slog.ErrorContext(ctx, "can't get transaction", "error", err)
+		err = fmt.Errorf("can't get transaction")
+		return
+	}
+	if transaction.Status != entities.TransactionStatusPending {
+		return
+	}
+
+	withdrawStatusRequest := payment.WithdrawStatusRequest{
+		Provider:   transaction.Provider,
+		ID:         transaction.ProviderReference,
+		ExternalID: reference,
+	}
+	withdraw, err := s.paymentWrapper.WithdrawStatus(ctx, withdrawStatusRequest)
+	switch {
+	case payment.NotFound(err):
+		err = s.releaseWithdraw(ctx, reference, "disbursement not found at provider", entities.TransactionActorSystem)
+	case err != nil, withdraw.Data.Status != entities.TransactionStatusCompleted && withdraw.Data.Status != entities.TransactionStatusFailed:
+		slog.ErrorContext(ctx, "withdrawal still unresolved after its hold expired", "reference", reference, "provider", transaction.Provider, "status", withdraw.Data.Status, "error", err)
+		s.scheduleWithdrawCheck(ctx, reference, time.Now().Add(config.GetDuration("worker.speed_engine.withdrawRecheckInterval")))
+		err = nil
+	case withdraw.Data.Status == entities.TransactionStatusCompleted:
+		err = s.settleWithdraw(ctx, reference, entities.TransactionActorSystem)
+	default:
+		err = s.releaseWithdraw(ctx, reference, "disbursement failed", entities.TransactionActorSystem)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to close withdrawal hold", "reference", reference, "error", err)
+		err = fmt.Errorf("failed to close withdrawal hold")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// settleWithdraw turns the hold of a completed disbursement into an actual debit. A hold that
+// was already released means the money went out after the withdrawal failed, which is final:
+// the case is logged for an operator to debit the player with a balance adjustment.
+func (s *service) settleWithdraw(ctx context.Context, reference string, actor string) (err error) {
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, hold, err := s.findPendingWithdraw(ctx, reference)
+		if err != nil {
+			return
+		}
+		if hold.Status == entities.BalanceHoldStatusReleased {
+			slog.ErrorContext(ctx, "withdrawal completed at the provider after it failed, needs a manual debit", "reference", reference, "userId", transaction.UserID, "amount", hold.Amount)
+			return
+		}
+		if hold.Status != entities.BalanceHoldStatusHeld {
+			return
+		}
+
+		err = s.transactionRepository.UpdateStatus(ctx, &transaction, entities.TransactionStatusCompleted, actor)
+		if err != nil {
+			return
+		}
+
+		hold.Status = entities.BalanceHoldStatusSettled
+		err = s.balanceHoldRepository.Update(ctx, &hold)
+		if err != nil {
+			return
+		}
+
+		err = s.ledgerService.RecordWithdrawal(ctx, transaction)
+		if err != nil {
+			return
+		}
+
+		user, err := s.userRepository.FindByIDForUpdate(ctx, transaction.UserID)
+		if err != nil {
+			return
+		}
+		user.Balance -= hold.Amount
+		user.HeldBalance -= hold.Amount
+		return s.userRepository.UpdateBalance(ctx, &user)
+	})
+}
+
+// releaseWithdraw marks the withdrawal failed and makes the held funds available again.
+func (s *service) releaseWithdraw(ctx context.Context, reference string, reason string, actor string) (err error) {
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, hold, err := s.findPendingWithdraw(ctx, reference)
+		if err != nil || hold.Status != entities.BalanceHoldStatusHeld {
+			return
+		}
+
+		err = s.transactionRepository.UpdateStatus(ctx, &transaction, entities.TransactionStatusFailed, actor)
+		if err != nil {
+			return
+		}
+
+		hold.Status = entities.BalanceHoldStatusReleased
+		hold.Reason = reason
+		err = s.balanceHoldRepository.Update(ctx, &hold)
+		if err != nil {
+			return
+		}
+
+		user, err := s.userRepository.FindByIDForUpdate(ctx, transaction.UserID)
+		if err != nil {
+			return
+		}
+		user.HeldBalance -= hold.Amount
+		return s.userRepository.UpdateBalance(ctx, &user)
+	})
+}
+
+// reverseWithdraw credits back a completed disbursement that the provider returned afterwards.
+func (s *service) reverseWithdraw(ctx context.Context, reference string, actor string) (err error) {
+	return s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		transaction, err := s.transactionRepository.FindByReferenceForUpdate(ctx, reference)
+		if err != nil || transaction.Status == entities.TransactionStatusReversed {
+			return
+		}
+
+		err = s.transactionRepository.UpdateStatus(ctx, &transaction, entities.TransactionStatusReversed, actor)
+		if err != nil {
+			return
+		}
+
+		err = s.ledgerService.RecordWithdrawalReversal(ctx, transaction)
+		if err != nil {
+			return
+		}
+
+		user, err := s.userRepository.FindByIDForUpdate(ctx, transaction.UserID)
+		if err != nil {
+			return
+		}
+		user.Balance += transaction.Amount + transaction.Fee
+		return s.userRepository.UpdateBalance(ctx, &user)
+	})
+}
+
+// scheduleWithdrawCheck asks the provider for the disbursement status again at eta.
+func (s *service) scheduleWithdrawCheck(ctx context.Context, reference string, eta time.Time) {
+	signature := &tasks.Signature{
+		Name: "enqueue-speed_engine-withdraw-timeout",
+		Args: []tasks.Arg{
+			{Name: "externalId", Type: "string", Value: reference},
+		},
+		RetryCount:   config.GetInt("worker.speed_engine.retryCount"),
+		RetryTimeout: config.GetInt("worker.speed_engine.retryTimeout"),
+		ETA:          &eta,
+	}
+
+	_, err := s.worker.SendTaskWithContext(ctx, signature)
+	if err != nil {
+		slog.ErrorContext(ctx, "send task worker", "error", err)
+	}
+}
+
+func (s *service) findPendingWithdraw(ctx context.Context, reference string) (transaction entities.Transaction, hold entities.BalanceHold, err error) {
+	transaction, err = s.transactionRepository.FindByReferenceForUpdate(ctx, reference)
+	if err != nil {
+		return
+	}
+
+	hold, err = s.balanceHoldRepository.FindByTransactionIDForUpdate(ctx, transaction.ID)
+	return
+}
+
+// emailPolicy is the configured email verification policy, withdrawals wait for a verified email
+// unless configured otherwise.
+func emailPolicy() string {
+	if policy := config.GetString("email.verificationPolicy"); policy != "" {
+		return policy
+	}
+	return entities.EmailPolicyWithdraw
+}
@@ -0,0 +1,727 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RichardKnop/machinery/v1"
+	machineryConfig "github.com/RichardKnop/machinery/v1/config"
+	"github.com/danielpnjt/speed-engine/internal/config"
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	mocksRepo "github.com/danielpnjt/speed-engine/internal/domain/repositories/mocks"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/payment"
+	mocksRedis "github.com/danielpnjt/speed-engine/internal/infrastructure/redis/mocks"
+	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
+	"github.com/danielpnjt/speed-engine/internal/usecase/fee"
+	"github.com/danielpnjt/speed-engine/internal/usecase/ledger"
+	"github.com/danielpnjt/speed-engine/internal/usecase/limit"
+	"github.com/golang/mock/gomock"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeDB keeps users and transactions in memory and emulates row locks the way
+// postgres holds them for SELECT ... FOR UPDATE until the transaction ends.
+type fakeDB struct {
+	mu           sync.Mutex
+	rowLocks     map[int]*sync.Mutex
+	users        map[int]entities.User
+	transactions []entities.Transaction
+	holds        []entities.BalanceHold
+}
+
+type fakeTxKey struct{}
+
+type fakeTx struct {
+	held []*sync.Mutex
+}
+
+func newFakeDB(users ...entities.User) *fakeDB {
+	db := &fakeDB{
+		rowLocks: make(map[int]*sync.Mutex),
+		users:    make(map[int]entities.User),
+	}
+	for _, user := range users {
+		db.users[user.ID] = user
+		db.rowLocks[user.ID] = &sync.Mutex{}
+	}
+	return db
+}
+
+func (db *fakeDB) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(fakeTxKey{}).(*fakeTx); ok {
+		return fn(ctx)
+	}
+	tx := &fakeTx{}
+	defer func() {
+		for _, lock := range tx.held {
+			lock.Unlock()
+		}
+	}()
+	return fn(context.WithValue(ctx, fakeTxKey{}, tx))
+}
+
+type fakeUserRepository struct {
+	repositories.User
+	db *fakeDB
+}
+
+func (r *fakeUserRepository) FindByIDForUpdate(ctx context.Context, id int) (entities.User, error) {
+	tx := ctx.Value(fakeTxKey{}).(*fakeTx)
+	lock := r.db.rowLocks[id]
+	lock.Lock()
+	tx.held = append(tx.held, lock)
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.db.users[id], nil
+}
+
+func (r *fakeUserRepository) UpdateBalance(ctx context.Context, entity *entities.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if r.db.users[entity.ID].Version != entity.Version {
+		return constants.ErrConcurrentUpdate
+	}
+	entity.Version++
+	r.db.users[entity.ID] = *entity
+	return nil
+}
+
+type fakeTransactionRepository struct {
+	repositories.Transaction
+	db *fakeDB
+}
+
+// Create refuses a reference that is already taken, the way the unique index on reference does.
+func (r *fakeTransactionRepository) Create(ctx context.Context, entity *entities.Transaction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, transaction := range r.db.transactions {
+		if transaction.Reference == entity.Reference {
+			return fmt.Errorf("duplicate key value violates unique constraint on reference %s", entity.Reference)
+		}
+	}
+	entity.ID = len(r.db.transactions) + 1
+	r.db.transactions = append(r.db.transactions, *entity)
+	return nil
+}
+
+func (r *fakeTransactionRepository) Update(ctx context.Context, entity *entities.Transaction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.transactions[entity.ID-1] = *entity
+	return nil
+}
+
+func (r *fakeTransactionRepository) UpdateStatus(ctx context.Context, entity *entities.Transaction, status string, actor string) error {
+	if !entity.CanTransitionTo(status) {
+		return constants.ErrInvalidTransition
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	entity.Status = status
+	r.db.transactions[entity.ID-1] = *entity
+	return nil
+}
+
+// SumAmountByUserSince ignores since, every fake transaction was created just now.
+func (r *fakeTransactionRepository) SumAmountByUserSince(ctx context.Context, userID int, transactionType string, since time.Time) (float64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var total float64
+	for _, transaction := range r.db.transactions {
+		if transaction.UserID == userID && transaction.Type == transactionType && transaction.Status != entities.TransactionStatusFailed {
+			total += transaction.Amount
+		}
+	}
+	return total, nil
+}
+
+func (r *fakeTransactionRepository) FindByReference(ctx context.Context, reference string) (entities.Transaction, error) {
+	return r.FindByReferenceForUpdate(ctx, reference)
+}
+
+// FindExpiredPendingTopUps ignores now, the fake top ups are created already expired.
+func (r *fakeTransactionRepository) FindExpiredPendingTopUps(ctx context.Context, now time.Time, limit int) ([]entities.Transaction, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var transactions []entities.Transaction
+	for _, transaction := range r.db.transactions {
+		if transaction.Type == "in" && transaction.Status == entities.TransactionStatusPending {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (r *fakeTransactionRepository) FindByReferenceForUpdate(ctx context.Context, reference string) (entities.Transaction, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, transaction := range r.db.transactions {
+		if transaction.Reference == reference {
+			return transaction, nil
+		}
+	}
+	return entities.Transaction{}, gorm.ErrRecordNotFound
+}
+
+type fakeBalanceHoldRepository struct {
+	repositories.BalanceHold
+	db *fakeDB
+}
+
+func (r *fakeBalanceHoldRepository) Create(ctx context.Context, entity *entities.BalanceHold) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	entity.ID = len(r.db.holds) + 1
+	r.db.holds = append(r.db.holds, *entity)
+	return nil
+}
+
+func (r *fakeBalanceHoldRepository) Update(ctx context.Context, entity *entities.BalanceHold) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.holds[entity.ID-1] = *entity
+	return nil
+}
+
+func (r *fakeBalanceHoldRepository) FindByTransactionIDForUpdate(ctx context.Context, transactionID int) (entities.BalanceHold, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, hold := range r.db.holds {
+		if hold.TransactionID == transactionID {
+			return hold, nil
+		}
+	}
+	return entities.BalanceHold{}, gorm.ErrRecordNotFound
+}
+
+type fakePaymentCallbackRepository struct {
+	repositories.PaymentCallback
+}
+
+func (r *fakePaymentCallbackRepository) CreateIfNotExists(ctx context.Context, entity *entities.PaymentCallback) (bool, error) {
+	return true, nil
+}
+
+// rejectingPaymentWrapper refuses every disbursement the way a provider answers a bad request.
+type rejectingPaymentWrapper struct {
+	payment.Wrapper
+}
+
+func (w *rejectingPaymentWrapper) Withdraw(ctx context.Context, req payment.WithdrawRequest) (payment.WithdrawResponse, error) {
+	return payment.WithdrawResponse{}, &payment.Error{HTTPStatus: 400, ErrorCode: "INVALID_DESTINATION", Message: "invalid destination"}
+}
+
+// paidPaymentWrapper reports every virtual account as paid with amount and counts the ones it closed.
+type paidPaymentWrapper struct {
+	payment.Wrapper
+	amount float64
+	closed int
+}
+
+func (w *paidPaymentWrapper) TopUp(ctx context.Context, req payment.TopUpRequest) (payment.TopUpResponse, error) {
+	return payment.TopUpResponse{Data: payment.TopUpResponseData{ExternalID: req.ExternalID, Status: entities.TransactionStatusCompleted, ExpectedAmount: w.amount}}, nil
+}
+
+func (w *paidPaymentWrapper) CloseVA(ctx context.Context, req payment.CloseVARequest) (payment.CloseVAResponse, error) {
+	w.closed++
+	return payment.CloseVAResponse{}, nil
+}
+
+// unpaidPaymentWrapper knows of no payment on any virtual account.
+type unpaidPaymentWrapper struct {
+	payment.Wrapper
+}
+
+func (w *unpaidPaymentWrapper) TopUp(ctx context.Context, req payment.TopUpRequest) (payment.TopUpResponse, error) {
+	return payment.TopUpResponse{Data: payment.TopUpResponseData{ExternalID: req.ExternalID, Status: entities.TransactionStatusPending}}, nil
+}
+
+type fakeLedgerService struct {
+	ledger.Service
+}
+
+func (s *fakeLedgerService) RecordTopUp(ctx context.Context, transaction entities.Transaction) error {
+	return nil
+}
+
+func (s *fakeLedgerService) RecordWithdrawal(ctx context.Context, transaction entities.Transaction) error {
+	return nil
+}
+
+// fakeFeeService charges the same flat fee on every movement.
+type fakeFeeService struct {
+	fee.Service
+	fee float64
+}
+
+func (s *fakeFeeService) Calculate(ctx context.Context, operation string, bankCode string, amount float64) (fee.Charge, error) {
+	if s.fee == 0 {
+		return fee.Charge{Provider: "sandbox"}, nil
+	}
+	ruleID := 1
+	return fee.Charge{Provider: "sandbox", RuleID: &ruleID, Fee: s.fee}, nil
+}
+
+type fakeLimitService struct {
+	limit.Service
+}
+
+func (s *fakeLimitService) Check(ctx context.Context, user entities.User, transactionType string, amount float64) error {
+	return nil
+}
+
+func (s *fakeLimitService) Record(ctx context.Context, userID int, transactionType string) error {
+	return nil
+}
+
+// newLimitService builds the real limit service with top ups left open and withdrawals bound by policy.
+func newLimitService(repository repositories.Transaction, redisWrapper *mocksRedis.MockWrapper, policy entities.LimitPolicy, rules []entities.VelocityRule, kycWithdrawLimits []float64) limit.Service {
+	open := entities.LimitPolicy{MaxAmount: 1e12, DailyCaps: []float64{1e12}, MonthlyCaps: []float64{1e12}}
+	if policy.MaxAmount == 0 {
+		policy = open
+	}
+	return limit.NewService().
+		SetDB(&gorm.DB{}).
+		SetTransactionRepository(repository).
+		SetUserRepository(&fakeUserRepository{}).
+		SetRedisWrapper(redisWrapper).
+		SetPolicy(entities.TransactionTypeIn, open).
+		SetPolicy(entities.TransactionTypeOut, policy).
+		SetVelocityRules(rules).
+		SetKYCWithdrawLimits(kycWithdrawLimits).
+		Validate()
+}
+
+type fakeAuditService struct {
+	audit.Service
+	mu      sync.Mutex
+	entries []audit.Entry
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, entry audit.Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+}
+
+// newWithdrawTestService builds a transaction service around fakeDB for player 123, who owns the
+// verified BCA account 7. Fees and limits are off and payouts go to the sandbox, tests replace the
+// fields they exercise.
+func newWithdrawTestService(t *testing.T, player entities.User) (*service, *fakeDB, *mocksRepo.MockBank) {
+	verifiedAt := time.Now()
+	player.ID = 123
+	player.Username = "daniel.pnjt"
+	player.EmailVerifiedAt = &verifiedAt
+	db := newFakeDB(player)
+
+	mockBankRepo := mocksRepo.NewMockBank(gomock.NewController(t))
+	mockBankRepo.EXPECT().FindByIDAndUserID(gomock.Any(), 7, 123).Return(entities.Bank{
+		ID:            7,
+		UserID:        123,
+		AccountName:   "Daniel Alexander",
+		AccountNumber: "1234567890",
+		BankCode:      "BCA",
+		VerifiedAt:    &verifiedAt,
+	}, nil).AnyTimes()
+
+	service := &service{
+		unitOfWork:                db,
+		transactionRepository:     &fakeTransactionRepository{db: db},
+		balanceHoldRepository:     &fakeBalanceHoldRepository{db: db},
+		paymentCallbackRepository: &fakePaymentCallbackRepository{},
+		userRepository:            &fakeUserRepository{db: db},
+		bankRepository:            mockBankRepo,
+		paymentWrapper:            payment.NewSandboxWrapper(),
+		ledgerService:             &fakeLedgerService{},
+		feeService:                &fakeFeeService{},
+		limitService:              &fakeLimitService{},
+		auditService:              &fakeAuditService{},
+	}
+	return service, db, mockBankRepo
+}
+
+// TestTransactionService_Withdraw_ChecksBalanceUnderUserLock runs concurrent withdrawals against
+// fakeDB, it proves the balance is read through FindByIDForUpdate inside the unit of work. That
+// postgres holds the row lock the same way is up to the repository and is not covered here.
+func TestTransactionService_Withdraw_ChecksBalanceUnderUserLock(t *testing.T) {
+	service, db, _ := newWithdrawTestService(t, entities.User{Balance: 100000})
+	auditService := &fakeAuditService{}
+	service.auditService = auditService
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 30000})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var succeeded, rejected int
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, constants.ErrInsufficientFunds)
+		rejected++
+	}
+
+	require.Equal(t, 3, succeeded)
+	require.Equal(t, attempts-3, rejected)
+	require.Equal(t, float64(10000), db.users[123].Balance)
+	require.Equal(t, float64(0), db.users[123].HeldBalance)
+	require.Len(t, db.transactions, 3)
+	require.Len(t, auditService.entries, 3)
+	for _, hold := range db.holds {
+		require.Equal(t, entities.BalanceHoldStatusSettled, hold.Status)
+	}
+}
+
+func TestTransactionService_Withdraw_KYCLimit(t *testing.T) {
+	service, db, _ := newWithdrawTestService(t, entities.User{Balance: 100000, KYCLevel: entities.KYCLevelBasic})
+	service.limitService = newLimitService(service.transactionRepository, mocksRedis.NewMockWrapper(gomock.NewController(t)), entities.LimitPolicy{}, nil, []float64{0, 20000, 50000})
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+
+	_, err := service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 30000})
+	require.ErrorIs(t, err, constants.ErrKYCLimitExceeded)
+	require.Len(t, db.transactions, 0)
+
+	_, err = service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 20000})
+	require.NoError(t, err)
+	require.Equal(t, float64(80000), db.users[123].Balance)
+}
+
+func TestTransactionService_Withdraw_ForeignBank(t *testing.T) {
+	service, db, mockBankRepo := newWithdrawTestService(t, entities.User{Balance: 100000})
+	mockBankRepo.EXPECT().FindByIDAndUserID(gomock.Any(), 8, 123).Return(entities.Bank{}, gorm.ErrRecordNotFound)
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+
+	_, err := service.Withdraw(ctx, WithdrawRequest{BankID: 8, Amount: 30000})
+	require.ErrorIs(t, err, constants.ErrBankNotFound)
+	require.Len(t, db.transactions, 0)
+	require.Equal(t, float64(100000), db.users[123].Balance)
+}
+
+func TestTransactionService_Withdraw_Fee(t *testing.T) {
+	service, db, _ := newWithdrawTestService(t, entities.User{Balance: 100000})
+	service.feeService = &fakeFeeService{fee: 2500}
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+
+	_, err := service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 30000})
+	require.NoError(t, err)
+	require.Equal(t, float64(67500), db.users[123].Balance)
+	require.Equal(t, float64(2500), db.transactions[0].Fee)
+	require.Equal(t, float64(32500), db.holds[0].Amount)
+
+	// * the balance covers the amount but not the fee on top of it
+	_, err = service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 67500})
+	require.ErrorIs(t, err, constants.ErrInsufficientFunds)
+	require.Equal(t, float64(67500), db.users[123].Balance)
+}
+
+func TestTransactionService_Withdraw_Limits(t *testing.T) {
+	service, db, _ := newWithdrawTestService(t, entities.User{Balance: 200000})
+
+	window := time.Hour
+	velocityKey := entities.VelocityKey(entities.TransactionTypeOut, window, 123)
+	mockRedis := mocksRedis.NewMockWrapper(gomock.NewController(t))
+	gomock.InOrder(
+		mockRedis.EXPECT().GetTTL(gomock.Any(), velocityKey).Return(time.Duration(-2), nil),
+		// * a counter left without expiry is dropped instead of blocking forever
+		mockRedis.EXPECT().GetTTL(gomock.Any(), velocityKey).Return(time.Duration(-1), nil),
+		mockRedis.EXPECT().Delete(gomock.Any(), velocityKey).Return(nil),
+		mockRedis.EXPECT().GetTTL(gomock.Any(), velocityKey).Return(window, nil),
+	)
+	mockRedis.EXPECT().Get(gomock.Any(), velocityKey).Return(float64(2), nil)
+	mockRedis.EXPECT().Incr(gomock.Any(), velocityKey, window).Return(int64(1), nil).Times(2)
+
+	service.limitService = newLimitService(service.transactionRepository, mockRedis, entities.LimitPolicy{
+		MinAmount:   10000,
+		MaxAmount:   100000,
+		DailyCaps:   []float64{50000},
+		MonthlyCaps: []float64{1000000},
+	}, []entities.VelocityRule{{Type: entities.TransactionTypeOut, Window: window, Max: 2}}, []float64{100000})
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+
+	_, err := service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 5000})
+	require.ErrorIs(t, err, constants.ErrAmountBelowMinimum)
+
+	_, err = service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 20000})
+	require.NoError(t, err)
+	_, err = service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 20000})
+	require.NoError(t, err)
+
+	// * 40000 of the 50000 daily cap is used
+	_, err = service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 20000})
+	require.ErrorIs(t, err, constants.ErrDailyLimitExceeded)
+
+	// * fits under the cap but the hourly velocity rule allows two withdrawals
+	_, err = service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 10000})
+	require.ErrorIs(t, err, constants.ErrVelocityLimitExceeded)
+
+	require.Len(t, db.transactions, 2)
+	require.Equal(t, float64(160000), db.users[123].Balance)
+}
+
+func TestTransactionService_Withdraw_VelocityRecordFails(t *testing.T) {
+	service, db, _ := newWithdrawTestService(t, entities.User{Balance: 100000})
+
+	window := time.Hour
+	velocityKey := entities.VelocityKey(entities.TransactionTypeOut, window, 123)
+	mockRedis := mocksRedis.NewMockWrapper(gomock.NewController(t))
+	mockRedis.EXPECT().GetTTL(gomock.Any(), velocityKey).Return(window, nil)
+	// * the key expired between the two reads
+	mockRedis.EXPECT().Get(gomock.Any(), velocityKey).Return(nil, goredis.Nil)
+	mockRedis.EXPECT().Incr(gomock.Any(), velocityKey, window).Return(int64(0), errors.New("redis: connection refused"))
+	service.limitService = newLimitService(service.transactionRepository, mockRedis, entities.LimitPolicy{}, []entities.VelocityRule{{Type: entities.TransactionTypeOut, Window: window, Max: 2}}, []float64{1e12})
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+
+	// * the hold is committed before the count, a redis outage does not undo the withdrawal
+	_, err := service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 30000})
+	require.NoError(t, err)
+	require.Len(t, db.transactions, 1)
+	require.Equal(t, float64(70000), db.users[123].Balance)
+}
+
+func TestTransactionService_Withdraw_CompletedAfterFailure(t *testing.T) {
+	service, db, _ := newWithdrawTestService(t, entities.User{Balance: 100000})
+	service.paymentWrapper = &rejectingPaymentWrapper{}
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+
+	_, err := service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 30000})
+	require.Error(t, err)
+	require.Equal(t, entities.TransactionStatusFailed, db.transactions[0].Status)
+	require.Equal(t, entities.BalanceHoldStatusReleased, db.holds[0].Status)
+	require.Equal(t, float64(100000), db.users[123].Balance)
+	require.Equal(t, float64(0), db.users[123].HeldBalance)
+
+	// * the provider paid out after all, a failed withdrawal stays failed and is left to an
+	// * operator, the callback is acknowledged so it is not redelivered
+	_, err = service.CallbackDisbursement(context.TODO(), DisbursementCallbackRequest{
+		CallbackID: "callback-1",
+		ID:         "disb-1",
+		ExternalID: db.transactions[0].Reference,
+		Status:     entities.TransactionStatusCompleted,
+	})
+	require.NoError(t, err)
+	require.Equal(t, entities.TransactionStatusFailed, db.transactions[0].Status)
+	require.Equal(t, entities.BalanceHoldStatusReleased, db.holds[0].Status)
+	require.Equal(t, float64(100000), db.users[123].Balance)
+	require.Equal(t, float64(0), db.users[123].HeldBalance)
+}
+
+func TestTransactionService_ExpireTopUps_PaidWithoutCallback(t *testing.T) {
+	db := newFakeDB(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 0})
+	db.transactions = append(db.transactions, entities.Transaction{
+		ID:        1,
+		UserID:    123,
+		Reference: "daniel.pnjt-topup",
+		Type:      "in",
+		Amount:    50000,
+		Status:    entities.TransactionStatusPending,
+	})
+	paymentWrapper := &paidPaymentWrapper{}
+
+	service := &service{
+		unitOfWork:            db,
+		transactionRepository: &fakeTransactionRepository{db: db},
+		userRepository:        &fakeUserRepository{db: db},
+		paymentWrapper:        paymentWrapper,
+		ledgerService:         &fakeLedgerService{},
+	}
+
+	// * the player paid but the callback never arrived, the sweep settles instead of expiring
+	res, err := service.ExpireTopUps(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, ExpireTopUpsResponseData{Found: 1, Settled: 1}, res.Data)
+	require.Equal(t, entities.TransactionStatusCompleted, db.transactions[0].Status)
+	require.Equal(t, float64(50000), db.users[123].Balance)
+	require.Equal(t, 0, paymentWrapper.closed)
+}
+
+func TestTransactionService_CallbackVA_ExpiredTopUp(t *testing.T) {
+	db := newFakeDB(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 0})
+	db.transactions = append(db.transactions, entities.Transaction{
+		ID:        1,
+		UserID:    123,
+		Reference: "daniel.pnjt-topup",
+		Type:      "in",
+		Amount:    50000,
+		Status:    entities.TransactionStatusExpired,
+	})
+
+	service := &service{
+		unitOfWork:                db,
+		transactionRepository:     &fakeTransactionRepository{db: db},
+		paymentCallbackRepository: &fakePaymentCallbackRepository{},
+		userRepository:            &fakeUserRepository{db: db},
+		paymentWrapper:            &paidPaymentWrapper{},
+		ledgerService:             &fakeLedgerService{},
+	}
+
+	// * the player paid just before the VA was closed, the provider confirms the payment
+	_, err := service.CallbackVA(context.TODO(), VACallbackRequest{
+		CallbackID: "callback-1",
+		ID:         "cb-1",
+		PaymentID:  "pay-1",
+		ExternalID: "daniel.pnjt-topup",
+		Amount:     50000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, entities.TransactionStatusCompleted, db.transactions[0].Status)
+	require.Equal(t, float64(50000), db.users[123].Balance)
+}
+
+func TestTransactionService_CallbackVA_FailedTopUpUnconfirmed(t *testing.T) {
+	db := newFakeDB(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 0})
+	db.transactions = append(db.transactions, entities.Transaction{
+		ID:        1,
+		UserID:    123,
+		Reference: "daniel.pnjt-topup",
+		Type:      "in",
+		Amount:    50000,
+		Status:    entities.TransactionStatusFailed,
+	})
+
+	service := &service{
+		unitOfWork:                db,
+		transactionRepository:     &fakeTransactionRepository{db: db},
+		paymentCallbackRepository: &fakePaymentCallbackRepository{},
+		userRepository:            &fakeUserRepository{db: db},
+		paymentWrapper:            &unpaidPaymentWrapper{},
+		ledgerService:             &fakeLedgerService{},
+	}
+
+	// * the provider has no record of the payment, a failed top up is not credited on the callback alone
+	_, err := service.CallbackVA(context.TODO(), VACallbackRequest{
+		CallbackID: "callback-1",
+		ID:         "cb-1",
+		PaymentID:  "pay-1",
+		ExternalID: "daniel.pnjt-topup",
+		Amount:     50000,
+	})
+	require.Error(t, err)
+	require.Equal(t, entities.TransactionStatusFailed, db.transactions[0].Status)
+	require.Equal(t, float64(0), db.users[123].Balance)
+}
+
+func TestTransactionService_CallbackVA_Permanent(t *testing.T) {
+	db := newFakeDB(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 0})
+	db.transactions = append(db.transactions, entities.Transaction{
+		ID:        1,
+		UserID:    123,
+		Reference: "daniel.pnjt-topup",
+		Type:      "in",
+		Amount:    50000,
+		Status:    entities.TransactionStatusPending,
+	})
+
+	service := &service{
+		unitOfWork:                db,
+		transactionRepository:     &fakeTransactionRepository{db: db},
+		paymentCallbackRepository: &fakePaymentCallbackRepository{},
+		userRepository:            &fakeUserRepository{db: db},
+		paymentWrapper:            &paidPaymentWrapper{},
+		ledgerService:             &fakeLedgerService{},
+	}
+
+	// * retrying cannot fix either, the errors tell the handler to answer with a 4xx
+	_, err := service.CallbackVA(context.TODO(), VACallbackRequest{CallbackID: "callback-1", ID: "cb-1", ExternalID: "someone-else", Amount: 50000})
+	require.ErrorIs(t, err, constants.ErrUnknownReference)
+
+	_, err = service.CallbackVA(context.TODO(), VACallbackRequest{CallbackID: "callback-2", ID: "cb-2", ExternalID: "daniel.pnjt-topup", Amount: 5000})
+	require.ErrorIs(t, err, constants.ErrCallbackMismatch)
+	require.Equal(t, entities.TransactionStatusPending, db.transactions[0].Status)
+	require.Equal(t, float64(0), db.users[123].Balance)
+}
+
+func TestTransactionService_CallbackMidtrans(t *testing.T) {
+	db := newFakeDB(entities.User{ID: 123, Username: "daniel.pnjt", Balance: 0})
+	db.transactions = append(db.transactions, entities.Transaction{
+		ID:        1,
+		UserID:    123,
+		Reference: "daniel.pnjt-topup",
+		Type:      "in",
+		Amount:    50000,
+		Provider:  "midtrans",
+		Status:    entities.TransactionStatusPending,
+	})
+
+	service := &service{
+		unitOfWork:                db,
+		transactionRepository:     &fakeTransactionRepository{db: db},
+		paymentCallbackRepository: &fakePaymentCallbackRepository{},
+		userRepository:            &fakeUserRepository{db: db},
+		paymentWrapper:            &paidPaymentWrapper{amount: 50000},
+		ledgerService:             &fakeLedgerService{},
+	}
+
+	// * the notification says pending but midtrans reports the charge settled, its own record wins
+	_, err := service.CallbackMidtrans(context.TODO(), MidtransNotificationRequest{
+		CallbackID:        "midtrans-1:pending",
+		TransactionID:     "midtrans-1",
+		OrderID:           "daniel.pnjt-topup",
+		TransactionStatus: "pending",
+	})
+	require.NoError(t, err)
+	require.Equal(t, entities.TransactionStatusCompleted, db.transactions[0].Status)
+	require.Equal(t, float64(50000), db.users[123].Balance)
+}
+
+func TestTransactionService_SettleManual(t *testing.T) {
+	service, db, _ := newWithdrawTestService(t, entities.User{Balance: 100000})
+	service.paymentWrapper = payment.NewRouter().
+		Register(payment.ProviderManual, payment.NewManualWrapper(config.ManualTransferConfig{BankCode: "BCA", AccountNumber: "0987654321"})).
+		SetDefaultProviders(payment.ProviderManual).
+		Validate()
+	auditService := &fakeAuditService{}
+	service.auditService = auditService
+	// * the hold timeout check is only scheduled, the eager broker never runs it
+	worker, err := machinery.NewServer(&machineryConfig.Config{Broker: "eager", ResultBackend: "eager", DefaultQueue: "speed_engine-queue"})
+	require.NoError(t, err)
+	service.worker = worker
+
+	ctx := context.WithValue(context.TODO(), types.String("user"), entities.Login{ID: 123, Username: "daniel.pnjt"})
+	_, err = service.Withdraw(ctx, WithdrawRequest{BankID: 7, Amount: 30000})
+	require.NoError(t, err)
+	require.Equal(t, entities.TransactionStatusPending, db.transactions[0].Status)
+	require.Equal(t, float64(30000), db.users[123].HeldBalance)
+
+	// * the operator sent the payout from internet banking and confirms it
+	adminCtx := context.WithValue(context.TODO(), types.String("admin"), entities.AdminLogin{Username: "finance"})
+	_, err = service.SettleManual(adminCtx, SettleManualRequest{Reference: db.transactions[0].Reference, Status: entities.TransactionStatusCompleted})
+	require.NoError(t, err)
+	require.Equal(t, entities.TransactionStatusCompleted, db.transactions[0].Status)
+	require.Equal(t, float64(70000), db.users[123].Balance)
+	require.Equal(t, float64(0), db.users[123].HeldBalance)
+	require.Equal(t, entities.AuditActionSettleManualTransfer, auditService.entries[len(auditService.entries)-1].Action)
+
+	db.transactions = append(db.transactions, entities.Transaction{ID: 2, UserID: 123, Reference: "daniel.pnjt-xendit", Type: "in", Amount: 50000, Provider: "xendit", Status: entities.TransactionStatusPending})
+	_, err = service.SettleManual(adminCtx, SettleManualRequest{Reference: "daniel.pnjt-xendit", Status: entities.TransactionStatusCompleted})
+	require.ErrorIs(t, err, constants.ErrNotManualTransfer)
+}
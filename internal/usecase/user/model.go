@@ -1,6 +1,9 @@
 package user
 
 import (
+	"time"
+
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 )
 
@@ -8,7 +11,7 @@ import (
 type (
 	RegisterRequest struct {
 		Username        string `json:"username" validate:"required"`
-		Email           string `json:"email" validate:"required"`
+		Email           string `json:"email" validate:"required,email"`
 		Name            string `json:"name" validate:"required"`
 		Password        string `json:"password" validate:"required"`
 		ConfirmPassword string `json:"confirmPassword" validate:"required"`
@@ -19,9 +22,52 @@ type (
 		Password string `json:"password" validate:"required"`
 	}
 
+	ChangePasswordRequest struct {
+		CurrentPassword string `json:"currentPassword" validate:"required"`
+		NewPassword     string `json:"newPassword" validate:"required"`
+		ConfirmPassword string `json:"confirmPassword" validate:"required"`
+	}
+
+	VerifyEmailRequest struct {
+		Token string `json:"token" validate:"required"`
+	}
+
+	ForgotPasswordRequest struct {
+		Username string `json:"username" validate:"required"`
+	}
+
+	ResetPasswordRequest struct {
+		Token           string `json:"token" validate:"required"`
+		NewPassword     string `json:"newPassword" validate:"required"`
+		ConfirmPassword string `json:"confirmPassword" validate:"required"`
+	}
+
+	LoginTwoFactorRequest struct {
+		ChallengeToken string `json:"challengeToken" validate:"required"`
+		Code           string `json:"code" validate:"required"`
+	}
+
+	TwoFactorCodeRequest struct {
+		Code string `json:"code" validate:"required"`
+	}
+
+	DisableTwoFactorRequest struct {
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code" validate:"required"`
+	}
+
+	RefreshRequest struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
+
 	FindAllRequest struct {
 		constants.PaginationRequest
 	}
+
+	ChangeStatusRequest struct {
+		UserID int    `param:"userID" validate:"required"`
+		Reason string `json:"reason" validate:"required"`
+	}
 )
 
 // * Responses
@@ -34,8 +80,38 @@ type (
 	}
 
 	LoginResponseData struct {
-		Token    string `json:"token"`
-		ExpireAt int64  `json:"expireAt"`
+		Token           string `json:"token"`
+		ExpireAt        int64  `json:"expireAt"`
+		RefreshToken    string `json:"refreshToken"`
+		RefreshExpireAt int64  `json:"refreshExpireAt"`
+		SessionID       string `json:"sessionId"`
+		// * set instead of the tokens when the player still owes a second factor
+		TwoFactorRequired bool   `json:"twoFactorRequired"`
+		ChallengeToken    string `json:"challengeToken,omitempty"`
+	}
+
+	TwoFactorSetupResponseData struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioningUri"`
+	}
+
+	RecoveryCodesResponseData struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+
+	SessionResponseData struct {
+		ID         string    `json:"id"`
+		IP         string    `json:"ip"`
+		UserAgent  string    `json:"userAgent"`
+		CreatedAt  time.Time `json:"createdAt"`
+		LastUsedAt time.Time `json:"lastUsedAt"`
+		ExpireAt   int64     `json:"expireAt"`
+		Current    bool      `json:"current"`
+	}
+
+	PlayerDetailResponseData struct {
+		entities.User
+		AvailableBalance float64 `json:"availableBalance"`
 	}
 
 	GenerateAccessTokenResponseData struct {
@@ -3,14 +3,36 @@ package user
 import (
 	"context"
 
+	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 )
 
 type Service interface {
 	Register(ctx context.Context, req RegisterRequest) (res constants.DefaultResponse, err error)
 	Login(ctx context.Context, req LoginRequest) (res constants.DefaultResponse, err error)
+	LoginTwoFactor(ctx context.Context, req LoginTwoFactorRequest) (res constants.DefaultResponse, err error)
+	Refresh(ctx context.Context, req RefreshRequest) (res constants.DefaultResponse, err error)
 	Logout(ctx context.Context) (res constants.DefaultResponse, err error)
+	LogoutOthers(ctx context.Context) (res constants.DefaultResponse, err error)
+	SetupTwoFactor(ctx context.Context) (res constants.DefaultResponse, err error)
+	EnableTwoFactor(ctx context.Context, req TwoFactorCodeRequest) (res constants.DefaultResponse, err error)
+	DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) (res constants.DefaultResponse, err error)
+	RegenerateRecoveryCodes(ctx context.Context, req TwoFactorCodeRequest) (res constants.DefaultResponse, err error)
+	VerifySecondFactor(ctx context.Context, code string) (err error)
+	GetActiveLogin(ctx context.Context, userID int) (login entities.Login, err error)
+	VerifyEmail(ctx context.Context, req VerifyEmailRequest) (res constants.DefaultResponse, err error)
+	ResendEmailVerification(ctx context.Context) (res constants.DefaultResponse, err error)
+	ChangePassword(ctx context.Context, req ChangePasswordRequest) (res constants.DefaultResponse, err error)
+	ForgotPassword(ctx context.Context, req ForgotPasswordRequest) (res constants.DefaultResponse, err error)
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) (res constants.DefaultResponse, err error)
+	GetSessions(ctx context.Context) (res constants.DefaultResponse, err error)
 	GetDetail(ctx context.Context, userID int) (res constants.DefaultResponse, err error)
 	GetAll(ctx context.Context, req FindAllRequest) (res constants.DefaultResponse, err error)
 	GetDetailPlayer(ctx context.Context) (res constants.DefaultResponse, err error)
+	Freeze(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error)
+	Suspend(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error)
+	Close(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error)
+	Activate(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error)
+	GetStatusLog(ctx context.Context, userID int) (res constants.DefaultResponse, err error)
+	Unlock(ctx context.Context, userID int) (res constants.DefaultResponse, err error)
 }
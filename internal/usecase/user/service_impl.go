@@ -2,24 +2,64 @@ package user
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/danielpnjt/speed-engine/internal/config"
 	"github.com/danielpnjt/speed-engine/internal/domain/entities"
 	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/notifier"
 	"github.com/danielpnjt/speed-engine/internal/infrastructure/redis"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
 	"github.com/danielpnjt/speed-engine/internal/pkg/types"
 	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
 	"gorm.io/gorm"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	sessionLockTTL         = 10 * time.Second
+
+	defaultLoginFailureWindow    = 15 * time.Minute
+	defaultLoginLockout          = 15 * time.Minute
+	defaultLoginMaxAttempts      = 10
+	defaultLoginMaxAttemptsPerIP = 50
+	loginFreeAttempts            = 3
+	maxLoginDelay                = time.Minute
+	// * enough rotations to catch a replay of any token issued over a normal session lifetime
+	maxUsedRefreshTokens = 50
+
+	defaultPasswordResetTTL     = 30 * time.Minute
+	defaultEmailVerificationTTL = 24 * time.Hour
+
+	loginChallengeTTL = 5 * time.Minute
+	totpSetupTTL      = 10 * time.Minute
+	// * long enough to outlive every time step a code is accepted in
+	totpReplayTTL     = 2 * time.Minute
+	recoveryCodeCount = 10
+)
+
 type service struct {
-	db             *gorm.DB
-	userRepository repositories.User
-	redisWrapper   redis.Wrapper
+	db                         *gorm.DB
+	unitOfWork                 repositories.UnitOfWork
+	userRepository             repositories.User
+	userStatusLogRepository    repositories.UserStatusLog
+	transactionRepository      repositories.Transaction
+	redisWrapper               redis.Wrapper
+	auditService               audit.Service
+	notifierWrapper            notifier.Wrapper
+	userRecoveryCodeRepository repositories.UserRecoveryCode
 }
 
 func NewService() *service {
@@ -31,16 +71,46 @@ func (s *service) SetDB(db *gorm.DB) *service {
 	return s
 }
 
+func (s *service) SetUnitOfWork(unitOfWork repositories.UnitOfWork) *service {
+	s.unitOfWork = unitOfWork
+	return s
+}
+
 func (s *service) SetUserRepository(repository repositories.User) *service {
 	s.userRepository = repository
 	return s
 }
 
+func (s *service) SetUserStatusLogRepository(repository repositories.UserStatusLog) *service {
+	s.userStatusLogRepository = repository
+	return s
+}
+
+func (s *service) SetTransactionRepository(repository repositories.Transaction) *service {
+	s.transactionRepository = repository
+	return s
+}
+
+func (s *service) SetUserRecoveryCodeRepository(repository repositories.UserRecoveryCode) *service {
+	s.userRecoveryCodeRepository = repository
+	return s
+}
+
 func (s *service) SetRedisWrapper(wrapper redis.Wrapper) *service {
 	s.redisWrapper = wrapper
 	return s
 }
 
+func (s *service) SetNotifierWrapper(wrapper notifier.Wrapper) *service {
+	s.notifierWrapper = wrapper
+	return s
+}
+
+func (s *service) SetAuditService(service audit.Service) *service {
+	s.auditService = service
+	return s
+}
+
 func (s *service) Validate() Service {
 	if s.db == nil {
 		panic("db is nil")
@@ -51,6 +121,24 @@ func (s *service) Validate() Service {
 	if s.redisWrapper == nil {
 		panic("redisWrapper is nil")
 	}
+	if s.unitOfWork == nil {
+		panic("unitOfWork is nil")
+	}
+	if s.userStatusLogRepository == nil {
+		panic("userStatusLogRepository is nil")
+	}
+	if s.auditService == nil {
+		panic("auditService is nil")
+	}
+	if s.notifierWrapper == nil {
+		panic("notifierWrapper is nil")
+	}
+	if s.userRecoveryCodeRepository == nil {
+		panic("userRecoveryCodeRepository is nil")
+	}
+	if s.transactionRepository == nil {
+		panic("transactionRepository is nil")
+	}
 	return s
 }
 
@@ -81,12 +169,21 @@ func (s *service) Register(ctx context.Context, req RegisterRequest) (res consta
 		return
 	}
 
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	_, err = s.userRepository.FindByEmail(ctx, email)
+	if err == nil {
+		slog.ErrorContext(ctx, "email already registered")
+		err = fmt.Errorf("email already registered")
+		return
+	}
+
 	newUser := entities.User{
 		Username: req.Username,
-		Email:    req.Email,
+		Email:    email,
 		Name:     req.Name,
 		Password: hashedPassword,
 		Balance:  0,
+		Status:   entities.UserStatusActive,
 	}
 
 	err = s.userRepository.Create(ctx, &newUser)
@@ -96,6 +193,11 @@ func (s *service) Register(ctx context.Context, req RegisterRequest) (res consta
 		return
 	}
 
+	// * the account exists either way, a player whose email did not go out can ask for it again
+	if errSend := s.sendEmailVerification(ctx, newUser); errSend != nil {
+		slog.ErrorContext(ctx, "failed to send email verification", "userId", newUser.ID, "error", errSend)
+	}
+
 	res = constants.DefaultResponse{
 		Status:  constants.STATUS_SUCCESS,
 		Message: constants.MESSAGE_SUCCESS,
@@ -105,67 +207,293 @@ func (s *service) Register(ctx context.Context, req RegisterRequest) (res consta
 	return
 }
 
+// Login opens a new session for the device, sessions on the player's other devices stay signed in.
 func (s *service) Login(ctx context.Context, req LoginRequest) (res constants.DefaultResponse, err error) {
-	var token string
-	var exp int64
+	meta, _ := ctx.Value(types.String("requestMeta")).(audit.RequestMeta)
+	err = s.checkLoginBlocked(ctx, req.Username, meta.IP)
+	if err != nil {
+		s.recordLoginFailure(ctx, req.Username, "blocked")
+		return
+	}
 
+	// * unknown usernames and wrong passwords count alike, so probing for accounts gets throttled too
 	user, err := s.userRepository.FindByUsername(ctx, req.Username)
+	if err != nil || !utils.CheckPasswordHash(user.Password, req.Password) {
+		slog.ErrorContext(ctx, "failed to login, wrong username or password", "username", req.Username, "error", err)
+		s.registerLoginFailure(ctx, req.Username, meta.IP)
+		s.recordLoginFailure(ctx, req.Username, "wrong username or password")
+		err = fmt.Errorf("username or password is wrong")
+		return
+	}
+	if !user.CanLogin() {
+		slog.ErrorContext(ctx, "login refused for inactive account", "userId", user.ID, "status", user.Status)
+		s.recordLoginFailure(ctx, req.Username, "account is "+user.Status)
+		err = constants.ErrAccountInactive
+		return
+	}
+
+	// * the password is right, a player with two factor authentication still owes a code
+	if user.TOTPEnabled {
+		respData, errChallenge := s.createLoginChallenge(ctx, user)
+		if errChallenge != nil {
+			slog.ErrorContext(ctx, "failed to create login challenge", "userId", user.ID, "error", errChallenge)
+			err = fmt.Errorf("failed to login")
+			return
+		}
+		res = constants.DefaultResponse{
+			Status:  constants.STATUS_SUCCESS,
+			Message: constants.MESSAGE_SUCCESS,
+			Data:    respData,
+			Errors:  make([]string, 0),
+		}
+		return
+	}
+	s.clearLoginFailures(ctx, user.Username)
+
+	respData, err := s.openSession(ctx, user, false)
+	if err != nil {
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    respData,
+		Errors:  make([]string, 0),
+	}
+
+	return
+}
+
+// LoginTwoFactor finishes a login that is waiting for the second factor, an authenticator code or
+// a recovery code. Wrong codes count towards the same lockout as wrong passwords.
+func (s *service) LoginTwoFactor(ctx context.Context, req LoginTwoFactorRequest) (res constants.DefaultResponse, err error) {
+	meta, _ := ctx.Value(types.String("requestMeta")).(audit.RequestMeta)
+	challengeKey := entities.LoginChallengeKey(utils.HashToken(req.ChallengeToken))
+	data, err := s.redisWrapper.Get(ctx, challengeKey)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to find user by email")
-		err = fmt.Errorf("email or password is wrong")
+		slog.ErrorContext(ctx, "failed to find login challenge", "error", err)
+		err = constants.ErrInvalidLoginChallenge
+		return
+	}
+	var challenge entities.LoginChallenge
+	raw, _ := json.Marshal(data)
+	if err = json.Unmarshal(raw, &challenge); err != nil {
+		slog.ErrorContext(ctx, "failed to unmarshal login challenge", "error", err)
+		err = constants.ErrInvalidLoginChallenge
 		return
 	}
 
-	token, exp, err = utils.JwtSign(user.ID, req.Username)
+	err = s.checkLoginBlocked(ctx, challenge.Username, meta.IP)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to sign JWT token")
-		err = fmt.Errorf("Failed to sign JWT token")
+		s.recordLoginFailure(ctx, challenge.Username, "blocked")
 		return
 	}
 
-	newLogin := entities.Login{
-		ID:        user.ID,
-		Username:  user.Username,
-		Password:  user.Password,
-		Email:     user.Email,
-		Name:      user.Name,
-		Balance:   user.Balance,
-		Token:     token,
-		ExpireAt:  exp,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		DeletedAt: user.DeletedAt,
+	user, err := s.userRepository.FindByID(ctx, challenge.UserID)
+	if err != nil || !user.CanLogin() || !user.TOTPEnabled {
+		slog.ErrorContext(ctx, "login challenge no longer applies", "userId", challenge.UserID, "status", user.Status, "error", err)
+		err = constants.ErrInvalidLoginChallenge
+		return
 	}
 
-	err = s.redisWrapper.Set(ctx, req.Username, time.Duration(exp)*time.Second, newLogin)
+	err = s.verifySecondFactor(ctx, user, req.Code)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to store session in Redis")
-		err = fmt.Errorf("failed to create session")
+		slog.ErrorContext(ctx, "failed to login, wrong second factor", "userId", user.ID, "error", err)
+		s.registerLoginFailure(ctx, user.Username, meta.IP)
+		s.recordLoginFailure(ctx, user.Username, "wrong second factor")
 		return
 	}
 
-	respData := LoginResponseData{
-		Token:    token,
-		ExpireAt: exp,
+	if errDelete := s.redisWrapper.Delete(ctx, challengeKey); errDelete != nil {
+		slog.WarnContext(ctx, "failed to drop login challenge", "userId", user.ID, "error", errDelete)
+	}
+	s.clearLoginFailures(ctx, user.Username)
+
+	respData, err := s.openSession(ctx, user, true)
+	if err != nil {
+		return
 	}
+
 	res = constants.DefaultResponse{
 		Status:  constants.STATUS_SUCCESS,
 		Message: constants.MESSAGE_SUCCESS,
 		Data:    respData,
 		Errors:  make([]string, 0),
 	}
+	return
+}
+
+// openSession signs the player in on the requesting device.
+func (s *service) openSession(ctx context.Context, user entities.User, secondFactor bool) (data LoginResponseData, err error) {
+	meta, _ := ctx.Value(types.String("requestMeta")).(audit.RequestMeta)
+	sessionID, err := utils.RandomHex(16)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate session id", "error", err)
+		err = fmt.Errorf("failed to create session")
+		return
+	}
+	session := entities.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		IP:        meta.IP,
+		UserAgent: meta.UserAgent,
+		CreatedAt: time.Now(),
+	}
+	data, err = s.issueTokens(ctx, &session)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store session in Redis", "error", err)
+		err = fmt.Errorf("failed to create session")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Actor:      entities.UserActor(user.ID),
+		Action:     entities.AuditActionLogin,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+		After:      map[string]interface{}{"success": true, "secondFactor": secondFactor},
+	})
+	return
+}
+
+func (s *service) createLoginChallenge(ctx context.Context, user entities.User) (data LoginResponseData, err error) {
+	challengeToken, err := utils.RandomHex(32)
+	if err != nil {
+		return
+	}
+	err = s.redisWrapper.Set(ctx, entities.LoginChallengeKey(utils.HashToken(challengeToken)), loginChallengeTTL, entities.LoginChallenge{
+		UserID:   user.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		return
+	}
+	data = LoginResponseData{
+		TwoFactorRequired: true,
+		ChallengeToken:    challengeToken,
+	}
+	return
+}
+
+// Refresh trades a refresh token for a new access token and a new refresh token. Presenting a
+// refresh token that was already rotated away means it leaked, the whole session is revoked.
+func (s *service) Refresh(ctx context.Context, req RefreshRequest) (res constants.DefaultResponse, err error) {
+	sessionID, _, ok := strings.Cut(req.RefreshToken, ".")
+	if !ok {
+		slog.ErrorContext(ctx, "malformed refresh token")
+		err = constants.ErrInvalidRefreshToken
+		return
+	}
+
+	// * two refreshes racing on one session would both rotate it and strand one of the tokens
+	lockKey := fmt.Sprintf("session-lock:%s", sessionID)
+	locked, err := s.redisWrapper.SetNX(ctx, lockKey, sessionLockTTL, true)
+	if err != nil || !locked {
+		slog.ErrorContext(ctx, "failed to lock session for refresh", "sessionId", sessionID, "error", err)
+		err = fmt.Errorf("refresh already in progress")
+		return
+	}
+	defer func() {
+		if errDelete := s.redisWrapper.Delete(ctx, lockKey); errDelete != nil {
+			slog.WarnContext(ctx, "failed to unlock session", "sessionId", sessionID, "error", errDelete)
+		}
+	}()
+
+	session, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find session", "sessionId", sessionID, "error", err)
+		err = constants.ErrInvalidRefreshToken
+		return
+	}
+
+	hash := utils.HashToken(req.RefreshToken)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(session.RefreshTokenHash)) != 1 {
+		if !slices.Contains(session.UsedRefreshTokenHashes, hash) {
+			slog.ErrorContext(ctx, "unknown refresh token", "sessionId", sessionID)
+			err = constants.ErrInvalidRefreshToken
+			return
+		}
+
+		slog.ErrorContext(ctx, "refresh token reused, revoking session", "sessionId", sessionID, "userId", session.UserID)
+		s.revokeSession(ctx, session.UserID, session.ID)
+		s.auditService.Record(ctx, audit.Entry{
+			Actor:      entities.UserActor(session.UserID),
+			Action:     entities.AuditActionRefreshTokenReuse,
+			TargetType: "session",
+			TargetID:   session.ID,
+		})
+		err = constants.ErrRefreshTokenReused
+		return
+	}
+
+	user, err := s.userRepository.FindByID(ctx, session.UserID)
+	if err != nil || !user.CanLogin() {
+		slog.ErrorContext(ctx, "refresh refused for inactive account", "userId", session.UserID, "status", user.Status, "error", err)
+		s.revokeSession(ctx, session.UserID, session.ID)
+		err = constants.ErrAccountInactive
+		return
+	}
 
+	respData, err := s.issueTokens(ctx, &session)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to rotate session tokens", "sessionId", sessionID, "error", err)
+		err = fmt.Errorf("failed to refresh session")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    respData,
+		Errors:  make([]string, 0),
+	}
 	return
 }
 
+// Logout signs out the device the request came from.
 func (s *service) Logout(ctx context.Context) (res constants.DefaultResponse, err error) {
 	userData, _ := ctx.Value(types.String("user")).(entities.Login)
-	err = s.redisWrapper.Delete(ctx, userData.Username)
+	err = s.redisWrapper.Delete(ctx, entities.SessionKey(userData.SessionID))
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to delete session token from Redis")
 		err = fmt.Errorf("failed to logout")
 		return
 	}
+	if errRemove := s.redisWrapper.SRem(ctx, entities.UserSessionsKey(userData.ID), userData.SessionID); errRemove != nil {
+		slog.WarnContext(ctx, "failed to unlist session", "sessionId", userData.SessionID, "error", errRemove)
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionLogout,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userData.ID),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+
+	return
+}
+
+// LogoutOthers signs out every device of the player except the one the request came from.
+func (s *service) LogoutOthers(ctx context.Context) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	revoked, err := s.revokeSessions(ctx, userData.ID, userData.SessionID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to revoke other sessions", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("failed to logout other devices")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionLogout,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userData.ID),
+		After:      map[string]interface{}{"scope": "others", "revoked": revoked},
+	})
 
 	res = constants.DefaultResponse{
 		Status:  constants.STATUS_SUCCESS,
@@ -173,10 +501,141 @@ func (s *service) Logout(ctx context.Context) (res constants.DefaultResponse, er
 		Data:    "",
 		Errors:  make([]string, 0),
 	}
+	return
+}
+
+func (s *service) GetSessions(ctx context.Context) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	sessionIDs, err := s.redisWrapper.SMembers(ctx, entities.UserSessionsKey(userData.ID))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list sessions", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("failed to list sessions")
+		return
+	}
+
+	sessions := make([]SessionResponseData, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		session, errSession := s.getSession(ctx, sessionID)
+		if errSession != nil {
+			// * the session expired on its own, only its id was left behind
+			if errRemove := s.redisWrapper.SRem(ctx, entities.UserSessionsKey(userData.ID), sessionID); errRemove != nil {
+				slog.WarnContext(ctx, "failed to unlist expired session", "sessionId", sessionID, "error", errRemove)
+			}
+			continue
+		}
+		sessions = append(sessions, SessionResponseData{
+			ID:         session.ID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpireAt:   session.ExpireAt,
+			Current:    session.ID == userData.SessionID,
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    sessions,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// issueTokens rotates the session's refresh token, signs a fresh access token for it and
+// stores the session, sliding its expiry out by the refresh token lifetime.
+func (s *service) issueTokens(ctx context.Context, session *entities.Session) (data LoginResponseData, err error) {
+	secret, err := utils.RandomHex(32)
+	if err != nil {
+		return
+	}
+	refreshToken := fmt.Sprintf("%s.%s", session.ID, secret)
+	if session.RefreshTokenHash != "" {
+		session.UsedRefreshTokenHashes = append(session.UsedRefreshTokenHashes, session.RefreshTokenHash)
+		if len(session.UsedRefreshTokenHashes) > maxUsedRefreshTokens {
+			session.UsedRefreshTokenHashes = session.UsedRefreshTokenHashes[len(session.UsedRefreshTokenHashes)-maxUsedRefreshTokens:]
+		}
+	}
 
+	refreshTTL := configDuration("session.refreshTokenTtl", defaultRefreshTokenTTL)
+	now := time.Now()
+	session.RefreshTokenHash = utils.HashToken(refreshToken)
+	session.LastUsedAt = now
+	session.ExpireAt = now.Add(refreshTTL).Unix()
+
+	token, exp, err := utils.JwtSign(session.UserID, session.Username, session.ID, configDuration("session.accessTokenTtl", defaultAccessTokenTTL))
+	if err != nil {
+		return
+	}
+
+	err = s.redisWrapper.Set(ctx, entities.SessionKey(session.ID), refreshTTL, session)
+	if err != nil {
+		return
+	}
+	err = s.redisWrapper.SAdd(ctx, entities.UserSessionsKey(session.UserID), refreshTTL, session.ID)
+	if err != nil {
+		return
+	}
+
+	data = LoginResponseData{
+		Token:           token,
+		ExpireAt:        exp,
+		RefreshToken:    refreshToken,
+		RefreshExpireAt: session.ExpireAt,
+		SessionID:       session.ID,
+	}
+	return
+}
+
+func (s *service) getSession(ctx context.Context, sessionID string) (session entities.Session, err error) {
+	data, err := s.redisWrapper.Get(ctx, entities.SessionKey(sessionID))
+	if err != nil {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(raw, &session)
+	return
+}
+
+func (s *service) revokeSession(ctx context.Context, userID int, sessionID string) {
+	if err := s.redisWrapper.Delete(ctx, entities.SessionKey(sessionID)); err != nil {
+		slog.ErrorContext(ctx, "failed to revoke session", "sessionId", sessionID, "error", err)
+	}
+	if err := s.redisWrapper.SRem(ctx, entities.UserSessionsKey(userID), sessionID); err != nil {
+		slog.WarnContext(ctx, "failed to unlist session", "sessionId", sessionID, "error", err)
+	}
+}
+
+// revokeSessions signs the player out everywhere except the session to keep, which may be empty.
+func (s *service) revokeSessions(ctx context.Context, userID int, keep string) (revoked int, err error) {
+	sessionIDs, err := s.redisWrapper.SMembers(ctx, entities.UserSessionsKey(userID))
+	if err != nil {
+		return
+	}
+	for _, sessionID := range sessionIDs {
+		if sessionID == keep {
+			continue
+		}
+		s.revokeSession(ctx, userID, sessionID)
+		revoked++
+	}
 	return
 }
 
+func configDuration(key string, fallback time.Duration) time.Duration {
+	if value := config.GetDuration(key); value > 0 {
+		return value
+	}
+	return fallback
+}
+
 func (s *service) GetDetail(ctx context.Context, userID int) (res constants.DefaultResponse, err error) {
 	user, err := s.userRepository.FindByID(ctx, userID)
 	if err != nil {
@@ -184,6 +643,11 @@ func (s *service) GetDetail(ctx context.Context, userID int) (res constants.Defa
 		err = fmt.Errorf("failed to find user by id")
 		return
 	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionViewUser,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
 
 	res = constants.DefaultResponse{
 		Status:  constants.STATUS_SUCCESS,
@@ -230,6 +694,113 @@ func (s *service) GetDetailPlayer(ctx context.Context) (res constants.DefaultRes
 		return
 	}
 
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: PlayerDetailResponseData{
+			User:             user,
+			AvailableBalance: user.AvailableBalance(),
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+// Freeze stops new top ups and withdrawals, the player can still sign in and look around.
+func (s *service) Freeze(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error) {
+	return s.changeStatus(ctx, req, entities.UserStatusFrozen)
+}
+
+// Suspend blocks sign in and drops the player's current session.
+func (s *service) Suspend(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error) {
+	return s.changeStatus(ctx, req, entities.UserStatusSuspended)
+}
+
+// Close soft deletes an account whose balance has been fully paid out. It cannot be undone.
+func (s *service) Close(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error) {
+	return s.changeStatus(ctx, req, entities.UserStatusClosed)
+}
+
+// Activate lifts a freeze or a suspension.
+func (s *service) Activate(ctx context.Context, req ChangeStatusRequest) (res constants.DefaultResponse, err error) {
+	return s.changeStatus(ctx, req, entities.UserStatusActive)
+}
+
+func (s *service) changeStatus(ctx context.Context, req ChangeStatusRequest, status string) (res constants.DefaultResponse, err error) {
+	adminData, _ := ctx.Value(types.String("admin")).(entities.AdminLogin)
+
+	var user entities.User
+	var log entities.UserStatusLog
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		user, err = s.userRepository.FindByIDForUpdate(ctx, req.UserID)
+		if err != nil {
+			return
+		}
+		if user.Status == entities.UserStatusClosed {
+			return constants.ErrAccountClosed
+		}
+		if status == entities.UserStatusClosed && (user.Balance != 0 || user.HeldBalance != 0) {
+			return constants.ErrBalanceNotZero
+		}
+		if status == entities.UserStatusClosed {
+			// * an open virtual account could still be paid into a closed account
+			pending, err := s.transactionRepository.CountPendingByUserID(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			if pending > 0 {
+				return constants.ErrPendingMovements
+			}
+		}
+
+		log = entities.UserStatusLog{
+			UserID:     user.ID,
+			FromStatus: user.Status,
+			ToStatus:   status,
+			Reason:     req.Reason,
+			Actor:      entities.AdminActor(adminData.Username),
+		}
+		user.Status = status
+		if status == entities.UserStatusClosed {
+			now := time.Now()
+			user.DeletedAt = &now
+		}
+		err = s.userRepository.UpdateStatus(ctx, &user)
+		if err != nil {
+			return
+		}
+		return s.userStatusLogRepository.Create(ctx, &log)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to change account status", "userId", req.UserID, "status", status, "error", err)
+		for _, known := range []error{constants.ErrAccountClosed, constants.ErrBalanceNotZero, constants.ErrPendingMovements} {
+			if errors.Is(err, known) {
+				err = known
+				return
+			}
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = fmt.Errorf("failed to find user by id")
+			return
+		}
+		err = fmt.Errorf("failed to change account status")
+		return
+	}
+
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionChangeUserStatus,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+		Before:     map[string]interface{}{"status": log.FromStatus},
+		After:      map[string]interface{}{"status": log.ToStatus, "reason": log.Reason},
+	})
+
+	if !user.CanLogin() {
+		if _, errRevoke := s.revokeSessions(ctx, user.ID, ""); errRevoke != nil {
+			slog.ErrorContext(ctx, "failed to revoke player sessions", "userId", user.ID, "error", errRevoke)
+		}
+	}
+
 	res = constants.DefaultResponse{
 		Status:  constants.STATUS_SUCCESS,
 		Message: constants.MESSAGE_SUCCESS,
@@ -238,3 +809,760 @@ func (s *service) GetDetailPlayer(ctx context.Context) (res constants.DefaultRes
 	}
 	return
 }
+
+func (s *service) GetStatusLog(ctx context.Context, userID int) (res constants.DefaultResponse, err error) {
+	logs, err := s.userStatusLogRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find account status log", "userId", userID, "error", err)
+		err = fmt.Errorf("failed to find account status log")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    logs,
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// SetupTwoFactor starts enrolling an authenticator app. The secret only takes effect once
+// EnableTwoFactor confirms the player can produce codes from it.
+func (s *service) SetupTwoFactor(ctx context.Context) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if user.TOTPEnabled {
+		slog.ErrorContext(ctx, "two factor authentication already enabled", "userId", user.ID)
+		err = constants.ErrTwoFactorEnabled
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate totp secret", "error", err)
+		err = fmt.Errorf("failed to set up two factor authentication")
+		return
+	}
+	err = s.redisWrapper.Set(ctx, entities.TOTPSetupKey(user.ID), totpSetupTTL, secret)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store totp secret", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to set up two factor authentication")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data: TwoFactorSetupResponseData{
+			Secret:          secret,
+			ProvisioningURI: utils.TOTPURI(config.GetString("appName"), user.Username, secret),
+		},
+		Errors: make([]string, 0),
+	}
+	return
+}
+
+// EnableTwoFactor confirms the secret from SetupTwoFactor with a code and hands out the recovery
+// codes, they are shown this once.
+func (s *service) EnableTwoFactor(ctx context.Context, req TwoFactorCodeRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if user.TOTPEnabled {
+		slog.ErrorContext(ctx, "two factor authentication already enabled", "userId", user.ID)
+		err = constants.ErrTwoFactorEnabled
+		return
+	}
+
+	data, err := s.redisWrapper.Get(ctx, entities.TOTPSetupKey(user.ID))
+	secret, _ := data.(string)
+	if err != nil || secret == "" {
+		slog.ErrorContext(ctx, "no two factor setup in progress", "userId", user.ID, "error", err)
+		err = fmt.Errorf("two factor setup expired, start again")
+		return
+	}
+	user.TOTPSecret = secret
+	user.TOTPEnabled = true
+	if err = s.verifyTOTP(ctx, user, req.Code); err != nil {
+		return
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID, func(ctx context.Context) error {
+		return s.userRepository.UpdateTOTP(ctx, &user)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to enable two factor authentication", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to enable two factor authentication")
+		return
+	}
+	if errDelete := s.redisWrapper.Delete(ctx, entities.TOTPSetupKey(user.ID)); errDelete != nil {
+		slog.WarnContext(ctx, "failed to drop totp setup", "userId", user.ID, "error", errDelete)
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionEnableTwoFactor,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    RecoveryCodesResponseData{RecoveryCodes: codes},
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// DisableTwoFactor turns two factor authentication off, it takes both the password and a code.
+func (s *service) DisableTwoFactor(ctx context.Context, req DisableTwoFactorRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if !user.TOTPEnabled {
+		slog.ErrorContext(ctx, "two factor authentication is not enabled", "userId", user.ID)
+		err = constants.ErrTwoFactorNotEnabled
+		return
+	}
+	if !utils.CheckPasswordHash(user.Password, req.Password) {
+		slog.ErrorContext(ctx, "password is wrong", "userId", user.ID)
+		err = fmt.Errorf("password is wrong")
+		return
+	}
+	if err = s.guardedSecondFactor(ctx, user, req.Code); err != nil {
+		return
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if err = s.userRepository.UpdateTOTP(ctx, &user); err != nil {
+			return
+		}
+		return s.userRecoveryCodeRepository.DeleteByUserID(ctx, user.ID)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to disable two factor authentication", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to disable two factor authentication")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionDisableTwoFactor,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the player, used or not.
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, req TwoFactorCodeRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if !user.TOTPEnabled {
+		slog.ErrorContext(ctx, "two factor authentication is not enabled", "userId", user.ID)
+		err = constants.ErrTwoFactorNotEnabled
+		return
+	}
+	if err = s.guardedSecondFactor(ctx, user, req.Code); err != nil {
+		return
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to regenerate recovery codes", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to regenerate recovery codes")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionRegenerateRecoveryCodes,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    RecoveryCodesResponseData{RecoveryCodes: codes},
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// VerifySecondFactor is the step up check in front of sensitive player actions. Players without
+// two factor authentication pass, the others must present a fresh code.
+func (s *service) VerifySecondFactor(ctx context.Context, code string) (err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if !user.TOTPEnabled {
+		return
+	}
+	if code == "" {
+		err = constants.ErrSecondFactorRequired
+		return
+	}
+	return s.guardedSecondFactor(ctx, user, code)
+}
+
+// GetActiveLogin loads the player behind an access token. A suspended or closed account is refused
+// even while its token and session are still around.
+func (s *service) GetActiveLogin(ctx context.Context, userID int) (login entities.Login, err error) {
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if !user.CanLogin() {
+		err = constants.ErrAccountInactive
+		return
+	}
+	login = entities.Login{
+		ID:          user.ID,
+		Name:        user.Name,
+		Username:    user.Username,
+		Email:       user.Email,
+		Balance:     user.Balance,
+		Status:      user.Status,
+		TOTPEnabled: user.TOTPEnabled,
+	}
+	return
+}
+
+// guardedSecondFactor checks a code for a signed in player, throttled like a login so a stolen
+// access token cannot be used to guess codes.
+func (s *service) guardedSecondFactor(ctx context.Context, user entities.User, code string) (err error) {
+	meta, _ := ctx.Value(types.String("requestMeta")).(audit.RequestMeta)
+	if err = s.checkLoginBlocked(ctx, user.Username, meta.IP); err != nil {
+		return
+	}
+	err = s.verifySecondFactor(ctx, user, code)
+	if err != nil {
+		slog.ErrorContext(ctx, "wrong second factor", "userId", user.ID, "error", err)
+		s.registerLoginFailure(ctx, user.Username, meta.IP)
+		return
+	}
+	return
+}
+
+// verifySecondFactor accepts an authenticator code or, failing that, one of the recovery codes.
+func (s *service) verifySecondFactor(ctx context.Context, user entities.User, code string) (err error) {
+	if len(code) == 6 {
+		return s.verifyTOTP(ctx, user, code)
+	}
+
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	ok, err := s.userRecoveryCodeRepository.Use(ctx, user.ID, utils.HashToken(normalized))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to use recovery code", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to verify two factor code")
+		return
+	}
+	if !ok {
+		err = constants.ErrInvalidSecondFactor
+		return
+	}
+	slog.InfoContext(ctx, "recovery code used", "userId", user.ID)
+	return
+}
+
+// verifyTOTP checks an authenticator code and spends its time step, a code seen once is refused after.
+func (s *service) verifyTOTP(ctx context.Context, user entities.User, code string) (err error) {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		err = constants.ErrInvalidSecondFactor
+		return
+	}
+	fresh, err := s.redisWrapper.SetNX(ctx, entities.TOTPUsedKey(user.ID, step), totpReplayTTL, true)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to mark totp code used", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to verify two factor code")
+		return
+	}
+	if !fresh {
+		slog.ErrorContext(ctx, "totp code replayed", "userId", user.ID, "step", step)
+		err = constants.ErrInvalidSecondFactor
+		return
+	}
+	return
+}
+
+// replaceRecoveryCodes swaps the player's recovery codes for a new set in one transaction together
+// with fn, and returns the plain codes.
+func (s *service) replaceRecoveryCodes(ctx context.Context, userID int, fn func(ctx context.Context) error) (codes []string, err error) {
+	records := make([]entities.UserRecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, errRandom := utils.RandomHex(5)
+		if errRandom != nil {
+			err = errRandom
+			return
+		}
+		codes = append(codes, fmt.Sprintf("%s-%s", code[:5], code[5:]))
+		records = append(records, entities.UserRecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(code),
+		})
+	}
+
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) (err error) {
+		if fn != nil {
+			if err = fn(ctx); err != nil {
+				return
+			}
+		}
+		if err = s.userRecoveryCodeRepository.DeleteByUserID(ctx, userID); err != nil {
+			return
+		}
+		return s.userRecoveryCodeRepository.Create(ctx, records)
+	})
+	return
+}
+
+// ChangePassword replaces the password of the signed in player and signs out their other devices.
+func (s *service) ChangePassword(ctx context.Context, req ChangePasswordRequest) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if !utils.CheckPasswordHash(user.Password, req.CurrentPassword) {
+		slog.ErrorContext(ctx, "current password is wrong", "userId", user.ID)
+		err = fmt.Errorf("current password is wrong")
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		slog.ErrorContext(ctx, "new password is the current password", "userId", user.ID)
+		err = fmt.Errorf("new password must differ from the current password")
+		return
+	}
+
+	err = s.setPassword(ctx, &user, req.NewPassword, req.ConfirmPassword)
+	if err != nil {
+		return
+	}
+	if _, errRevoke := s.revokeSessions(ctx, user.ID, userData.SessionID); errRevoke != nil {
+		slog.ErrorContext(ctx, "failed to revoke other sessions", "userId", user.ID, "error", errRevoke)
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionChangePassword,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// VerifyEmail marks the player's email verified with the token mailed to it.
+func (s *service) VerifyEmail(ctx context.Context, req VerifyEmailRequest) (res constants.DefaultResponse, err error) {
+	userIDPart, _, _ := strings.Cut(req.Token, ".")
+	userID, errParse := strconv.Atoi(userIDPart)
+	if errParse != nil {
+		slog.ErrorContext(ctx, "malformed email verification token", "error", errParse)
+		err = constants.ErrInvalidEmailToken
+		return
+	}
+
+	stored, err := s.redisWrapper.Get(ctx, entities.EmailVerificationKey(userID))
+	storedHash, _ := stored.(string)
+	if err != nil || subtle.ConstantTimeCompare([]byte(storedHash), []byte(utils.HashToken(req.Token))) != 1 {
+		slog.ErrorContext(ctx, "email verification token does not match", "userId", userID, "error", err)
+		err = constants.ErrInvalidEmailToken
+		return
+	}
+
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userID, "error", err)
+		err = constants.ErrInvalidEmailToken
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		err = s.userRepository.MarkEmailVerified(ctx, &user)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to mark email verified", "userId", userID, "error", err)
+			err = fmt.Errorf("failed to verify email")
+			return
+		}
+		s.auditService.Record(ctx, audit.Entry{
+			Actor:      entities.UserActor(user.ID),
+			Action:     entities.AuditActionVerifyEmail,
+			TargetType: "user",
+			TargetID:   strconv.Itoa(user.ID),
+			After:      map[string]interface{}{"email": user.Email},
+		})
+	}
+	if errDelete := s.redisWrapper.Delete(ctx, entities.EmailVerificationKey(userID)); errDelete != nil {
+		slog.WarnContext(ctx, "failed to drop email verification token", "userId", userID, "error", errDelete)
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// ResendEmailVerification mails a new verification token, the previous one stops working.
+func (s *service) ResendEmailVerification(ctx context.Context) (res constants.DefaultResponse, err error) {
+	userData, _ := ctx.Value(types.String("user")).(entities.Login)
+	user, err := s.userRepository.FindByID(ctx, userData.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userData.ID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		err = constants.ErrEmailAlreadyVerified
+		return
+	}
+
+	err = s.sendEmailVerification(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to send email verification", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to send email verification")
+		return
+	}
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) sendEmailVerification(ctx context.Context, user entities.User) (err error) {
+	secret, err := utils.RandomHex(32)
+	if err != nil {
+		return
+	}
+	token := fmt.Sprintf("%d.%s", user.ID, secret)
+	ttl := configDuration("email.verificationTokenTtl", defaultEmailVerificationTTL)
+	err = s.redisWrapper.Set(ctx, entities.EmailVerificationKey(user.ID), ttl, utils.HashToken(token))
+	if err != nil {
+		return
+	}
+
+	err = s.notifierWrapper.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this token to verify your email: %s\n\nIt expires in %s.",
+			user.Name, token, ttl),
+	})
+	return
+}
+
+// ForgotPassword sends a single use reset token to the player's email. It answers the same whether
+// the username exists or not so it cannot be used to find accounts.
+func (s *service) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) (res constants.DefaultResponse, err error) {
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+
+	user, errFind := s.userRepository.FindByUsername(ctx, req.Username)
+	if errFind != nil || !user.CanLogin() {
+		slog.WarnContext(ctx, "password reset requested for unknown or inactive account", "username", req.Username, "error", errFind)
+		return
+	}
+
+	secret, err := utils.RandomHex(32)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to generate reset token", "error", err)
+		err = fmt.Errorf("failed to request password reset")
+		return
+	}
+	resetToken := fmt.Sprintf("%d.%s", user.ID, secret)
+	ttl := configDuration("password.resetTokenTtl", defaultPasswordResetTTL)
+	err = s.redisWrapper.Set(ctx, entities.PasswordResetKey(user.ID), ttl, utils.HashToken(resetToken))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store reset token", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to request password reset")
+		return
+	}
+
+	err = s.notifierWrapper.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this token to reset your password: %s\n\nIt expires in %s and works once. If you did not ask for it, ignore this message.",
+			user.Name, resetToken, ttl),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to send reset token", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to request password reset")
+		return
+	}
+	s.auditService.Record(ctx, audit.Entry{
+		Actor:      entities.UserActor(user.ID),
+		Action:     entities.AuditActionForgotPassword,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+	return
+}
+
+// ResetPassword sets a new password with a reset token. The token is spent on use and every
+// session of the player is signed out.
+func (s *service) ResetPassword(ctx context.Context, req ResetPasswordRequest) (res constants.DefaultResponse, err error) {
+	// * a rejected password must leave the token usable for another try
+	err = validatePassword(ctx, req.NewPassword, req.ConfirmPassword)
+	if err != nil {
+		return
+	}
+
+	userIDPart, _, _ := strings.Cut(req.Token, ".")
+	userID, errParse := strconv.Atoi(userIDPart)
+	if errParse != nil {
+		slog.ErrorContext(ctx, "malformed reset token", "error", errParse)
+		err = constants.ErrInvalidResetToken
+		return
+	}
+
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil || !user.CanLogin() {
+		slog.ErrorContext(ctx, "password reset refused for inactive account", "userId", userID, "status", user.Status, "error", err)
+		err = constants.ErrInvalidResetToken
+		return
+	}
+
+	// * spend the token before touching the password, it must not work a second time even if
+	// * the update below fails. The compare and the delete are one step, so two requests racing
+	// * with the same token cannot both pass, and a wrong token leaves the real one in place
+	spent, err := s.redisWrapper.DeleteIfEqual(ctx, entities.PasswordResetKey(userID), utils.HashToken(req.Token))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to spend reset token", "userId", userID, "error", err)
+		err = fmt.Errorf("failed to reset password")
+		return
+	}
+	if !spent {
+		slog.ErrorContext(ctx, "reset token does not match", "userId", userID)
+		err = constants.ErrInvalidResetToken
+		return
+	}
+
+	err = s.setPassword(ctx, &user, req.NewPassword, req.ConfirmPassword)
+	if err != nil {
+		return
+	}
+	if _, errRevoke := s.revokeSessions(ctx, user.ID, ""); errRevoke != nil {
+		slog.ErrorContext(ctx, "failed to revoke sessions", "userId", user.ID, "error", errRevoke)
+	}
+	s.clearLoginFailures(ctx, user.Username)
+	s.auditService.Record(ctx, audit.Entry{
+		Actor:      entities.UserActor(user.ID),
+		Action:     entities.AuditActionResetPassword,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+func (s *service) setPassword(ctx context.Context, user *entities.User, password string, confirmPassword string) (err error) {
+	err = validatePassword(ctx, password, confirmPassword)
+	if err != nil {
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to hash password", "error", err)
+		err = fmt.Errorf("failed to update password")
+		return
+	}
+	user.Password = hashedPassword
+	err = s.userRepository.UpdatePassword(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update password", "userId", user.ID, "error", err)
+		err = fmt.Errorf("failed to update password")
+		return
+	}
+	return
+}
+
+func validatePassword(ctx context.Context, password string, confirmPassword string) (err error) {
+	if !utils.IsValidPassword(password) {
+		slog.ErrorContext(ctx, "password is not valid")
+		err = fmt.Errorf("password is not valid")
+		return
+	}
+	if password != confirmPassword {
+		slog.ErrorContext(ctx, "password is not match")
+		err = fmt.Errorf("password is not match")
+		return
+	}
+	return
+}
+
+// Unlock lifts a login block on the player's username and forgets its failed attempts.
+func (s *service) Unlock(ctx context.Context, userID int) (res constants.DefaultResponse, err error) {
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find user", "userId", userID, "error", err)
+		err = fmt.Errorf("user not found")
+		return
+	}
+
+	err = s.redisWrapper.Delete(ctx, entities.LoginBlockKey(entities.LoginScopeUsername, user.Username))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to lift login block", "userId", userID, "error", err)
+		err = fmt.Errorf("failed to unlock login")
+		return
+	}
+	s.clearLoginFailures(ctx, user.Username)
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionUnlockLogin,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	res = constants.DefaultResponse{
+		Status:  constants.STATUS_SUCCESS,
+		Message: constants.MESSAGE_SUCCESS,
+		Data:    "",
+		Errors:  make([]string, 0),
+	}
+	return
+}
+
+// checkLoginBlocked refuses the attempt while the username or the ip is held back.
+func (s *service) checkLoginBlocked(ctx context.Context, username string, ip string) (err error) {
+	keys := []string{entities.LoginBlockKey(entities.LoginScopeUsername, username)}
+	if ip != "" {
+		keys = append(keys, entities.LoginBlockKey(entities.LoginScopeIP, ip))
+	}
+	for _, key := range keys {
+		ttl, errTTL := s.redisWrapper.GetTTL(ctx, key)
+		if errTTL != nil {
+			slog.ErrorContext(ctx, "failed to check login block", "key", key, "error", errTTL)
+			err = fmt.Errorf("failed to login")
+			return
+		}
+		// * a missing key reports a negative ttl
+		if ttl > 0 {
+			slog.ErrorContext(ctx, "login blocked", "key", key, "retryIn", ttl)
+			err = fmt.Errorf("%w, try again in %d seconds", constants.ErrLoginBlocked, int(math.Ceil(ttl.Seconds())))
+			return
+		}
+	}
+	return
+}
+
+// registerLoginFailure counts a failed attempt. The username is held back for a delay doubling with
+// every failure past the free attempts and locked out once it reaches the limit, the ip is only
+// locked out, at a higher limit since many players may share one.
+func (s *service) registerLoginFailure(ctx context.Context, username string, ip string) {
+	window := configDuration("login.failureWindow", defaultLoginFailureWindow)
+	lockout := configDuration("login.lockoutDuration", defaultLoginLockout)
+
+	failures, err := s.redisWrapper.Incr(ctx, entities.LoginFailureKey(entities.LoginScopeUsername, username), window)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count login failure", "username", username, "error", err)
+	} else if block := loginDelay(int(failures), configInt("login.maxAttempts", defaultLoginMaxAttempts), lockout); block > 0 {
+		if errBlock := s.redisWrapper.Set(ctx, entities.LoginBlockKey(entities.LoginScopeUsername, username), block, failures); errBlock != nil {
+			slog.ErrorContext(ctx, "failed to block login", "username", username, "error", errBlock)
+		}
+	}
+
+	if ip == "" {
+		return
+	}
+	failures, err = s.redisWrapper.Incr(ctx, entities.LoginFailureKey(entities.LoginScopeIP, ip), window)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count login failure", "ip", ip, "error", err)
+		return
+	}
+	if failures >= int64(configInt("login.maxAttemptsPerIp", defaultLoginMaxAttemptsPerIP)) {
+		if errBlock := s.redisWrapper.Set(ctx, entities.LoginBlockKey(entities.LoginScopeIP, ip), lockout, failures); errBlock != nil {
+			slog.ErrorContext(ctx, "failed to block login", "ip", ip, "error", errBlock)
+		}
+	}
+}
+
+func (s *service) clearLoginFailures(ctx context.Context, username string) {
+	if err := s.redisWrapper.Delete(ctx, entities.LoginFailureKey(entities.LoginScopeUsername, username)); err != nil {
+		slog.WarnContext(ctx, "failed to reset login failures", "username", username, "error", err)
+	}
+}
+
+// loginDelay is how long the username waits after its n-th failure in the window.
+func loginDelay(failures int, maxAttempts int, lockout time.Duration) time.Duration {
+	if failures >= maxAttempts {
+		return lockout
+	}
+	if failures < loginFreeAttempts {
+		return 0
+	}
+	delay := time.Second << (failures - loginFreeAttempts)
+	if delay > maxLoginDelay {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+func configInt(key string, fallback int) int {
+	if value := config.GetInt(key); value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func (s *service) recordLoginFailure(ctx context.Context, username string, reason string) {
+	s.auditService.Record(ctx, audit.Entry{
+		Action:     entities.AuditActionLogin,
+		TargetType: "user",
+		TargetID:   username,
+		After:      map[string]interface{}{"success": false, "reason": reason},
+	})
+}
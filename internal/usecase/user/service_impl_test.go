@@ -7,9 +7,14 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/danielpnjt/speed-engine/internal/domain/entities"
+	"github.com/danielpnjt/speed-engine/internal/domain/repositories"
 	mocksRepo "github.com/danielpnjt/speed-engine/internal/domain/repositories/mocks"
+	"github.com/danielpnjt/speed-engine/internal/infrastructure/notifier"
 	mocksWrapperRedis "github.com/danielpnjt/speed-engine/internal/infrastructure/redis/mocks"
 	"github.com/danielpnjt/speed-engine/internal/pkg/constants"
+	"github.com/danielpnjt/speed-engine/internal/pkg/types"
+	"github.com/danielpnjt/speed-engine/internal/pkg/utils"
+	"github.com/danielpnjt/speed-engine/internal/usecase/audit"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
@@ -25,6 +30,15 @@ func init() {
 	// log.New()
 }
 
+type fakeAuditService struct {
+	audit.Service
+	entries []audit.Entry
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, entry audit.Entry) {
+	s.entries = append(s.entries, entry)
+}
+
 func TestValidate(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -73,6 +87,60 @@ func TestValidate(t *testing.T) {
 	})
 
 	service.SetRedisWrapper(mockWrapperRedis)
+
+	t.Run("panic when unitOfWork is nil", func(t *testing.T) {
+		require.Panics(t, func() {
+			service.Validate()
+		}, "unitOfWork is nil")
+	})
+
+	service.SetUnitOfWork(repositories.NewUnitOfWork(mockGorm))
+
+	t.Run("panic when userStatusLogRepository is nil", func(t *testing.T) {
+		require.Panics(t, func() {
+			service.Validate()
+		}, "userStatusLogRepository is nil")
+	})
+
+	service.SetUserStatusLogRepository(repositories.NewUserStatusLog(mockGorm))
+
+	t.Run("panic when auditService is nil", func(t *testing.T) {
+		require.Panics(t, func() {
+			service.Validate()
+		}, "auditService is nil")
+	})
+
+	service.SetAuditService(&fakeAuditService{})
+
+	t.Run("panic when notifierWrapper is nil", func(t *testing.T) {
+		require.Panics(t, func() {
+			service.Validate()
+		}, "notifierWrapper is nil")
+	})
+
+	service.SetNotifierWrapper(notifier.NewLogWrapper(""))
+
+	t.Run("panic when userRecoveryCodeRepository is nil", func(t *testing.T) {
+		require.Panics(t, func() {
+			service.Validate()
+		}, "userRecoveryCodeRepository is nil")
+	})
+
+	service.SetUserRecoveryCodeRepository(repositories.NewUserRecoveryCode(mockGorm))
+
+	t.Run("panic when transactionRepository is nil", func(t *testing.T) {
+		require.Panics(t, func() {
+			service.Validate()
+		}, "transactionRepository is nil")
+	})
+
+	service.SetTransactionRepository(repositories.NewTransaction(mockGorm))
+
+	t.Run("valid when all dependencies are set", func(t *testing.T) {
+		require.NotPanics(t, func() {
+			service.Validate()
+		})
+	})
 }
 
 func TestUserService_Login(t *testing.T) {
@@ -97,6 +165,7 @@ func TestUserService_Login(t *testing.T) {
 		userRepository: mockUserRepo,
 		redisWrapper:   mockWrapperRedis,
 		db:             mockDB,
+		auditService:   &fakeAuditService{},
 	}
 
 	tests := []struct {
@@ -126,7 +195,10 @@ func TestUserService_Login(t *testing.T) {
 				mock.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 			},
 			doMockRedisWrapper: func(mock *mocksWrapperRedis.MockWrapper) {
+				mock.EXPECT().GetTTL(gomock.Any(), entities.LoginBlockKey(entities.LoginScopeUsername, "daniel.pnjt")).Return(time.Duration(-2), nil)
+				mock.EXPECT().Delete(gomock.Any(), entities.LoginFailureKey(entities.LoginScopeUsername, "daniel.pnjt")).Return(nil)
 				mock.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mock.EXPECT().SAdd(gomock.Any(), entities.UserSessionsKey(123), gomock.Any(), gomock.Any()).Return(nil)
 			},
 
 			wantRes: constants.DefaultResponse{
@@ -153,3 +225,337 @@ func TestUserService_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_Login_InactiveAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocksRepo.NewMockUser(ctrl)
+	mockWrapperRedis := mocksWrapperRedis.NewMockWrapper(ctrl)
+	auditService := &fakeAuditService{}
+	service := &service{
+		userRepository: mockUserRepo,
+		redisWrapper:   mockWrapperRedis,
+		auditService:   auditService,
+	}
+
+	for _, status := range []string{entities.UserStatusSuspended, entities.UserStatusClosed} {
+		t.Run(status, func(t *testing.T) {
+			mockWrapperRedis.EXPECT().GetTTL(gomock.Any(), gomock.Any()).Return(time.Duration(-2), nil).Times(1)
+			mockUserRepo.EXPECT().FindByUsername(gomock.Any(), "daniel.pnjt").Return(entities.User{
+				ID:       123,
+				Username: "daniel.pnjt",
+				Password: "$2a$10$0AiqFrWfcar1Cuq9jL8fE.XhHjkrq6O5d26Hwt1t2McO2033hcrZq",
+				Status:   status,
+			}, nil).Times(1)
+
+			_, err := service.Login(context.TODO(), LoginRequest{Username: "daniel.pnjt", Password: "DK!@Password123"})
+			require.ErrorIs(t, err, constants.ErrAccountInactive)
+			require.Equal(t, entities.AuditActionLogin, auditService.entries[len(auditService.entries)-1].Action)
+		})
+	}
+}
+
+func TestUserService_Login_WrongPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocksRepo.NewMockUser(ctrl)
+	mockWrapperRedis := mocksWrapperRedis.NewMockWrapper(ctrl)
+	auditService := &fakeAuditService{}
+	service := &service{
+		userRepository: mockUserRepo,
+		redisWrapper:   mockWrapperRedis,
+		auditService:   auditService,
+	}
+
+	ctx := context.WithValue(context.TODO(), types.String("requestMeta"), audit.RequestMeta{IP: "10.0.0.1"})
+	mockWrapperRedis.EXPECT().GetTTL(gomock.Any(), gomock.Any()).Return(time.Duration(-2), nil).Times(2)
+	mockUserRepo.EXPECT().FindByUsername(gomock.Any(), "daniel.pnjt").Return(entities.User{
+		ID:       123,
+		Username: "daniel.pnjt",
+		Password: "$2a$10$0AiqFrWfcar1Cuq9jL8fE.XhHjkrq6O5d26Hwt1t2McO2033hcrZq",
+		Status:   entities.UserStatusActive,
+	}, nil).Times(1)
+	mockWrapperRedis.EXPECT().Incr(gomock.Any(), entities.LoginFailureKey(entities.LoginScopeUsername, "daniel.pnjt"), gomock.Any()).Return(int64(4), nil)
+	mockWrapperRedis.EXPECT().Set(gomock.Any(), entities.LoginBlockKey(entities.LoginScopeUsername, "daniel.pnjt"), 2*time.Second, gomock.Any()).Return(nil)
+	mockWrapperRedis.EXPECT().Incr(gomock.Any(), entities.LoginFailureKey(entities.LoginScopeIP, "10.0.0.1"), gomock.Any()).Return(int64(4), nil)
+
+	_, err := service.Login(ctx, LoginRequest{Username: "daniel.pnjt", Password: "not-the-password"})
+	require.EqualError(t, err, "username or password is wrong")
+	require.Equal(t, entities.AuditActionLogin, auditService.entries[len(auditService.entries)-1].Action)
+}
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 2, want: 0},
+		{failures: 3, want: time.Second},
+		{failures: 5, want: 4 * time.Second},
+		{failures: 9, want: time.Minute},
+		{failures: 10, want: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, loginDelay(tt.failures, 10, 15*time.Minute), "failures %d", tt.failures)
+	}
+}
+
+func TestUserService_LoginTwoFactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocksRepo.NewMockUser(ctrl)
+	mockWrapperRedis := mocksWrapperRedis.NewMockWrapper(ctrl)
+	service := &service{
+		userRepository: mockUserRepo,
+		redisWrapper:   mockWrapperRedis,
+		auditService:   &fakeAuditService{},
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	require.NoError(t, err)
+	user := entities.User{
+		ID:          123,
+		Username:    "daniel.pnjt",
+		Password:    "$2a$10$0AiqFrWfcar1Cuq9jL8fE.XhHjkrq6O5d26Hwt1t2McO2033hcrZq",
+		Status:      entities.UserStatusActive,
+		TOTPSecret:  secret,
+		TOTPEnabled: true,
+	}
+
+	// * the password alone only earns a challenge
+	var challengeKey string
+	mockWrapperRedis.EXPECT().GetTTL(gomock.Any(), gomock.Any()).Return(time.Duration(-2), nil).AnyTimes()
+	mockUserRepo.EXPECT().FindByUsername(gomock.Any(), "daniel.pnjt").Return(user, nil)
+	mockWrapperRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, key string, _ time.Duration, _ interface{}) error {
+			challengeKey = key
+			return nil
+		})
+
+	res, err := service.Login(context.TODO(), LoginRequest{Username: "daniel.pnjt", Password: "DK!@Password123"})
+	require.NoError(t, err)
+	challenge := res.Data.(LoginResponseData)
+	require.True(t, challenge.TwoFactorRequired)
+	require.Empty(t, challenge.Token)
+	require.Equal(t, entities.LoginChallengeKey(utils.HashToken(challenge.ChallengeToken)), challengeKey)
+
+	code, err := utils.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	mockWrapperRedis.EXPECT().Get(gomock.Any(), challengeKey).Return(entities.LoginChallenge{UserID: 123, Username: "daniel.pnjt"}, nil).Times(2)
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), 123).Return(user, nil).Times(2)
+
+	t.Run("code opens the session", func(t *testing.T) {
+		mockWrapperRedis.EXPECT().SetNX(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+		mockWrapperRedis.EXPECT().Delete(gomock.Any(), challengeKey).Return(nil)
+		mockWrapperRedis.EXPECT().Delete(gomock.Any(), entities.LoginFailureKey(entities.LoginScopeUsername, "daniel.pnjt")).Return(nil)
+		mockWrapperRedis.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockWrapperRedis.EXPECT().SAdd(gomock.Any(), entities.UserSessionsKey(123), gomock.Any(), gomock.Any()).Return(nil)
+
+		res, err := service.LoginTwoFactor(context.TODO(), LoginTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: code})
+		require.NoError(t, err)
+		require.NotEmpty(t, res.Data.(LoginResponseData).Token)
+	})
+
+	t.Run("replayed code is refused", func(t *testing.T) {
+		mockWrapperRedis.EXPECT().SetNX(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+		mockWrapperRedis.EXPECT().Incr(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+
+		_, err := service.LoginTwoFactor(context.TODO(), LoginTwoFactorRequest{ChallengeToken: challenge.ChallengeToken, Code: code})
+		require.ErrorIs(t, err, constants.ErrInvalidSecondFactor)
+	})
+}
+
+func TestUserService_GetActiveLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocksRepo.NewMockUser(ctrl)
+	service := &service{
+		userRepository: mockUserRepo,
+	}
+
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), 123).Return(entities.User{
+		ID:       123,
+		Username: "daniel.pnjt",
+		Balance:  5000,
+		Status:   entities.UserStatusActive,
+	}, nil)
+	login, err := service.GetActiveLogin(context.TODO(), 123)
+	require.NoError(t, err)
+	require.Equal(t, "daniel.pnjt", login.Username)
+	require.Equal(t, float64(5000), login.Balance)
+
+	mockUserRepo.EXPECT().FindByID(gomock.Any(), 124).Return(entities.User{
+		ID:       124,
+		Username: "suspended.player",
+		Status:   entities.UserStatusSuspended,
+	}, nil)
+	_, err = service.GetActiveLogin(context.TODO(), 124)
+	require.ErrorIs(t, err, constants.ErrAccountInactive)
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocksRepo.NewMockUser(ctrl)
+	mockWrapperRedis := mocksWrapperRedis.NewMockWrapper(ctrl)
+	service := &service{
+		userRepository: mockUserRepo,
+		redisWrapper:   mockWrapperRedis,
+		auditService:   &fakeAuditService{},
+	}
+
+	resetToken := "123.secret"
+	req := ResetPasswordRequest{Token: resetToken, NewPassword: "N3wPassword!", ConfirmPassword: "N3wPassword!"}
+
+	activeUser := entities.User{
+		ID:       123,
+		Username: "daniel.pnjt",
+		Status:   entities.UserStatusActive,
+	}
+
+	t.Run("wrong token", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), 123).Return(activeUser, nil)
+		mockWrapperRedis.EXPECT().DeleteIfEqual(gomock.Any(), entities.PasswordResetKey(123), utils.HashToken("123.other")).Return(false, nil)
+		mockUserRepo.EXPECT().UpdatePassword(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := service.ResetPassword(context.TODO(), ResetPasswordRequest{Token: "123.other", NewPassword: "N3wPassword!", ConfirmPassword: "N3wPassword!"})
+		require.ErrorIs(t, err, constants.ErrInvalidResetToken)
+	})
+
+	t.Run("rejected password keeps the token", func(t *testing.T) {
+		mockWrapperRedis.EXPECT().DeleteIfEqual(gomock.Any(), entities.PasswordResetKey(123), gomock.Any()).Times(0)
+
+		_, err := service.ResetPassword(context.TODO(), ResetPasswordRequest{Token: resetToken, NewPassword: "N3wPassword!", ConfirmPassword: "0therPassword!"})
+		require.EqualError(t, err, "password is not match")
+	})
+
+	t.Run("token is spent before the password changes", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), 123).Return(activeUser, nil)
+		gomock.InOrder(
+			mockWrapperRedis.EXPECT().DeleteIfEqual(gomock.Any(), entities.PasswordResetKey(123), utils.HashToken(resetToken)).Return(true, nil),
+			mockUserRepo.EXPECT().UpdatePassword(gomock.Any(), gomock.Any()).Return(nil),
+		)
+		mockWrapperRedis.EXPECT().SMembers(gomock.Any(), entities.UserSessionsKey(123)).Return([]string{}, nil)
+		mockWrapperRedis.EXPECT().Delete(gomock.Any(), entities.LoginFailureKey(entities.LoginScopeUsername, "daniel.pnjt")).Return(nil)
+
+		_, err := service.ResetPassword(context.TODO(), req)
+		require.NoError(t, err)
+	})
+
+	t.Run("a spent token does not work twice", func(t *testing.T) {
+		mockUserRepo.EXPECT().FindByID(gomock.Any(), 123).Return(activeUser, nil)
+		// * the first request already deleted the key
+		mockWrapperRedis.EXPECT().DeleteIfEqual(gomock.Any(), entities.PasswordResetKey(123), utils.HashToken(resetToken)).Return(false, nil)
+		mockUserRepo.EXPECT().UpdatePassword(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := service.ResetPassword(context.TODO(), req)
+		require.ErrorIs(t, err, constants.ErrInvalidResetToken)
+	})
+}
+
+func TestUserService_Refresh_ReusedToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWrapperRedis := mocksWrapperRedis.NewMockWrapper(ctrl)
+	auditService := &fakeAuditService{}
+	service := &service{
+		userRepository: mocksRepo.NewMockUser(ctrl),
+		redisWrapper:   mockWrapperRedis,
+		auditService:   auditService,
+	}
+
+	stolenToken := "5e55102.old-secret"
+	session := entities.Session{
+		ID:                     "5e55102",
+		UserID:                 123,
+		Username:               "daniel.pnjt",
+		RefreshTokenHash:       "current-hash",
+		UsedRefreshTokenHashes: []string{utils.HashToken(stolenToken)},
+	}
+
+	mockWrapperRedis.EXPECT().SetNX(gomock.Any(), "session-lock:5e55102", gomock.Any(), gomock.Any()).Return(true, nil)
+	mockWrapperRedis.EXPECT().Get(gomock.Any(), entities.SessionKey(session.ID)).Return(session, nil)
+	mockWrapperRedis.EXPECT().Delete(gomock.Any(), entities.SessionKey(session.ID)).Return(nil).Times(1)
+	mockWrapperRedis.EXPECT().SRem(gomock.Any(), entities.UserSessionsKey(123), session.ID).Return(nil).Times(1)
+	mockWrapperRedis.EXPECT().Delete(gomock.Any(), "session-lock:5e55102").Return(nil)
+
+	_, err := service.Refresh(context.TODO(), RefreshRequest{RefreshToken: stolenToken})
+	require.ErrorIs(t, err, constants.ErrRefreshTokenReused)
+	require.Equal(t, entities.AuditActionRefreshTokenReuse, auditService.entries[len(auditService.entries)-1].Action)
+}
+
+func TestUserService_Close_BalanceNotZero(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db, mock, _ := sqlmock.New()
+	mockDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	mockUserRepo := mocksRepo.NewMockUser(ctrl)
+	mockUserRepo.EXPECT().FindByIDForUpdate(gomock.Any(), 123).Return(entities.User{
+		ID:       123,
+		Username: "daniel.pnjt",
+		Balance:  5000,
+		Status:   entities.UserStatusActive,
+	}, nil).Times(1)
+	mockUserRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).Times(0)
+
+	service := &service{
+		db:             mockDB,
+		unitOfWork:     repositories.NewUnitOfWork(mockDB),
+		userRepository: mockUserRepo,
+		redisWrapper:   mocksWrapperRedis.NewMockWrapper(ctrl),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	_, err = service.Close(context.TODO(), ChangeStatusRequest{UserID: 123, Reason: "player asked to close"})
+	require.ErrorIs(t, err, constants.ErrBalanceNotZero)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserService_Close_PendingTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db, mock, _ := sqlmock.New()
+	mockDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	mockUserRepo := mocksRepo.NewMockUser(ctrl)
+	mockUserRepo.EXPECT().FindByIDForUpdate(gomock.Any(), 123).Return(entities.User{
+		ID:       123,
+		Username: "daniel.pnjt",
+		Status:   entities.UserStatusActive,
+	}, nil).Times(1)
+	mockUserRepo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).Times(0)
+	mockTransactionRepo := mocksRepo.NewMockTransaction(ctrl)
+	mockTransactionRepo.EXPECT().CountPendingByUserID(gomock.Any(), 123).Return(int64(1), nil).Times(1)
+
+	service := &service{
+		db:                    mockDB,
+		unitOfWork:            repositories.NewUnitOfWork(mockDB),
+		userRepository:        mockUserRepo,
+		transactionRepository: mockTransactionRepo,
+		redisWrapper:          mocksWrapperRedis.NewMockWrapper(ctrl),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	_, err = service.Close(context.TODO(), ChangeStatusRequest{UserID: 123, Reason: "player asked to close"})
+	require.ErrorIs(t, err, constants.ErrPendingMovements)
+	require.NoError(t, mock.ExpectationsWereMet())
+}